@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	jwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	"net/http"
@@ -18,6 +20,15 @@ func LoggingMiddleware(logger log.Logger) Middleware {
 	}
 }
 
+// AuthMiddleware parse bearer token from context and store its claims
+func AuthMiddleware(keyFunc jwt.Keyfunc, claimsFactory kitjwt.ClaimsFactory) Middleware {
+	return Middleware(kitjwt.NewParser(
+		keyFunc,
+		jwt.SigningMethodHS256,
+		claimsFactory,
+	))
+}
+
 // ContentTypeMiddleware return content type json middleware
 func ContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,144 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Audited actions
+const (
+	AuditRegister           = "user.register"
+	AuditLogin              = "user.login"
+	AuditUpdateUser         = "user.update"
+	AuditChangePassword     = "user.password.change"
+	AuditDeleteUser         = "user.delete"
+	AuditRestoreUser        = "user.restore"
+	AuditSuspendUser        = "user.suspend"
+	AuditReactivateUser     = "user.reactivate"
+	AuditDeactivateUser     = "user.deactivate"
+	AuditRevokeSession      = "session.revoke"
+	AuditCreateOrganization = "organization.create"
+	AuditInviteMember       = "organization.invite"
+	AuditAcceptInvitation   = "organization.invitation.accept"
+	AuditDeclineInvitation  = "organization.invitation.decline"
+	AuditChangeMemberRole   = "organization.member.role"
+	AuditRemoveMember       = "organization.member.remove"
+	AuditLeaveOrganization  = "organization.leave"
+	AuditCreateWebhook      = "webhook.create"
+	AuditDeleteWebhook      = "webhook.delete"
+	AuditRedeliverWebhook   = "webhook.redeliver"
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent model struct, append only record of security relevant action.
+// Events of each tenant form a hash chain in sequence order, hash of every
+// event covers its content and hash of previous event of its tenant
+type AuditEvent struct {
+	Sequence  int64     `json:"sequence" db:"sequence"`
+	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	ActorID   string    `json:"actor_id" db:"actor_id"`
+	Target    string    `json:"target"`
+	Action    string    `json:"action"`
+	Outcome   string    `json:"outcome"`
+	Reason    string    `json:"reason"`
+	IP        string    `json:"ip" db:"ip"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	RequestID string    `json:"request_id" db:"request_id"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash" db:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditFilter filtering options for listing audit events, zero value means
+// unfiltered
+type AuditFilter struct {
+	ActorID       string
+	Target        string
+	Action        string
+	Outcome       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// AuditQuery query for listing audit events newest first, Before is zero on
+// first page
+type AuditQuery struct {
+	Filter   AuditFilter
+	PageSize int
+	Before   int64
+}
+
+// AuditRepository interface for audit log, events can only be appended
+type AuditRepository interface {
+	AppendAuditEvent(ctx context.Context, event AuditEvent) error
+	ListAuditEvents(ctx context.Context, query AuditQuery) ([]AuditEvent, error)
+}
+
+// ChainHash compute hash of event chained to previous event hash, creation
+// time is hashed in whole seconds so it survives database round trip
+func (event AuditEvent) ChainHash() string {
+	content, _ := json.Marshal([]interface{}{
+		event.Sequence,
+		event.TenantID.String(),
+		event.ActorID,
+		event.Target,
+		event.Action,
+		event.Outcome,
+		event.Reason,
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		event.CreatedAt.Unix(),
+		event.PrevHash,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain check events listed newest first without filter, each
+// event must match its hash and chain to the next one listed. Oldest event
+// listed is trusted to chain to events before it
+func VerifyAuditChain(events []AuditEvent) error {
+	for i, event := range events {
+		if event.Hash != event.ChainHash() {
+			return fmt.Errorf("%w: event %d was altered", ErrAuditChainBroken, event.Sequence)
+		}
+		if i+1 < len(events) && event.PrevHash != events[i+1].Hash {
+			return fmt.Errorf("%w: event %d does not follow %d", ErrAuditChainBroken, event.Sequence, events[i+1].Sequence)
+		}
+	}
+	return nil
+}
+
+// EncodeAuditPageToken encode sequence of last event into opaque page token
+func EncodeAuditPageToken(sequence int64) string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(strconv.FormatInt(sequence, 10)),
+	)
+}
+
+// DecodeAuditPageToken decode page token into sequence of last event
+func DecodeAuditPageToken(token string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidPageToken
+	}
+	sequence, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || sequence <= 0 {
+		return 0, ErrInvalidPageToken
+	}
+	return sequence, nil
+}
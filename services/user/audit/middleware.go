@@ -0,0 +1,313 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+)
+
+// auditMiddleware records audit event for every security relevant call,
+// read only calls are passed through
+type auditMiddleware struct {
+	next   user.Service
+	events user.AuditRepository
+	logger log.Logger
+}
+
+// NewMiddleware create service middleware appending audit events into repo,
+// failing to append is logged and never fails the audited call
+func NewMiddleware(
+	events user.AuditRepository,
+	logger log.Logger,
+) user.ServiceMiddleware {
+	return func(next user.Service) user.Service {
+		return &auditMiddleware{
+			next:   next,
+			events: events,
+			logger: log.With(logger, "middleware", "audit"),
+		}
+	}
+}
+
+// record append audit event of action on target with outcome of err
+func (mw auditMiddleware) record(
+	ctx context.Context,
+	action, target string,
+	err error,
+) {
+	actorID, _ := auth.UserIDFromContext(ctx)
+	client := user.ClientFromContext(ctx)
+	event := user.AuditEvent{
+		ActorID:   actorID,
+		Target:    target,
+		Action:    action,
+		Outcome:   user.AuditSuccess,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		RequestID: client.RequestID,
+		CreatedAt: time.Now(),
+	}
+	if err != nil {
+		event.Outcome = user.AuditFailure
+		event.Reason = err.Error()
+	}
+	if err := mw.events.AppendAuditEvent(ctx, event); err != nil {
+		_ = level.Error(mw.logger).Log(
+			"action", action,
+			"request_id", client.RequestID,
+			"err", err,
+		)
+	}
+}
+
+func (mw auditMiddleware) Register(
+	ctx context.Context,
+	email, passwords string,
+) (string, error) {
+	status, err := mw.next.Register(ctx, email, passwords)
+	mw.record(ctx, user.AuditRegister, email, err)
+	return status, err
+}
+
+func (mw auditMiddleware) Login(
+	ctx context.Context,
+	email, passwords, deviceLabel string,
+) (string, error) {
+	token, err := mw.next.Login(ctx, email, passwords, deviceLabel)
+	mw.record(ctx, user.AuditLogin, email, err)
+	return token, err
+}
+
+func (mw auditMiddleware) GetMe(ctx context.Context) (*user.User, error) {
+	return mw.next.GetMe(ctx)
+}
+
+func (mw auditMiddleware) GetUser(
+	ctx context.Context,
+	id string,
+) (*user.User, error) {
+	return mw.next.GetUser(ctx, id)
+}
+
+func (mw auditMiddleware) UpdateUser(
+	ctx context.Context,
+	id string,
+	update user.Update,
+) (*user.User, error) {
+	updatedUser, err := mw.next.UpdateUser(ctx, id, update)
+	mw.record(ctx, user.AuditUpdateUser, id, err)
+	return updatedUser, err
+}
+
+func (mw auditMiddleware) ChangePassword(
+	ctx context.Context,
+	id, currentPassword, newPassword string,
+) error {
+	err := mw.next.ChangePassword(ctx, id, currentPassword, newPassword)
+	mw.record(ctx, user.AuditChangePassword, id, err)
+	return err
+}
+
+func (mw auditMiddleware) DeleteUser(ctx context.Context, id string) error {
+	err := mw.next.DeleteUser(ctx, id)
+	mw.record(ctx, user.AuditDeleteUser, id, err)
+	return err
+}
+
+func (mw auditMiddleware) RestoreUser(
+	ctx context.Context,
+	id string,
+) (*user.User, error) {
+	restoredUser, err := mw.next.RestoreUser(ctx, id)
+	mw.record(ctx, user.AuditRestoreUser, id, err)
+	return restoredUser, err
+}
+
+func (mw auditMiddleware) SuspendUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	suspendedUser, err := mw.next.SuspendUser(ctx, id, reason)
+	mw.record(ctx, user.AuditSuspendUser, id, err)
+	return suspendedUser, err
+}
+
+func (mw auditMiddleware) ReactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	reactivatedUser, err := mw.next.ReactivateUser(ctx, id, reason)
+	mw.record(ctx, user.AuditReactivateUser, id, err)
+	return reactivatedUser, err
+}
+
+func (mw auditMiddleware) DeactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	deactivatedUser, err := mw.next.DeactivateUser(ctx, id, reason)
+	mw.record(ctx, user.AuditDeactivateUser, id, err)
+	return deactivatedUser, err
+}
+
+func (mw auditMiddleware) ListUsers(
+	ctx context.Context,
+	filter user.ListFilter,
+	orderBy string,
+	pageSize int,
+	pageToken string,
+) ([]user.User, string, error) {
+	return mw.next.ListUsers(ctx, filter, orderBy, pageSize, pageToken)
+}
+
+func (mw auditMiddleware) CreateOrganization(
+	ctx context.Context,
+	name string,
+) (*user.Organization, error) {
+	organization, err := mw.next.CreateOrganization(ctx, name)
+	target := name
+	if organization != nil {
+		target = organization.ID.String()
+	}
+	mw.record(ctx, user.AuditCreateOrganization, target, err)
+	return organization, err
+}
+
+func (mw auditMiddleware) ListOrganizations(
+	ctx context.Context,
+) ([]user.Organization, error) {
+	return mw.next.ListOrganizations(ctx)
+}
+
+func (mw auditMiddleware) InviteMember(
+	ctx context.Context,
+	organizationID, email, role string,
+) (*user.Invitation, string, error) {
+	invitation, token, err := mw.next.InviteMember(ctx, organizationID, email, role)
+	mw.record(ctx, user.AuditInviteMember, organizationID, err)
+	return invitation, token, err
+}
+
+func (mw auditMiddleware) AcceptInvitation(
+	ctx context.Context,
+	token string,
+) (*user.Membership, error) {
+	membership, err := mw.next.AcceptInvitation(ctx, token)
+	target := ""
+	if membership != nil {
+		target = membership.OrganizationID.String()
+	}
+	mw.record(ctx, user.AuditAcceptInvitation, target, err)
+	return membership, err
+}
+
+func (mw auditMiddleware) DeclineInvitation(
+	ctx context.Context,
+	token string,
+) error {
+	err := mw.next.DeclineInvitation(ctx, token)
+	mw.record(ctx, user.AuditDeclineInvitation, "", err)
+	return err
+}
+
+func (mw auditMiddleware) ListMembers(
+	ctx context.Context,
+	organizationID string,
+) ([]user.Membership, error) {
+	return mw.next.ListMembers(ctx, organizationID)
+}
+
+func (mw auditMiddleware) ChangeMemberRole(
+	ctx context.Context,
+	organizationID, userID, role string,
+) (*user.Membership, error) {
+	membership, err := mw.next.ChangeMemberRole(ctx, organizationID, userID, role)
+	mw.record(ctx, user.AuditChangeMemberRole, userID, err)
+	return membership, err
+}
+
+func (mw auditMiddleware) RemoveMember(
+	ctx context.Context,
+	organizationID, userID string,
+) error {
+	err := mw.next.RemoveMember(ctx, organizationID, userID)
+	mw.record(ctx, user.AuditRemoveMember, userID, err)
+	return err
+}
+
+func (mw auditMiddleware) LeaveOrganization(
+	ctx context.Context,
+	organizationID string,
+) error {
+	err := mw.next.LeaveOrganization(ctx, organizationID)
+	mw.record(ctx, user.AuditLeaveOrganization, organizationID, err)
+	return err
+}
+
+func (mw auditMiddleware) ListSessions(
+	ctx context.Context,
+) ([]user.Session, error) {
+	return mw.next.ListSessions(ctx)
+}
+
+func (mw auditMiddleware) RevokeSession(ctx context.Context, id string) error {
+	err := mw.next.RevokeSession(ctx, id)
+	mw.record(ctx, user.AuditRevokeSession, id, err)
+	return err
+}
+
+func (mw auditMiddleware) ListAuditEvents(
+	ctx context.Context,
+	filter user.AuditFilter,
+	pageSize int,
+	pageToken string,
+) ([]user.AuditEvent, string, error) {
+	return mw.next.ListAuditEvents(ctx, filter, pageSize, pageToken)
+}
+
+func (mw auditMiddleware) CreateWebhook(
+	ctx context.Context,
+	url string,
+	events []string,
+) (*user.Webhook, string, error) {
+	webhook, secret, err := mw.next.CreateWebhook(ctx, url, events)
+	target := url
+	if webhook != nil {
+		target = webhook.ID.String()
+	}
+	mw.record(ctx, user.AuditCreateWebhook, target, err)
+	return webhook, secret, err
+}
+
+func (mw auditMiddleware) ListWebhooks(
+	ctx context.Context,
+) ([]user.Webhook, error) {
+	return mw.next.ListWebhooks(ctx)
+}
+
+func (mw auditMiddleware) DeleteWebhook(ctx context.Context, id string) error {
+	err := mw.next.DeleteWebhook(ctx, id)
+	mw.record(ctx, user.AuditDeleteWebhook, id, err)
+	return err
+}
+
+func (mw auditMiddleware) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID, status string,
+) ([]user.WebhookDelivery, error) {
+	return mw.next.ListWebhookDeliveries(ctx, webhookID, status)
+}
+
+func (mw auditMiddleware) RedeliverWebhook(
+	ctx context.Context,
+	deliveryID string,
+) (*user.WebhookDelivery, error) {
+	delivery, err := mw.next.RedeliverWebhook(ctx, deliveryID)
+	mw.record(ctx, user.AuditRedeliverWebhook, deliveryID, err)
+	return delivery, err
+}
@@ -0,0 +1,99 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/log"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/audit"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+)
+
+// events fake audit repository keeping appended events, appends fail while
+// err is set
+type events struct {
+	user.AuditRepository
+	appended []user.AuditEvent
+	err      error
+}
+
+func (repo *events) AppendAuditEvent(_ context.Context, event user.AuditEvent) error {
+	if repo.err != nil {
+		return repo.err
+	}
+	repo.appended = append(repo.appended, event)
+	return nil
+}
+
+// service fake service failing every call with err
+type service struct {
+	user.Service
+	err error
+}
+
+func (s service) Login(context.Context, string, string, string) (string, error) {
+	return "token", s.err
+}
+
+func (s service) DeleteUser(context.Context, string) error {
+	return s.err
+}
+
+func (s service) GetMe(context.Context) (*user.User, error) {
+	return &user.User{}, s.err
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	repo := &events{}
+	next := &service{}
+	mw := audit.NewMiddleware(repo, log.NewNopLogger())(next)
+	ctx := user.ContextWithClient(context.Background(), user.Client{
+		IP:        "203.0.113.9",
+		UserAgent: "curl/7.68.0",
+		RequestID: "request",
+	})
+	admin := context.WithValue(ctx, kitjwt.JWTClaimsContextKey, &auth.Claims{
+		StandardClaims: jwt.StandardClaims{Subject: "admin"},
+	})
+
+	_, _ = mw.Login(ctx, "ada@example.com", "password", "")
+	next.err = user.ErrInvalidCredentials
+	_, _ = mw.Login(ctx, "ada@example.com", "wrong", "")
+	next.err = nil
+	_ = mw.DeleteUser(admin, "42")
+	// Reads are not audited
+	_, _ = mw.GetMe(admin)
+
+	want := []user.AuditEvent{
+		{Action: user.AuditLogin, Target: "ada@example.com", Outcome: user.AuditSuccess},
+		{Action: user.AuditLogin, Target: "ada@example.com", Outcome: user.AuditFailure, Reason: user.ErrInvalidCredentials.Error()},
+		{Action: user.AuditDeleteUser, Target: "42", Outcome: user.AuditSuccess, ActorID: "admin"},
+	}
+	if len(repo.appended) != len(want) {
+		t.Fatalf("appended %d events, want %d", len(repo.appended), len(want))
+	}
+	for i, event := range repo.appended {
+		w := want[i]
+		if event.Action != w.Action || event.Target != w.Target || event.Outcome != w.Outcome ||
+			event.Reason != w.Reason || event.ActorID != w.ActorID {
+			t.Errorf("event %d: %+v, want %+v", i, event, w)
+		}
+		if event.IP != "203.0.113.9" || event.UserAgent != "curl/7.68.0" || event.RequestID != "request" {
+			t.Errorf("event %d: client %s %s %s", i, event.IP, event.UserAgent, event.RequestID)
+		}
+		if event.CreatedAt.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+
+	// Failing audit log does not fail audited call
+	repo.err = errors.New("audit log is down")
+	if err := mw.DeleteUser(admin, "42"); err != nil {
+		t.Fatalf("delete with failing audit log: %v", err)
+	}
+}
@@ -1,6 +1,25 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	amqptransport "github.com/go-kit/kit/transport/amqp"
+	natstransport "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/nats.go"
+	"github.com/streadway/amqp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenDuration lifetime of issued token
+const TokenDuration = 24 * time.Hour
+
+// ErrUnauthenticated returned when context has no valid token claims
+var ErrUnauthenticated = errors.New("unauthenticated")
 
 // HashPassword hashing password
 func HashPassword(password string) ([]byte, error) {
@@ -17,3 +36,86 @@ func VerifyPassword(hashedPassword string, password string) error {
 		[]byte(password),
 	)
 }
+
+// Claims token claims, user id is the subject and session id is the
+// token id
+type Claims struct {
+	TenantID string `json:"tid"`
+	jwt.StandardClaims
+}
+
+// ClaimsFactory create empty claims for token parser
+func ClaimsFactory() jwt.Claims {
+	return &Claims{}
+}
+
+// GenerateToken create signed token with user id as subject bound to session
+func GenerateToken(secret, userID, tenantID, sessionID string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		TenantID: tenantID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        sessionID,
+			Subject:   userID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(TokenDuration).Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+		SignedString([]byte(secret))
+}
+
+// KeyFunc returns jwt key function for secret
+func KeyFunc(secret string) jwt.Keyfunc {
+	return func(_ *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}
+}
+
+// UserIDFromContext get authenticated user id from parsed token claims
+func UserIDFromContext(ctx context.Context) (string, error) {
+	claims, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*Claims)
+	if !ok || claims.Subject == "" {
+		return "", ErrUnauthenticated
+	}
+	return claims.Subject, nil
+}
+
+// SessionIDFromContext get session id of authenticated token
+func SessionIDFromContext(ctx context.Context) (string, error) {
+	claims, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*Claims)
+	if !ok || claims.Id == "" {
+		return "", ErrUnauthenticated
+	}
+	return claims.Id, nil
+}
+
+// NATSToContext moves bearer token from Authorization header of message to
+// context, counterpart of kitjwt HTTPToContext for nats subscribers
+func NATSToContext() natstransport.RequestFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		return bearerToContext(ctx, msg.Header.Get("Authorization"))
+	}
+}
+
+// AMQPToContext moves bearer token from Authorization header of delivery to
+// context
+func AMQPToContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return bearerToContext(ctx, TableValue(d.Headers, "Authorization"))
+	}
+}
+
+// TableValue returns string value of amqp header, empty when absent
+func TableValue(headers amqp.Table, key string) string {
+	value, _ := headers[key].(string)
+	return value
+}
+
+// bearerToContext moves token of bearer authorization header to context
+func bearerToContext(ctx context.Context, header string) context.Context {
+	if len(header) < 7 || !strings.EqualFold(header[:7], "bearer ") {
+		return ctx
+	}
+	return context.WithValue(ctx, kitjwt.JWTTokenContextKey, header[7:])
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	amqptransport "github.com/go-kit/kit/transport/amqp"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	httptransport "github.com/go-kit/kit/transport/http"
+	natstransport "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/nats.go"
+	uuid "github.com/satori/go.uuid"
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// RequestIDHeader header carrying request id, generated when absent
+const RequestIDHeader = "X-Request-ID"
+
+// TrustedProxies networks of proxies whose forwarded for header is honored
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parse comma separated networks or addresses of
+// trusted proxies, e.g. "10.0.0.0/8,127.0.0.1"
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			ip := net.ParseIP(field)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", field)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", field, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains tells whether ip is address of trusted proxy
+func (proxies TrustedProxies) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range proxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns address of client connected from peer. Forwarded chain
+// is walked from the right while hops are trusted proxies, so addresses
+// prepended by client itself are never taken
+func (proxies TrustedProxies) clientIP(peer, forwarded string) string {
+	ip := host(peer)
+	if forwarded == "" {
+		return ip
+	}
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0 && proxies.Contains(ip); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip
+}
+
+// HTTPToClientContext moves caller ip, user agent and request id of request
+// to context, forwarded for header is only honored from trusted proxies
+func HTTPToClientContext(proxies TrustedProxies) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return user.ContextWithClient(ctx, user.Client{
+			IP:        proxies.clientIP(r.RemoteAddr, r.Header.Get("X-Forwarded-For")),
+			UserAgent: r.UserAgent(),
+			RequestID: requestID(r.Header.Get(RequestIDHeader)),
+		})
+	}
+}
+
+// GRPCToClientContext moves caller ip, user agent and request id of call to
+// context, forwarded for metadata is only honored from trusted proxies.
+// Calls of in process gateway carry no peer, gateway itself is trusted hop
+// then and last address it forwards is the one of its peer
+func GRPCToClientContext(proxies TrustedProxies) grpctransport.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		forwarded := strings.Join(md.Get("x-forwarded-for"), ",")
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			addr = p.Addr.String()
+		} else if i := strings.LastIndex(forwarded, ","); i >= 0 {
+			addr, forwarded = strings.TrimSpace(forwarded[i+1:]), forwarded[:i]
+		} else {
+			addr, forwarded = strings.TrimSpace(forwarded), ""
+		}
+		ip := proxies.clientIP(addr, forwarded)
+		userAgent := first(md, "grpcgateway-user-agent")
+		if userAgent == "" {
+			userAgent = first(md, "user-agent")
+		}
+		return user.ContextWithClient(ctx, user.Client{
+			IP:        ip,
+			UserAgent: userAgent,
+			RequestID: requestID(first(md, strings.ToLower(RequestIDHeader))),
+		})
+	}
+}
+
+// NATSToClientContext moves caller ip, user agent and request id of message
+// to context, broker hides publisher address so ip is only known when
+// publisher forwards it. Publishers authenticate to broker, so they are
+// trusted to forward it
+func NATSToClientContext() natstransport.RequestFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		return user.ContextWithClient(ctx, user.Client{
+			IP:        forwardedFor(msg.Header.Get("X-Forwarded-For")),
+			UserAgent: msg.Header.Get("User-Agent"),
+			RequestID: requestID(msg.Header.Get(RequestIDHeader)),
+		})
+	}
+}
+
+// AMQPToClientContext moves caller ip, user agent and request id of delivery
+// to context, message id stands in for request id when header is absent
+func AMQPToClientContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		id := TableValue(d.Headers, RequestIDHeader)
+		if id == "" {
+			id = d.MessageId
+		}
+		return user.ContextWithClient(ctx, user.Client{
+			IP:        forwardedFor(TableValue(d.Headers, "X-Forwarded-For")),
+			UserAgent: TableValue(d.Headers, "User-Agent"),
+			RequestID: requestID(id),
+		})
+	}
+}
+
+// SessionMiddleware reject token whose session is revoked and keep last seen
+// time of session up to date, must run inside tenant middleware
+func SessionMiddleware(sessions user.SessionRepository) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			id, err := SessionIDFromContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			sessionID, err := uuid.FromString(id)
+			if err != nil {
+				return nil, ErrUnauthenticated
+			}
+			session, err := sessions.GetSession(ctx, sessionID)
+			if err == user.ErrSessionNotFound {
+				return nil, ErrUnauthenticated
+			}
+			if err != nil {
+				return nil, err
+			}
+			if session.RevokedAt != nil {
+				return nil, user.ErrSessionRevoked
+			}
+			now := time.Now()
+			if now.Sub(session.LastSeenAt) >= user.SessionTouchInterval {
+				if err := sessions.TouchSession(ctx, sessionID, now); err != nil {
+					return nil, err
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// forwardedFor returns client address, the left most of forwarded chain
+func forwardedFor(value string) string {
+	return strings.TrimSpace(strings.Split(value, ",")[0])
+}
+
+// requestID returns given request id or new one when empty
+func requestID(value string) string {
+	if value == "" {
+		return uuid.NewV4().String()
+	}
+	return value
+}
+
+// host strips port from address
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
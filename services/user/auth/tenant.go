@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	amqptransport "github.com/go-kit/kit/transport/amqp"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	httptransport "github.com/go-kit/kit/transport/http"
+	natstransport "github.com/go-kit/kit/transport/nats"
+	"github.com/nats-io/nats.go"
+	uuid "github.com/satori/go.uuid"
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type contextKey string
+
+// TenantKeyContextKey holds tenant id or slug requested by client
+const TenantKeyContextKey contextKey = "TenantKey"
+
+// TenantHeader header carrying tenant id or slug
+const TenantHeader = "X-Tenant-ID"
+
+// HTTPToTenantContext moves requested tenant from header or subdomain
+// of base domain to context
+func HTTPToTenantContext(baseDomain string) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		key := r.Header.Get(TenantHeader)
+		if key == "" {
+			key = subdomain(r.Host, baseDomain)
+		}
+		return context.WithValue(ctx, TenantKeyContextKey, key)
+	}
+}
+
+// GRPCToTenantContext moves requested tenant from metadata or subdomain
+// of base domain to context
+func GRPCToTenantContext(baseDomain string) grpctransport.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		key := first(md, strings.ToLower(TenantHeader))
+		if key == "" {
+			host := first(md, "x-forwarded-host")
+			if host == "" {
+				host = first(md, ":authority")
+			}
+			key = subdomain(host, baseDomain)
+		}
+		return context.WithValue(ctx, TenantKeyContextKey, key)
+	}
+}
+
+// NATSToTenantContext moves requested tenant from message header to context,
+// messages have no host so tenant can not be resolved from subdomain
+func NATSToTenantContext() natstransport.RequestFunc {
+	return func(ctx context.Context, msg *nats.Msg) context.Context {
+		return context.WithValue(ctx, TenantKeyContextKey, msg.Header.Get(TenantHeader))
+	}
+}
+
+// AMQPToTenantContext moves requested tenant from delivery header to context
+func AMQPToTenantContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, TenantKeyContextKey, TableValue(d.Headers, TenantHeader))
+	}
+}
+
+// TenantMiddleware scope context to tenant, tenant from token claims takes
+// precedence and must match requested tenant when both are present. Request
+// naming no tenant goes to default one when it is set
+func TenantMiddleware(tenants user.TenantRepository, defaultTenant string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			key, _ := ctx.Value(TenantKeyContextKey).(string)
+			claims, ok := ctx.Value(kitjwt.JWTClaimsContextKey).(*Claims)
+			if !ok {
+				if key == "" {
+					key = defaultTenant
+				}
+				if key == "" {
+					return nil, user.ErrTenantRequired
+				}
+				tenant, err := tenants.GetTenant(ctx, key)
+				if err != nil {
+					return nil, err
+				}
+				return next(user.ContextWithTenant(ctx, tenant.ID), request)
+			}
+			tenantID, err := uuid.FromString(claims.TenantID)
+			if err != nil {
+				return nil, ErrUnauthenticated
+			}
+			if key != "" {
+				tenant, err := tenants.GetTenant(ctx, key)
+				if err != nil || tenant.ID != tenantID {
+					return nil, user.ErrTenantMismatch
+				}
+			}
+			return next(user.ContextWithTenant(ctx, tenantID), request)
+		}
+	}
+}
+
+// subdomain returns left most label of host under base domain
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	suffix := "." + baseDomain
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	labels := strings.Split(strings.TrimSuffix(host, suffix), ".")
+	return labels[len(labels)-1]
+}
+
+// first returns first metadata value of key
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+)
+
+// tenants fake repository of tenants looked up by id or slug
+type tenants []user.Tenant
+
+func (repo tenants) GetTenant(_ context.Context, key string) (*user.Tenant, error) {
+	for _, tenant := range repo {
+		if tenant.ID.String() == key || tenant.Slug == key {
+			return &tenant, nil
+		}
+	}
+	return nil, user.ErrTenantNotFound
+}
+
+func (repo tenants) CreateTenant(context.Context, user.Tenant) error {
+	return nil
+}
+
+func TestHTTPToTenantContext(t *testing.T) {
+	resolve := auth.HTTPToTenantContext("example.com")
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		want   string
+	}{
+		{"subdomain", "acme.example.com", "", "acme"},
+		{"subdomain with port", "acme.example.com:8080", "", "acme"},
+		{"nested subdomain", "api.acme.example.com", "", "acme"},
+		{"header wins over subdomain", "acme.example.com", "globex", "globex"},
+		{"other domain", "acme.example.org", "", ""},
+		{"base domain itself", "example.com", "", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://"+tt.host+"/v1/users/me", nil)
+		if tt.header != "" {
+			r.Header.Set(auth.TenantHeader, tt.header)
+		}
+		key, _ := resolve(context.Background(), r).Value(auth.TenantKeyContextKey).(string)
+		if key != tt.want {
+			t.Errorf("%s: tenant %q, want %q", tt.name, key, tt.want)
+		}
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	acme := user.Tenant{ID: uuid.NewV4(), Slug: "acme"}
+	globex := user.Tenant{ID: uuid.NewV4(), Slug: "globex"}
+	repo := tenants{acme, globex}
+	claims := func(tenant user.Tenant) *auth.Claims {
+		claims := &auth.Claims{TenantID: tenant.ID.String()}
+		claims.Subject = uuid.NewV4().String()
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		key           string
+		claims        *auth.Claims
+		defaultTenant string
+		want          uuid.UUID
+		err           error
+	}{
+		{"requested tenant", "acme", nil, "", acme.ID, nil},
+		{"requested by id", globex.ID.String(), nil, "", globex.ID, nil},
+		{"default tenant", "", nil, "globex", globex.ID, nil},
+		{"no tenant", "", nil, "", uuid.Nil, user.ErrTenantRequired},
+		{"unknown tenant", "initech", nil, "", uuid.Nil, user.ErrTenantNotFound},
+		{"tenant of token", "", claims(acme), "globex", acme.ID, nil},
+		{"token of requested tenant", "acme", claims(acme), "", acme.ID, nil},
+		{"token of other tenant", "globex", claims(acme), "", uuid.Nil, user.ErrTenantMismatch},
+		{"token without tenant", "", &auth.Claims{}, "", uuid.Nil, auth.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		var scoped uuid.UUID
+		next := func(ctx context.Context, _ interface{}) (interface{}, error) {
+			scoped, _ = user.TenantFromContext(ctx)
+			return nil, nil
+		}
+		ctx := context.WithValue(context.Background(), auth.TenantKeyContextKey, tt.key)
+		if tt.claims != nil {
+			ctx = context.WithValue(ctx, kitjwt.JWTClaimsContextKey, tt.claims)
+		}
+		_, err := auth.TenantMiddleware(repo, tt.defaultTenant)(next)(ctx, nil)
+		if err != tt.err || scoped != tt.want {
+			t.Errorf("%s: tenant %s, err %v, want %s, err %v", tt.name, scoped, err, tt.want, tt.err)
+		}
+	}
+}
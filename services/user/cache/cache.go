@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values by key until their ttl passes. Implementations may be
+// shared by several processes, e.g. backed by distributed cache, so values
+// are kept encoded
+type Cache interface {
+	// Get returns value of key, false when it is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value of key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys, missing ones are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,93 @@
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// fakeCache in memory stand in for distributed cache, values are copied in
+// and out as they would be sent over network and it fails every call while
+// err is set
+type fakeCache struct {
+	mutex   sync.Mutex
+	values  map[string][]byte
+	expires map[string]time.Time
+	err     error
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{
+		values:  map[string][]byte{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (c *fakeCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return nil, false, c.err
+	}
+	value, ok := c.values[key]
+	if !ok || time.Now().After(c.expires[key]) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), value...), true, nil
+}
+
+func (c *fakeCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.values[key] = append([]byte(nil), value...)
+	c.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (c *fakeCache) Delete(_ context.Context, keys ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	for _, key := range keys {
+		delete(c.values, key)
+		delete(c.expires, key)
+	}
+	return nil
+}
+
+// counter metrics counter keeping totals by label values, e.g. "lookup=id"
+type counter struct {
+	mutex  *sync.Mutex
+	totals map[string]float64
+	labels string
+}
+
+func newCounter() counter {
+	return counter{mutex: &sync.Mutex{}, totals: map[string]float64{}}
+}
+
+func (c counter) With(labelValues ...string) metrics.Counter {
+	c.labels = strings.Join(labelValues, "=")
+	return c
+}
+
+func (c counter) Add(delta float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.totals[c.labels] += delta
+}
+
+// total returns total counted with labels
+func (c counter) total(labels string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.totals[labels]
+}
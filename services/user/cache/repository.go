@@ -0,0 +1,412 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository"
+)
+
+// negative value cached for lookups by id that found no user
+var negative = []byte("null")
+
+// lookupTimeout longest time shared lookup runs, it is detached from
+// contexts of its callers so their deadlines do not end it
+const lookupTimeout = 30 * time.Second
+
+// fenceStripes number of write counters keys are spread over
+const fenceStripes = 256
+
+// fence counts writes by stripe of key they invalidate, lookup storing what
+// it loaded checks no write to its key began since it started loading
+type fence [fenceStripes]uint64
+
+// version returns count of writes to stripe of key
+func (f *fence) version(key string) uint64 {
+	return atomic.LoadUint64(f.stripe(key))
+}
+
+// bump counts write to keys
+func (f *fence) bump(keys []string) {
+	for _, key := range keys {
+		atomic.AddUint64(f.stripe(key), 1)
+	}
+}
+
+// stripe returns counter of key
+func (f *fence) stripe(key string) *uint64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &f[h.Sum32()%fenceStripes]
+}
+
+// cachedRepository read through cache of user lookups by id and email. Users
+// are cached by id without their password hash, logins finding user go to
+// next so only emails of no user are cached
+type cachedRepository struct {
+	next        user.Repository
+	cache       Cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+	fence       fence
+	hits        metrics.Counter
+	misses      metrics.Counter
+	logger      log.Logger
+}
+
+// NewRepository create repository caching lookups of next in cache for ttl,
+// lookups finding no user are cached for negativeTTL. Concurrent misses of
+// same key share one lookup run on primary outside of their units of work.
+// Writes drop cached entries right away and again once their unit of work
+// commits, failing cache is logged and bypassed. Users returned by GetUser
+// have no password hash, Login returns it
+func NewRepository(
+	next user.Repository,
+	cache Cache,
+	ttl, negativeTTL time.Duration,
+	hits, misses metrics.Counter,
+	logger log.Logger,
+) user.Repository {
+	return &cachedRepository{
+		next:        next,
+		cache:       cache,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		hits:        hits,
+		misses:      misses,
+		logger:      log.With(logger, "repository", "cache"),
+	}
+}
+
+// Register stores user and drops negative entries of its email
+func (repo *cachedRepository) Register(ctx context.Context, newUser user.User) error {
+	if err := repo.next.Register(ctx, newUser); err != nil {
+		return err
+	}
+	tenantID, err := user.TenantFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	repo.invalidate(ctx, idKey(tenantID, newUser.ID), emailKey(tenantID, newUser.Email))
+	return nil
+}
+
+// Login returns no user for email cached as missing, user found is returned
+// by next with its password hash and is not cached. Entry of email holds
+// email that found no user, so other casing of it is looked up on its own
+// where database tells casings apart
+func (repo *cachedRepository) Login(
+	ctx context.Context,
+	email, passwords string,
+) (*user.User, error) {
+	tenantID, err := user.TenantFromContext(ctx)
+	if err != nil || repository.Pinned(ctx) {
+		return repo.next.Login(ctx, email, passwords)
+	}
+	key := emailKey(tenantID, email)
+	if value, ok := repo.get(ctx, key); ok && string(value) == email {
+		repo.hits.With("lookup", "email").Add(1)
+		return nil, user.ErrUserNotFound
+	}
+	repo.misses.With("lookup", "email").Add(1)
+
+	return repo.lookup(ctx, key+":"+email, tenantID, func(ctx context.Context) (*user.User, error) {
+		version := repo.fence.version(key)
+		found, err := repo.next.Login(ctx, email, passwords)
+		if repo.notFound(ctx, err) {
+			repo.fill(ctx, version, key, []byte(email), repo.negativeTTL)
+		}
+		return found, err
+	})
+}
+
+// GetUser returns user of id from cache, without password hash whether it
+// was cached or not
+func (repo *cachedRepository) GetUser(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	tenantID, err := user.TenantFromContext(ctx)
+	if err != nil || repository.Pinned(ctx) {
+		found, err := repo.next.GetUser(ctx, id)
+		return withoutPassword(found), err
+	}
+	key := idKey(tenantID, id)
+	if cached, ok := repo.user(ctx, key); ok {
+		repo.hits.With("lookup", "id").Add(1)
+		if cached == nil {
+			return nil, user.ErrUserNotFound
+		}
+		return cached, nil
+	}
+	repo.misses.With("lookup", "id").Add(1)
+
+	found, err := repo.lookup(ctx, key, tenantID, func(ctx context.Context) (*user.User, error) {
+		version := repo.fence.version(key)
+		found, err := repo.next.GetUser(ctx, id)
+		switch {
+		case repo.notFound(ctx, err):
+			repo.fill(ctx, version, key, negative, repo.negativeTTL)
+		case err == nil:
+			repo.fillUser(ctx, version, key, found)
+		}
+		return found, err
+	})
+	return withoutPassword(found), err
+}
+
+// UpdateUser updates user and drops its entry, changed email has its
+// negative entry dropped as well
+func (repo *cachedRepository) UpdateUser(
+	ctx context.Context,
+	id uuid.UUID,
+	columns map[string]interface{},
+) error {
+	if err := repo.next.UpdateUser(ctx, id, columns); err != nil {
+		return err
+	}
+	tenantID, err := user.TenantFromContext(ctx)
+	if err != nil {
+		return nil
+	}
+	keys := []string{idKey(tenantID, id)}
+	if email, ok := columns["email"].(string); ok {
+		keys = append(keys, emailKey(tenantID, email))
+	}
+	repo.invalidate(ctx, keys...)
+	return nil
+}
+
+// ChangePassword changes password of user and drops its entry
+func (repo *cachedRepository) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	hashedPassword, changedBy string,
+	changedAt time.Time,
+) error {
+	err := repo.next.ChangePassword(ctx, id, hashedPassword, changedBy, changedAt)
+	if err != nil {
+		return err
+	}
+	repo.invalidateUser(ctx, id)
+	return nil
+}
+
+// DeleteUser soft deletes user and drops its entry
+func (repo *cachedRepository) DeleteUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	if err := repo.next.DeleteUser(ctx, id, deletedAt); err != nil {
+		return err
+	}
+	repo.invalidateUser(ctx, id)
+	return nil
+}
+
+// RestoreUser restores user and drops its entry
+func (repo *cachedRepository) RestoreUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAfter time.Time,
+) error {
+	if err := repo.next.RestoreUser(ctx, id, deletedAfter); err != nil {
+		return err
+	}
+	repo.invalidateUser(ctx, id)
+	return nil
+}
+
+// PurgeUsers is passed through, purged users were soft deleted so lookups
+// already miss them and registering their email again drops its entry
+func (repo *cachedRepository) PurgeUsers(
+	ctx context.Context,
+	deletedBefore time.Time,
+) (int64, error) {
+	return repo.next.PurgeUsers(ctx, deletedBefore)
+}
+
+// ChangeStatus changes status of user and drops its entry
+func (repo *cachedRepository) ChangeStatus(ctx context.Context, change user.StatusChange) error {
+	if err := repo.next.ChangeStatus(ctx, change); err != nil {
+		return err
+	}
+	repo.invalidateUser(ctx, change.UserID)
+	return nil
+}
+
+// FailLogin counts failed login and drops entry of user
+func (repo *cachedRepository) FailLogin(ctx context.Context, id uuid.UUID) (int, error) {
+	failed, err := repo.next.FailLogin(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	repo.invalidateUser(ctx, id)
+	return failed, nil
+}
+
+// ListUsers is passed through
+func (repo *cachedRepository) ListUsers(
+	ctx context.Context,
+	query user.ListQuery,
+) ([]user.User, error) {
+	return repo.next.ListUsers(ctx, query)
+}
+
+// lookup run fn once for concurrent misses of key and share user it finds.
+// Fn runs on context of tenant detached from caller which started it, so it
+// is not part of unit of work of that caller and is not cut short when that
+// caller gives up, each caller stops waiting once its own context is done.
+// Its reads go to primary so it does not cache what replica lags behind on
+func (repo *cachedRepository) lookup(
+	ctx context.Context,
+	key string,
+	tenantID uuid.UUID,
+	fn func(ctx context.Context) (*user.User, error),
+) (*user.User, error) {
+	shared := repo.group.DoChan(key, func() (interface{}, error) {
+		detached, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+		defer cancel()
+		return fn(repository.ReadPrimary(user.ContextWithTenant(detached, tenantID)))
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-shared:
+		return clone(result.Val), result.Err
+	}
+}
+
+// notFound tells whether lookup on ctx found no user, failing lookups and
+// ones cut short by their context are not
+func (repo *cachedRepository) notFound(ctx context.Context, err error) bool {
+	return err == user.ErrUserNotFound && ctx.Err() == nil
+}
+
+// user returns cached user of key, nil user when negative entry is cached
+func (repo *cachedRepository) user(ctx context.Context, key string) (*user.User, bool) {
+	value, ok := repo.get(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	var cached *user.User
+	if err := json.Unmarshal(value, &cached); err != nil {
+		_ = level.Warn(repo.logger).Log("key", key, "err", err)
+		return nil, false
+	}
+	return cached, true
+}
+
+// get returns cached value of key, failing cache counts as miss
+func (repo *cachedRepository) get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := repo.cache.Get(ctx, key)
+	if err != nil {
+		_ = level.Warn(repo.logger).Log("key", key, "err", err)
+		return nil, false
+	}
+	return value, ok
+}
+
+// fillUser cache user without its password hash under key for ttl, unless
+// key was written since version
+func (repo *cachedRepository) fillUser(ctx context.Context, version uint64, key string, u *user.User) {
+	value, err := json.Marshal(withoutPassword(u))
+	if err != nil {
+		_ = level.Warn(repo.logger).Log("key", key, "err", err)
+		return
+	}
+	repo.fill(ctx, version, key, value, repo.ttl)
+}
+
+// fill cache value of key loaded at version for ttl, unless key was written
+// in this process since then. Value may still be stale until ttl passes
+// when write commits between the check and storing it, or when write of
+// other process commits while it is loaded
+func (repo *cachedRepository) fill(
+	ctx context.Context,
+	version uint64,
+	key string,
+	value []byte,
+	ttl time.Duration,
+) {
+	if repo.fence.version(key) != version {
+		return
+	}
+	if err := repo.cache.Set(ctx, key, value, ttl); err != nil {
+		_ = level.Warn(repo.logger).Log("key", key, "err", err)
+	}
+}
+
+// invalidateUser drop entry of user id in tenant of context
+func (repo *cachedRepository) invalidateUser(ctx context.Context, id uuid.UUID) {
+	tenantID, err := user.TenantFromContext(ctx)
+	if err != nil {
+		return
+	}
+	repo.invalidate(ctx, idKey(tenantID, id))
+}
+
+// invalidate drop keys now and once unit of work of context commits, so
+// lookups filling them in the meantime do not keep what was just changed.
+// Both times fence keys so lookups that loaded before do not store what
+// they loaded
+func (repo *cachedRepository) invalidate(ctx context.Context, keys ...string) {
+	repo.fence.bump(keys)
+	repo.delete(ctx, keys)
+	for _, key := range keys {
+		repo.group.Forget(key)
+	}
+	repository.AfterCommit(ctx, func() {
+		repo.fence.bump(keys)
+		repo.delete(context.Background(), keys)
+	})
+}
+
+// delete removes keys from cache
+func (repo *cachedRepository) delete(ctx context.Context, keys []string) {
+	if err := repo.cache.Delete(ctx, keys...); err != nil {
+		_ = level.Error(repo.logger).Log("keys", len(keys), "err", err)
+	}
+}
+
+// idKey cache key of user id in tenant
+func idKey(tenantID, id uuid.UUID) string {
+	return "user:" + tenantID.String() + ":id:" + id.String()
+}
+
+// emailKey cache key of user email in tenant, casings of email share key so
+// writing one drops entry of all of them where database ignores case
+func emailKey(tenantID uuid.UUID, email string) string {
+	return "user:" + tenantID.String() + ":email:" + strings.ToLower(email)
+}
+
+// withoutPassword returns copy of u without password hash, nil for nil
+func withoutPassword(u *user.User) *user.User {
+	if u == nil {
+		return nil
+	}
+	copied := *u
+	copied.Passwords = ""
+	return &copied
+}
+
+// clone copy user shared by concurrent lookups, callers change user they
+// get before updating it
+func clone(shared interface{}) *user.User {
+	found, ok := shared.(*user.User)
+	if !ok || found == nil {
+		return nil
+	}
+	copied := *found
+	return &copied
+}
@@ -0,0 +1,361 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/cache"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/memory"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/repositorytest"
+)
+
+// lookups repository counting lookups reaching it, lookups wait for release
+// when it is set and fail with err when it is set
+type lookups struct {
+	user.Repository
+	mutex   sync.Mutex
+	calls   int
+	started chan context.Context
+	release chan struct{}
+	err     error
+}
+
+func (repo *lookups) GetUser(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	if err := repo.wait(ctx); err != nil {
+		return nil, err
+	}
+	return repo.Repository.GetUser(ctx, id)
+}
+
+func (repo *lookups) Login(ctx context.Context, email, passwords string) (*user.User, error) {
+	if err := repo.wait(ctx); err != nil {
+		return nil, err
+	}
+	return repo.Repository.Login(ctx, email, passwords)
+}
+
+func (repo *lookups) wait(ctx context.Context) error {
+	repo.mutex.Lock()
+	repo.calls++
+	err := repo.err
+	repo.mutex.Unlock()
+	if repo.started != nil {
+		repo.started <- ctx
+	}
+	if repo.release != nil {
+		<-repo.release
+	}
+	return err
+}
+
+func (repo *lookups) count() int {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.calls
+}
+
+// fixture cached repository over memory one with user registered in tenant
+// of returned context
+type fixture struct {
+	ctx     context.Context
+	next    *lookups
+	repo    user.Repository
+	cache   *fakeCache
+	hits    counter
+	misses  counter
+	user    user.User
+	missing uuid.UUID
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{
+		ctx:     user.ContextWithTenant(context.Background(), uuid.NewV4()),
+		next:    &lookups{Repository: memory.NewUserRepository()},
+		cache:   newFakeCache(),
+		hits:    newCounter(),
+		misses:  newCounter(),
+		missing: uuid.NewV4(),
+	}
+	f.repo = cache.NewRepository(f.next, f.cache, time.Minute, time.Minute, f.hits, f.misses, log.NewNopLogger())
+	now := time.Now().UTC()
+	f.user = user.User{
+		ID:        uuid.NewV4(),
+		Email:     "ada@example.com",
+		Passwords: "hash",
+		Role:      user.RoleUser,
+		Status:    user.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := f.repo.Register(f.ctx, f.user); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCachedRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(*testing.T) user.Repository {
+		return cache.NewRepository(
+			memory.NewUserRepository(),
+			newFakeCache(),
+			time.Minute,
+			time.Minute,
+			newCounter(),
+			newCounter(),
+			log.NewNopLogger(),
+		)
+	})
+}
+
+func TestCacheHitsAndMisses(t *testing.T) {
+	f := newFixture(t)
+	for i := 0; i < 2; i++ {
+		if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.repo.Login(f.ctx, "grace@example.com", ""); err != user.ErrUserNotFound {
+			t.Fatalf("login of missing email: err = %v", err)
+		}
+	}
+
+	if calls := f.next.count(); calls != 2 {
+		t.Fatalf("lookups reaching repository = %d, want 2", calls)
+	}
+	for _, lookup := range []string{"lookup=id", "lookup=email"} {
+		if hits, misses := f.hits.total(lookup), f.misses.total(lookup); hits != 1 || misses != 1 {
+			t.Errorf("%s: hits %v misses %v, want one of each", lookup, hits, misses)
+		}
+	}
+
+	// Login finding user always reaches repository
+	for i := 0; i < 2; i++ {
+		if _, err := f.repo.Login(f.ctx, f.user.Email, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := f.next.count(); calls != 4 {
+		t.Fatalf("lookups reaching repository = %d, want 4", calls)
+	}
+}
+
+func TestCacheKeepsNoPassword(t *testing.T) {
+	f := newFixture(t)
+	for i := 0; i < 2; i++ {
+		found, err := f.repo.GetUser(f.ctx, f.user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Passwords != "" {
+			t.Fatalf("get %d returned password hash", i)
+		}
+	}
+	for key, value := range f.cache.values {
+		if strings.Contains(string(value), f.user.Passwords) {
+			t.Fatalf("%s caches password hash: %s", key, value)
+		}
+	}
+	found, err := f.repo.Login(f.ctx, f.user.Email, "")
+	if err != nil || found.Passwords != f.user.Passwords {
+		t.Fatalf("login: %+v, err %v, want password hash", found, err)
+	}
+}
+
+func TestCacheEmailCasing(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.repo.Login(f.ctx, "Grace@example.com", ""); err != user.ErrUserNotFound {
+		t.Fatalf("login of missing email: err = %v", err)
+	}
+	// Other casing is not answered by entry of missing one
+	if _, err := f.repo.Login(f.ctx, "grace@example.com", ""); err != user.ErrUserNotFound {
+		t.Fatalf("login of other casing: err = %v", err)
+	}
+	if calls := f.next.count(); calls != 2 {
+		t.Fatalf("lookups reaching repository = %d, want 2", calls)
+	}
+
+	// Registering any casing drops entry of missing one
+	grace := f.user
+	grace.ID = uuid.NewV4()
+	grace.Email = "GRACE@example.com"
+	if err := f.repo.Register(f.ctx, grace); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.repo.Login(f.ctx, "grace@example.com", "")
+	if calls := f.next.count(); calls != 3 {
+		t.Fatalf("lookups reaching repository after register = %d, want 3", calls)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != nil {
+		t.Fatal(err)
+	}
+	newEmail := "lovelace@example.com"
+	if _, err := f.repo.Login(f.ctx, newEmail, ""); err != user.ErrUserNotFound {
+		t.Fatalf("login with new email: err = %v", err)
+	}
+
+	err := f.repo.UpdateUser(f.ctx, f.user.ID, map[string]interface{}{
+		"email": newEmail,
+		"name":  "Ada",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := f.repo.GetUser(f.ctx, f.user.ID)
+	if err != nil || found.Name != "Ada" {
+		t.Fatalf("get after update: %+v, err %v", found, err)
+	}
+	// Negative entry of new email is dropped and old email no longer leads
+	// to user
+	if _, err := f.repo.Login(f.ctx, newEmail, ""); err != nil {
+		t.Fatalf("login with new email after update: %v", err)
+	}
+	if _, err := f.repo.Login(f.ctx, f.user.Email, ""); err != user.ErrUserNotFound {
+		t.Fatalf("login with old email after update: err = %v", err)
+	}
+
+	if err := f.repo.DeleteUser(f.ctx, f.user.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != user.ErrUserNotFound {
+		t.Fatalf("get after delete: err = %v", err)
+	}
+}
+
+func TestCacheNegativeEntries(t *testing.T) {
+	f := newFixture(t)
+	for i := 0; i < 2; i++ {
+		if _, err := f.repo.GetUser(f.ctx, f.missing); err != user.ErrUserNotFound {
+			t.Fatalf("get missing: err = %v", err)
+		}
+	}
+	if calls := f.next.count(); calls != 1 {
+		t.Fatalf("lookups of missing user = %d, want 1", calls)
+	}
+
+	// Failed lookup is not mistaken for missing user
+	f.next.err = context.DeadlineExceeded
+	if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != context.DeadlineExceeded {
+		t.Fatalf("failing get: err = %v", err)
+	}
+	f.next.err = nil
+	if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != nil {
+		t.Fatalf("get after failed lookup: %v", err)
+	}
+}
+
+func TestCacheBypassesFailingCache(t *testing.T) {
+	f := newFixture(t)
+	f.cache.err = errors.New("cache is down")
+	for i := 0; i < 2; i++ {
+		if _, err := f.repo.GetUser(f.ctx, f.user.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := f.next.count(); calls != 2 {
+		t.Fatalf("lookups reaching repository = %d, want 2", calls)
+	}
+}
+
+// slowStore repository whose lookups by id wait for release once they read
+// user, as lookup does whose result takes time to reach cache
+type slowStore struct {
+	user.Repository
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (repo *slowStore) GetUser(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	found, err := repo.Repository.GetUser(ctx, id)
+	if repo.loaded != nil {
+		repo.loaded <- struct{}{}
+		<-repo.release
+	}
+	return found, err
+}
+
+func TestCacheFenceDropsStaleFill(t *testing.T) {
+	f := newFixture(t)
+	next := &slowStore{
+		Repository: f.next,
+		loaded:     make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	repo := cache.NewRepository(next, f.cache, time.Minute, time.Minute, f.hits, f.misses, log.NewNopLogger())
+	done := make(chan struct{})
+	go func() {
+		_, _ = repo.GetUser(f.ctx, f.user.ID)
+		close(done)
+	}()
+	<-next.loaded
+
+	// User changes after lookup read it and before lookup stores it
+	err := repo.UpdateUser(f.ctx, f.user.ID, map[string]interface{}{"name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(next.release)
+	<-done
+
+	next.loaded = nil
+	found, err := repo.GetUser(f.ctx, f.user.ID)
+	if err != nil || found.Name != "Ada" {
+		t.Fatalf("get after update: %+v, err %v", found, err)
+	}
+}
+
+type callerKey struct{}
+
+func TestCacheSharesLookup(t *testing.T) {
+	f := newFixture(t)
+	f.next.started = make(chan context.Context, 10)
+	f.next.release = make(chan struct{})
+
+	// First caller gives up while lookup it started is running
+	first, cancel := context.WithCancel(context.WithValue(f.ctx, callerKey{}, "first"))
+	errs := make(chan error, 1)
+	go func() {
+		_, err := f.repo.GetUser(first, f.user.ID)
+		errs <- err
+	}()
+	lookupCtx := <-f.next.started
+	if lookupCtx.Value(callerKey{}) != nil {
+		t.Fatal("lookup runs on context of caller")
+	}
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("canceled caller: err = %v", err)
+	}
+	if lookupCtx.Err() != nil {
+		t.Fatalf("lookup context ended with caller: %v", lookupCtx.Err())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if found, err := f.repo.GetUser(f.ctx, f.user.ID); err != nil || found.ID != f.user.ID {
+				t.Errorf("shared lookup: %+v, err %v", found, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(f.next.release)
+	wg.Wait()
+
+	if calls := f.next.count(); calls != 1 {
+		t.Fatalf("lookups reaching repository = %d, want 1", calls)
+	}
+}
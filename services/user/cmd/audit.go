@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// commandAudit subcommand checking audit log, e.g. "audit verify acme"
+const commandAudit = "audit"
+
+// errAuditUsage returned when audit subcommand arguments are invalid
+var errAuditUsage = errors.New("usage: audit verify <tenant slug>")
+
+// runAudit run audit subcommand with its arguments, chain of tenant is
+// checked page by page from newest event down to first one and count of
+// events is printed
+func runAudit(
+	ctx context.Context,
+	tenants user.TenantRepository,
+	audit user.AuditRepository,
+	args []string,
+) error {
+	if len(args) != 2 || args[0] != "verify" {
+		return errAuditUsage
+	}
+	tenant, err := tenants.GetTenant(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	ctx = user.ContextWithTenant(ctx, tenant.ID)
+	query := user.AuditQuery{PageSize: user.MaxPageSize}
+	// Last event of previous page is checked again so pages chain together
+	var previous []user.AuditEvent
+	var verified int
+	for {
+		events, err := audit.ListAuditEvents(ctx, query)
+		if err != nil {
+			return err
+		}
+		if len(events) > query.PageSize {
+			events = events[:query.PageSize]
+		}
+		if err := user.VerifyAuditChain(append(previous, events...)); err != nil {
+			return err
+		}
+		verified += len(events)
+		if len(events) > 0 {
+			previous = events[len(events)-1:]
+		}
+		if len(events) < query.PageSize {
+			break
+		}
+		query.Before = previous[0].Sequence
+	}
+	// First event of tenant chains to nothing, otherwise events were removed
+	if len(previous) > 0 && previous[0].PrevHash != "" {
+		return fmt.Errorf("%w: event %d follows removed event", user.ErrAuditChainBroken, previous[0].Sequence)
+	}
+	fmt.Println(verified)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	kitexpvar "github.com/go-kit/kit/metrics/expvar"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/cache"
+)
+
+// cacheUsers wrap repository with read through cache of user lookups held
+// in process, size zero disables it. Hits and misses are published through
+// expvar served by admin listener
+func cacheUsers(
+	userRepository user.Repository,
+	logger log.Logger,
+	size int,
+	ttl, negativeTTL time.Duration,
+) user.Repository {
+	if size <= 0 {
+		return userRepository
+	}
+	return cache.NewRepository(
+		userRepository,
+		cache.NewLRU(size),
+		ttl,
+		negativeTTL,
+		kitexpvar.NewCounter("user_cache_hits"),
+		kitexpvar.NewCounter("user_cache_misses"),
+		logger,
+	)
+}
+
+// envInt returns environment integer of key or fallback when it is unset or
+// invalid
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -2,114 +2,379 @@ package main
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"expvar"
 	"flag"
 	"fmt"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/muhammadisa/go-kit-boilerplate/middleware"
+	amqpdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/amqp"
+	graphqldelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/graphql"
 	grpcdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/grpc"
+	httpdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/http"
+	jsonrpcdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/jsonrpc"
+	natsdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/nats"
+	"github.com/nats-io/nats.go"
+	"github.com/oklog/run"
+	"github.com/streadway/amqp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
 
 	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/audit"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
 	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
 	"github.com/muhammadisa/go-kit-boilerplate/services/user/implementation"
 	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/memory"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/transaction"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/worker"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/health"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
-	"github.com/gocraft/dbr/dialect"
 	"github.com/gocraft/dbr/v2"
 	"github.com/joho/godotenv"
 )
 
+// Transport modes, any combination of them can be enabled
+const (
+	modeREST    = "rest"
+	modeGRPC    = "grpc"
+	modeGateway = "gateway"
+	modeMux     = "mux"
+	modeNATS    = "nats"
+	modeAMQP    = "amqp"
+)
+
+// amqpWorkers consumers of command queue, also used as prefetch count
+const amqpWorkers = 8
+
+// replicaCheckInterval how often health of replicas is checked
+const replicaCheckInterval = 5 * time.Second
+
+// errTransportClosed returned by transport whose connection closed while
+// group was still running
+var errTransportClosed = errors.New("transport connection closed")
+
+// signalError returned by signal actor so termination by signal is told
+// apart from failure
+type signalError struct {
+	signal os.Signal
+}
+
+func (e signalError) Error() string { return fmt.Sprintf("received signal %s", e.signal) }
+
+// parseModes split comma separated modes and reject unknown ones
+func parseModes(value string) (map[string]bool, error) {
+	modes := make(map[string]bool)
+	for _, mode := range strings.Split(value, ",") {
+		mode = strings.ToLower(strings.TrimSpace(mode))
+		switch mode {
+		case "":
+			continue
+		case modeREST, modeGRPC, modeGateway, modeMux, modeNATS, modeAMQP:
+			modes[mode] = true
+		default:
+			return nil, fmt.Errorf("unknown mode %q", mode)
+		}
+	}
+	if len(modes) == 0 {
+		return nil, errors.New("no mode enabled")
+	}
+	return modes, nil
+}
+
+// envOr returns environment value of key or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func signalMode(g *run.Group) {
+	c := make(chan os.Signal, 1)
+	cancel := make(chan struct{})
+	g.Add(func() error {
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case sig := <-c:
+			return signalError{signal: sig}
+		case <-cancel:
+			return nil
+		}
+	}, func(error) {
+		signal.Stop(c)
+		close(cancel)
+	})
+}
+
 func restMode(
-	_ context.Context,
+	g *run.Group,
 	logger log.Logger,
+	s shutdown,
+	httpAddr string,
 	userServiceHttp http.Handler,
-) {
-	port := os.Getenv("HTTP_PORT")
-	httpAddr := flag.String("http", port, "http listen address")
-	flag.Parse()
+) error {
+	listener, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s.probe.Handler(userServiceHttp)}
+	addGraceful(g, func() error {
+		_ = level.Info(logger).Log("transport", "HTTP", "addr", httpAddr)
+		return server.Serve(listener)
+	}, func() {
+		stopHTTP(s, server)
+	})
+	return nil
+}
 
-	errs := make(chan error)
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		errs <- fmt.Errorf("%s", <-c)
-	}()
-	go func() {
-		_ = level.Info(logger).Log("transport", "HTTP", "addr", *httpAddr)
-		server := &http.Server{
-			Addr:    *httpAddr,
-			Handler: userServiceHttp,
-		}
-		errs <- server.ListenAndServe()
-	}()
-	_ = level.Error(logger).Log("exit", <-errs)
+// adminMode serve expvar metrics next to probes on admin listener, which
+// unlike transports should be reachable from inside of deployment only
+func adminMode(
+	g *run.Group,
+	logger log.Logger,
+	s shutdown,
+	adminAddr string,
+) error {
+	listener, err := net.Listen("tcp", adminAddr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(health.MetricsPath, expvar.Handler())
+	server := &http.Server{Handler: s.probe.Handler(mux)}
+	addGraceful(g, func() error {
+		_ = level.Info(logger).Log("transport", "admin HTTP", "addr", adminAddr)
+		return server.Serve(listener)
+	}, func() {
+		stopHTTP(s, server)
+	})
+	return nil
 }
 
 func grpcMode(
-	_ context.Context,
+	g *run.Group,
 	logger log.Logger,
+	s shutdown,
+	grpcAddr string,
 	userServiceGrpc user_grpc.UserServiceServer,
-) {
-	port := os.Getenv("GRPC_PORT")
-	grpcListener, _ := net.Listen("tcp", port)
+) error {
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return err
+	}
 	grpcServer := grpc.NewServer()
-
-	errs := make(chan error)
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		errs <- fmt.Errorf("%s", <-c)
-	}()
-	go func() {
-		_ = logger.Log("transport", "gRPC", "addr", port)
-		user_grpc.RegisterUserServiceServer(grpcServer, userServiceGrpc)
-		errs <- grpcServer.Serve(grpcListener)
-	}()
-	_ = level.Error(logger).Log("exit", <-errs)
+	user_grpc.RegisterUserServiceServer(grpcServer, userServiceGrpc)
+	grpc_health_v1.RegisterHealthServer(grpcServer, s.probe.GRPCServer())
+	addGraceful(g, func() error {
+		_ = logger.Log("transport", "gRPC", "addr", grpcAddr)
+		return grpcServer.Serve(grpcListener)
+	}, func() {
+		stopGRPC(s, grpcServer)
+	})
+	return nil
 }
 
 func grpcGatewayMode(
-	_ context.Context,
+	g *run.Group,
 	logger log.Logger,
+	s shutdown,
+	gatewayAddr string,
 	userServiceGrpc user_grpc.UserServiceServer,
-) {
-	mux := runtime.NewServeMux()
+	userServiceRPC http.Handler,
+	userServiceGraphQL http.Handler,
+) error {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(headerMatcher))
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 	err := user_grpc.RegisterUserServiceHandlerServer(ctx, mux, userServiceGrpc)
 	if err != nil {
-		_ = level.Error(logger).Log("exit", err)
-		os.Exit(-1)
+		cancel()
+		return err
 	}
-	listen, err := net.Listen("tcp", ":8080")
+	// Serve json rpc and graphql next to gateway routes
+	root := http.NewServeMux()
+	root.Handle("/rpc", userServiceRPC)
+	root.Handle("/graphql", userServiceGraphQL)
+	root.Handle("/", mux)
+	listen, err := net.Listen("tcp", gatewayAddr)
 	if err != nil {
-		_ = level.Error(logger).Log("exit", err)
-		os.Exit(-1)
+		cancel()
+		return err
 	}
+	server := &http.Server{Handler: s.probe.Handler(root)}
+	addGraceful(g, func() error {
+		_ = logger.Log("transport", "gRPC and Restful", "addr", gatewayAddr)
+		return server.Serve(listen)
+	}, func() {
+		stopHTTP(s, server)
+		cancel()
+	})
+	return nil
+}
 
-	errs := make(chan error)
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		errs <- fmt.Errorf("%s", <-c)
-	}()
-	go func() {
-		_ = logger.Log("transport", "gRPC and Restful", "addr", ":8080")
-		errs <- http.Serve(listen, mux)
-	}()
-	_ = level.Error(logger).Log("exit", <-errs)
+// muxMode serve native grpc, grpc gateway, json rpc, graphql and http router
+// on one listener, requests are told apart by http/2 and grpc content type
+// so cleartext http/2 is accepted through h2c
+func muxMode(
+	g *run.Group,
+	logger log.Logger,
+	s shutdown,
+	addr string,
+	userServiceGrpc user_grpc.UserServiceServer,
+	userServiceHttp http.Handler,
+	userServiceRPC http.Handler,
+	userServiceGraphQL http.Handler,
+) error {
+	grpcServer := grpc.NewServer()
+	user_grpc.RegisterUserServiceServer(grpcServer, userServiceGrpc)
+	grpc_health_v1.RegisterHealthServer(grpcServer, s.probe.GRPCServer())
+	gateway := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(headerMatcher))
+	ctx, cancel := context.WithCancel(context.Background())
+	err := user_grpc.RegisterUserServiceHandlerServer(ctx, gateway, userServiceGrpc)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// Gateway routes are versioned, json rpc and graphql are mounted as in
+	// gateway mode and everything else goes to http router
+	root := http.NewServeMux()
+	root.Handle("/v1/", gateway)
+	root.Handle("/rpc", userServiceRPC)
+	root.Handle("/graphql", userServiceGraphQL)
+	root.Handle("/", userServiceHttp)
+	handler := h2c.NewHandler(s.probe.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		root.ServeHTTP(w, r)
+	})), &http2.Server{})
+	listen, err := net.Listen("tcp", addr)
+	if err != nil {
+		cancel()
+		return err
+	}
+	server := &http.Server{Handler: handler}
+	addGraceful(g, func() error {
+		_ = logger.Log("transport", "gRPC, gateway and HTTP", "addr", addr)
+		return server.Serve(listen)
+	}, func() {
+		// Http/2 connections are hijacked by h2c so server shutdown does
+		// not wait for them and grpc graceful stop cannot drain them,
+		// in flight requests counted by probe are waited for instead
+		ctx, cancelShutdown := s.context()
+		defer cancelShutdown()
+		_ = server.Shutdown(ctx)
+		_ = s.probe.Wait(ctx)
+		grpcServer.Stop()
+		_ = server.Close()
+		cancel()
+	})
+	return nil
+}
+
+func natsMode(
+	g *run.Group,
+	logger log.Logger,
+	s shutdown,
+	endpoints delivery.Endpoints,
+) error {
+	url := os.Getenv("NATS_URL")
+	closed := make(chan struct{})
+	nc, err := nats.Connect(
+		url,
+		nats.DrainTimeout(s.timeout),
+		nats.ClosedHandler(func(*nats.Conn) {
+			close(closed)
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	if _, err = natsdelivery.NewNATSServe(nc, endpoints, logger); err != nil {
+		nc.Close()
+		return err
+	}
+	addGraceful(g, func() error {
+		_ = logger.Log("transport", "NATS", "addr", url)
+		<-closed
+		return nil
+	}, func() {
+		// Draining unsubscribes, serves pending requests and then closes
+		// connection, it is closed anyway once drain timeout passes
+		if err := nc.Drain(); err != nil {
+			nc.Close()
+		}
+		<-closed
+	})
+	return nil
+}
+
+func amqpMode(
+	g *run.Group,
+	logger log.Logger,
+	s shutdown,
+	endpoints delivery.Endpoints,
+) error {
+	conn, err := amqp.Dial(os.Getenv("AMQP_URL"))
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err == nil {
+		err = ch.Qos(amqpWorkers, 0, false)
+	}
+	if err == nil {
+		err = amqpdelivery.DeclareQueues(ch)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	served := make(chan struct{})
+	addGraceful(g, func() error {
+		defer close(served)
+		_ = logger.Log("transport", "AMQP", "queue", amqpdelivery.CommandQueue)
+		return amqpdelivery.NewAMQPServe(ch, endpoints, logger, amqpWorkers)
+	}, func() {
+		// Cancelling consumer stops new deliveries, commands already
+		// received are served before connection closes
+		_ = ch.Cancel(amqpdelivery.ConsumerTag, false)
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case <-served:
+		case <-timer.C:
+		}
+		_ = conn.Close()
+	})
+	return nil
+}
+
+// headerMatcher forward tenant and request id header to grpc metadata
+func headerMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, auth.TenantHeader) ||
+		strings.EqualFold(key, auth.RequestIDHeader) {
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
 }
 
 func createLogger() log.Logger {
@@ -136,45 +401,167 @@ func loadEnvironment(logger log.Logger) {
 	}
 }
 
-func createDBRSession(logger log.Logger) *dbr.Session {
-	driverAndStrConn := fmt.Sprintf("mysql~%s", fmt.Sprintf(
-		"%s:%s@(%s:%s)/%s?charset=utf8&parseTime=True&loc=Local",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	))
-	connectionStr := strings.Split(driverAndStrConn, "~")
-	db, err := sql.Open(connectionStr[0], connectionStr[1])
+func createDBRSession(
+	logger log.Logger,
+	driver, dsn string,
+	queryTimeout time.Duration,
+) *dbr.Session {
+	maxOpenConns := 10
+	if driver == repository.DriverMemory {
+		// Every connection to in memory database opens empty one, so there
+		// must be only one
+		driver, maxOpenConns = repository.DriverSQLite, 1
+	}
+	conn, err := repository.Open(driver, dsn)
 	if err != nil {
-		_ = level.Error(logger).Log("exit", err)
+		_ = level.Error(logger).Log("exit", err, "driver", driver)
 		os.Exit(-1)
 	}
-	conn := &dbr.Connection{
-		DB:            db,
-		EventReceiver: &dbr.NullEventReceiver{},
-		Dialect:       dialect.MySQL,
-	}
-	conn.SetMaxOpenConns(10)
+	conn.SetMaxOpenConns(maxOpenConns)
 	session := conn.NewSession(nil)
-	_, err = session.Begin()
-	if err != nil {
+	// Queries are canceled after timeout at the latest, sooner when their
+	// context is done
+	session.Timeout = queryTimeout
+	if err = conn.Ping(); err != nil {
 		_ = level.Error(logger).Log("exit", err)
 		os.Exit(-1)
 	}
 	return session
 }
 
-func initService(session *dbr.Session) user.Service {
-	userRepository := repository.NewUserRepository(session)
-	return implementation.NewService(userRepository)
+// createReplicas create sessions of comma separated DB_REPLICAS data source
+// names, they use driver of primary
+func createReplicas(
+	logger log.Logger,
+	driver string,
+	queryTimeout time.Duration,
+) *repository.Replicas {
+	var sessions []*dbr.Session
+	for _, dsn := range strings.Split(os.Getenv("DB_REPLICAS"), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			sessions = append(sessions, createDBRSession(logger, driver, dsn, queryTimeout))
+		}
+	}
+	return repository.NewReplicas(sessions, logger, replicaCheckInterval)
+}
+
+// dataSourceName build data source name of driver from environment, sqlite
+// database is file named by DB_NAME
+func dataSourceName(driver string) string {
+	switch driver {
+	case repository.DriverPostgres:
+		return fmt.Sprintf(
+			"postgres://%s:%s@%s:%s/%s?sslmode=%s",
+			url.QueryEscape(os.Getenv("DB_USER")),
+			url.QueryEscape(os.Getenv("DB_PASSWORD")),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+			envOr("DB_SSLMODE", "disable"),
+		)
+	case repository.DriverSQLite:
+		// Writers wait for each other instead of failing with database is
+		// locked, transactions take write lock upfront so they never have
+		// to upgrade it
+		return fmt.Sprintf(
+			"file:%s?_busy_timeout=5000&_txlock=immediate&_foreign_keys=1",
+			os.Getenv("DB_NAME"),
+		)
+	case repository.DriverMemory:
+		return "file::memory:?_foreign_keys=1"
+	default:
+		return fmt.Sprintf(
+			"%s:%s@(%s:%s)/%s?charset=utf8&parseTime=True&loc=Local",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+		)
+	}
+}
+
+func initService(
+	userRepository user.Repository,
+	organizationRepository user.OrganizationRepository,
+	sessionRepository user.SessionRepository,
+	auditRepository user.AuditRepository,
+	webhookRepository user.WebhookRepository,
+	transactor user.Transactor,
+	approval bool,
+	logger log.Logger,
+) user.Service {
+	service := implementation.NewService(
+		userRepository,
+		organizationRepository,
+		sessionRepository,
+		auditRepository,
+		webhookRepository,
+		transactor,
+		os.Getenv("API_SECRET"),
+		approval,
+	)
+	// Audit events are appended outside of unit of work so failed calls
+	// rolling back still leave their trail
+	service = transaction.NewMiddleware(transactor)(service)
+	return audit.NewMiddleware(auditRepository, logger)(service)
 }
 
-func initEndpoints(service user.Service, logger log.Logger) delivery.Endpoints {
+func initEndpoints(
+	service user.Service,
+	tenants user.TenantRepository,
+	sessions user.SessionRepository,
+	defaultTenant string,
+	logger log.Logger,
+) delivery.Endpoints {
 	endpoints := delivery.MakeEndpoints(service)
-	endpoints.Login = middleware.LoggingMiddleware(logger)(endpoints.Login)
-	endpoints.Register = middleware.LoggingMiddleware(logger)(endpoints.Register)
+	loggingMiddleware := middleware.LoggingMiddleware(logger)
+	tenantMiddleware := auth.TenantMiddleware(tenants, defaultTenant)
+	sessionMiddleware := auth.SessionMiddleware(sessions)
+	authMiddleware := middleware.AuthMiddleware(
+		auth.KeyFunc(os.Getenv("API_SECRET")),
+		auth.ClaimsFactory,
+	)
+	// Public endpoints resolve tenant from header or subdomain, falling back
+	// to default tenant
+	public := func(e endpoint.Endpoint) endpoint.Endpoint {
+		return loggingMiddleware(tenantMiddleware(e))
+	}
+	// Protected endpoints resolve tenant from token claims and require
+	// token session not to be revoked
+	protected := func(e endpoint.Endpoint) endpoint.Endpoint {
+		return loggingMiddleware(authMiddleware(tenantMiddleware(sessionMiddleware(e))))
+	}
+	endpoints.Login = public(endpoints.Login)
+	endpoints.Register = public(endpoints.Register)
+	endpoints.BulkRegister = public(endpoints.BulkRegister)
+	endpoints.GetMe = protected(endpoints.GetMe)
+	endpoints.GetUser = protected(endpoints.GetUser)
+	endpoints.UpdateUser = protected(endpoints.UpdateUser)
+	endpoints.DeleteUser = protected(endpoints.DeleteUser)
+	endpoints.ListUsers = protected(endpoints.ListUsers)
+	endpoints.RestoreUser = protected(endpoints.RestoreUser)
+	endpoints.SuspendUser = protected(endpoints.SuspendUser)
+	endpoints.ReactivateUser = protected(endpoints.ReactivateUser)
+	endpoints.DeactivateUser = protected(endpoints.DeactivateUser)
+	endpoints.ChangePassword = protected(endpoints.ChangePassword)
+	endpoints.CreateOrganization = protected(endpoints.CreateOrganization)
+	endpoints.ListOrganizations = protected(endpoints.ListOrganizations)
+	endpoints.InviteMember = protected(endpoints.InviteMember)
+	endpoints.AcceptInvitation = protected(endpoints.AcceptInvitation)
+	endpoints.DeclineInvitation = protected(endpoints.DeclineInvitation)
+	endpoints.ListMembers = protected(endpoints.ListMembers)
+	endpoints.ChangeMemberRole = protected(endpoints.ChangeMemberRole)
+	endpoints.RemoveMember = protected(endpoints.RemoveMember)
+	endpoints.LeaveOrganization = protected(endpoints.LeaveOrganization)
+	endpoints.ListSessions = protected(endpoints.ListSessions)
+	endpoints.RevokeSession = protected(endpoints.RevokeSession)
+	endpoints.ListAuditEvents = protected(endpoints.ListAuditEvents)
+	endpoints.CreateWebhook = protected(endpoints.CreateWebhook)
+	endpoints.ListWebhooks = protected(endpoints.ListWebhooks)
+	endpoints.DeleteWebhook = protected(endpoints.DeleteWebhook)
+	endpoints.ListWebhookDeliveries = protected(endpoints.ListWebhookDeliveries)
+	endpoints.RedeliverWebhook = protected(endpoints.RedeliverWebhook)
 	return endpoints
 }
 
@@ -183,23 +570,206 @@ func main() {
 	logger := createLogger()
 	// Load environment
 	loadEnvironment(logger)
+	// Parse flags, environment provides their defaults
+	modesFlag := flag.String("modes", envOr("MODES", modeGateway), "comma separated transports to run: rest, grpc, gateway, mux, nats, amqp")
+	httpAddr := flag.String("http", os.Getenv("HTTP_PORT"), "http listen address")
+	grpcAddr := flag.String("grpc", os.Getenv("GRPC_PORT"), "grpc listen address")
+	gatewayAddr := flag.String("gateway", envOr("GATEWAY_PORT", ":8080"), "grpc gateway listen address")
+	muxAddr := flag.String("addr", os.Getenv("PORT"), "listen address of grpc, gateway and http served together")
+	adminAddr := flag.String("admin-addr", os.Getenv("ADMIN_PORT"), "listen address of metrics, empty disables it")
+	shutdownTimeout := flag.Duration("shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", 30*time.Second), "time given to in flight requests and workers on shutdown")
+	shutdownDelay := flag.Duration("shutdown-delay", envDuration("SHUTDOWN_DELAY", 0), "time between readiness turning not ready and transports stopping")
+	queryTimeout := flag.Duration("query-timeout", envDuration("QUERY_TIMEOUT", 5*time.Second), "default timeout of each database query, zero disables it")
+	cacheSize := flag.Int("cache-size", envInt("CACHE_SIZE", 10000), "users held by lookup cache, zero disables it")
+	cacheTTL := flag.Duration("cache-ttl", envDuration("CACHE_TTL", time.Minute), "how long user lookups are cached")
+	cacheNegativeTTL := flag.Duration("cache-negative-ttl", envDuration("CACHE_NEGATIVE_TTL", 5*time.Second), "how long lookups finding no user are cached")
+	approvalFlag := flag.Bool("registration-approval", envBool("REGISTRATION_APPROVAL", false), "register users as pending until admin activates them")
+	defaultTenant := flag.String("default-tenant", os.Getenv("DEFAULT_TENANT"), "slug of tenant created on startup and used by requests naming none, \"demo\" in memory mode")
+	trustedProxiesFlag := flag.String("trusted-proxies", os.Getenv("TRUSTED_PROXIES"), "comma separated networks of proxies whose forwarded for header is honored")
+	autoMigrateFlag := flag.Bool("auto-migrate", envBool("AUTO_MIGRATE", false), "apply pending schema migrations on startup")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [%s command | %s command | %s command]\n", os.Args[0], commandMigrate, commandTenant, commandAudit)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 	// Create dbr session
-	session := createDBRSession(logger)
-	// Init context and parse flags
+	driver := envOr("DB_DRIVER", repository.DriverMySQL)
+	session := createDBRSession(logger, driver, envOr("DB_DSN", dataSourceName(driver)), *queryTimeout)
+	// Init context
 	ctx := context.Background()
+	// Run migrate subcommand instead of service when given
+	if flag.Arg(0) == commandMigrate {
+		err := runMigrate(ctx, logger, session, flag.Args()[1:])
+		_ = session.Close()
+		if err != nil {
+			_ = level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		return
+	}
+	// Run tenant subcommand instead of service when given
+	if flag.Arg(0) == commandTenant {
+		tenants := repository.NewTenantRepository(session, nil)
+		err := runTenant(ctx, tenants, flag.Args()[1:])
+		_ = session.Close()
+		if err != nil {
+			_ = level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		return
+	}
+	// Run audit subcommand instead of service when given
+	if flag.Arg(0) == commandAudit {
+		tenants := repository.NewTenantRepository(session, nil)
+		audit := repository.NewAuditRepository(session)
+		err := runAudit(ctx, tenants, audit, flag.Args()[1:])
+		_ = session.Close()
+		if err != nil {
+			_ = level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+		return
+	}
+	modes, err := parseModes(*modesFlag)
+	if err != nil {
+		_ = level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+	proxies, err := auth.ParseTrustedProxies(*trustedProxiesFlag)
+	if err != nil {
+		_ = level.Error(logger).Log("exit", err)
+		os.Exit(-1)
+	}
+	// In memory database starts empty so its schema is always migrated
+	if *autoMigrateFlag || driver == repository.DriverMemory {
+		if err := autoMigrate(ctx, logger, session); err != nil {
+			_ = level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+	}
+	// In memory database has no tenant to register users into otherwise
+	if driver == repository.DriverMemory && *defaultTenant == "" {
+		*defaultTenant = "demo"
+	}
+	if *defaultTenant != "" {
+		tenants := repository.NewTenantRepository(session, nil)
+		if err := ensureTenant(ctx, logger, tenants, *defaultTenant); err != nil {
+			_ = level.Error(logger).Log("exit", err)
+			os.Exit(-1)
+		}
+	}
+	// Replicas serve reads of users and tenants, primary serves the rest
+	replicas := createReplicas(logger, driver, *queryTimeout)
+	// Prepare repository
+	userRepository := repository.NewUserRepository(session, replicas)
+	if driver == repository.DriverMemory {
+		userRepository = memory.NewUserRepository()
+	}
+	// Cache lookups of users, logins would otherwise all hit database
+	userRepository = cacheUsers(userRepository, logger, *cacheSize, *cacheTTL, *cacheNegativeTTL)
+	organizationRepository := repository.NewOrganizationRepository(session)
+	sessionRepository := repository.NewSessionRepository(session)
+	auditRepository := repository.NewAuditRepository(session)
+	webhookRepository := repository.NewWebhookRepository(session)
+	transactor := repository.NewTransactor(session)
 	// Prepare service
-	service := initService(session)
+	service := initService(
+		userRepository,
+		organizationRepository,
+		sessionRepository,
+		auditRepository,
+		webhookRepository,
+		transactor,
+		*approvalFlag,
+		logger,
+	)
+	// Background workers run until transports have stopped
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context) error) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			_ = run(workerCtx)
+		}()
+	}
+	// Check health of replicas in background
+	runWorker(replicas.Run)
+	// Purge expired soft deleted users in background
+	runWorker(worker.NewPurger(userRepository, logger, time.Hour).Run)
+	// Relay outbox events in background
+	outboxRepository := repository.NewOutboxRepository(session)
+	publisher := worker.NewWebhookPublisher(webhookRepository)
+	runWorker(worker.NewRelay(outboxRepository, publisher, logger, time.Second, 100).Run)
+	// Deliver webhooks in background
+	webhookClient := worker.NewWebhookClient(10 * time.Second)
+	runWorker(worker.NewDispatcher(webhookRepository, webhookClient, logger, time.Second, 100, 8).Run)
 	// Prepare endpoints
-	endpoints := initEndpoints(service, logger)
+	tenantRepository := repository.NewTenantRepository(session, replicas)
+	endpoints := initEndpoints(service, tenantRepository, sessionRepository, *defaultTenant, logger)
+	tenantDomain := os.Getenv("TENANT_DOMAIN")
 
-	// Rest Http
-	//userServiceHttp := httpdelivery.NewHTTPServe(ctx, endpoints, logger)
-	//restMode(ctx, logger, userServiceHttp)
-
-	// Grpc Http2
-	userServiceGrpc := grpcdelivery.NewGRPCServer(endpoints, logger)
-	grpcGatewayMode(ctx, logger, userServiceGrpc)
-	//grpcMode(ctx, logger, userServiceGrpc)
+	// Run enabled transports together, first one to stop stops the rest
+	var g run.Group
+	s := shutdown{
+		probe:   health.NewProbe(),
+		delay:   *shutdownDelay,
+		timeout: *shutdownTimeout,
+	}
+	signalMode(&g)
+	readinessMode(&g, s)
+	userServiceGrpc := grpcdelivery.NewGRPCServer(endpoints, logger, tenantDomain, proxies)
+	userServiceHttp := httpdelivery.NewHTTPServe(ctx, endpoints, logger, tenantDomain, proxies)
+	userServiceRPC := jsonrpcdelivery.NewJSONRPCServe(endpoints, logger, tenantDomain, proxies)
+	userServiceGraphQL := graphqldelivery.NewGraphQLServe(endpoints, logger, tenantDomain, proxies)
+	if modes[modeREST] {
+		// Rest Http
+		err = restMode(&g, logger, s, *httpAddr, userServiceHttp)
+	}
+	if err == nil && modes[modeGRPC] {
+		// Grpc Http2
+		err = grpcMode(&g, logger, s, *grpcAddr, userServiceGrpc)
+	}
+	if err == nil && modes[modeGateway] {
+		// Grpc gateway with json rpc and graphql
+		err = grpcGatewayMode(&g, logger, s, *gatewayAddr, userServiceGrpc, userServiceRPC, userServiceGraphQL)
+	}
+	if err == nil && modes[modeMux] {
+		// Grpc, gateway, json rpc, graphql and rest http on single port
+		err = muxMode(&g, logger, s, *muxAddr, userServiceGrpc, userServiceHttp, userServiceRPC, userServiceGraphQL)
+	}
+	if err == nil && *adminAddr != "" {
+		// Metrics on internal listener
+		err = adminMode(&g, logger, s, *adminAddr)
+	}
+	if err == nil && modes[modeNATS] {
+		// Nats request reply
+		err = natsMode(&g, logger, s, endpoints)
+	}
+	if err == nil && modes[modeAMQP] {
+		// Amqp commands
+		err = amqpMode(&g, logger, s, endpoints)
+	}
+	if err == nil {
+		err = g.Run()
+	}
 
-	defer ctx.Done()
+	// Transports have stopped, let workers finish their batch and then
+	// close database
+	stopWorkers()
+	if !waitTimeout(&workers, s.timeout) {
+		_ = level.Warn(logger).Log("msg", "workers did not stop before shutdown timeout")
+	}
+	if closeErr := session.Close(); closeErr != nil {
+		_ = level.Error(logger).Log("msg", "closing database", "err", closeErr)
+	}
+	if closeErr := replicas.Close(); closeErr != nil {
+		_ = level.Error(logger).Log("msg", "closing replicas", "err", closeErr)
+	}
+	if _, ok := err.(signalError); ok {
+		_ = level.Info(logger).Log("exit", err)
+		return
+	}
+	_ = level.Error(logger).Log("exit", err)
+	os.Exit(-1)
 }
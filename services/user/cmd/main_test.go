@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/health"
+)
+
+// newShutdown create shutdown of fresh probe without delay
+func newShutdown(timeout time.Duration) shutdown {
+	return shutdown{probe: health.NewProbe(), timeout: timeout}
+}
+
+// stopOn add actor ending group with signal error once stop is closed
+func stopOn(g *run.Group, stop <-chan struct{}) {
+	cancel := make(chan struct{})
+	g.Add(func() error {
+		select {
+		case <-stop:
+			return signalError{signal: syscall.SIGTERM}
+		case <-cancel:
+			return nil
+		}
+	}, func(error) {
+		close(cancel)
+	})
+}
+
+// runGroup run group in background, its result is sent once it returns
+func runGroup(g *run.Group) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- g.Run()
+	}()
+	return result
+}
+
+// wait returns result of group or fails when it does not return in time
+func wait(t *testing.T, result <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("group did not stop")
+		return nil
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	tests := []struct {
+		value string
+		modes map[string]bool
+		err   bool
+	}{
+		{value: "gateway", modes: map[string]bool{modeGateway: true}},
+		{value: " REST, grpc ,,nats", modes: map[string]bool{modeREST: true, modeGRPC: true, modeNATS: true}},
+		{value: "mux,amqp,mux", modes: map[string]bool{modeMux: true, modeAMQP: true}},
+		{value: "rest,soap", err: true},
+		{value: "", err: true},
+		{value: " , ", err: true},
+	}
+	for _, tt := range tests {
+		modes, err := parseModes(tt.value)
+		if (err != nil) != tt.err || !reflect.DeepEqual(modes, tt.modes) {
+			t.Errorf("parseModes(%q) = %v, %v", tt.value, modes, err)
+		}
+	}
+}
+
+func TestModesRunTogether(t *testing.T) {
+	var g run.Group
+	s := newShutdown(time.Second)
+	stop := make(chan struct{})
+	stopOn(&g, stop)
+	readinessMode(&g, s)
+	if err := restMode(&g, log.NewNopLogger(), s, "127.0.0.1:0", http.NotFoundHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := grpcMode(&g, log.NewNopLogger(), s, "127.0.0.1:0", &user_grpc.UnimplementedUserServiceServer{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := adminMode(&g, log.NewNopLogger(), s, "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	result := runGroup(&g)
+
+	select {
+	case err := <-result:
+		t.Fatalf("group stopped with %v while transports were running", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(stop)
+	err := wait(t, result)
+	if _, ok := err.(signalError); !ok {
+		t.Fatalf("err = %v, want signal error", err)
+	}
+	if s.probe.Ready() {
+		t.Error("probe is still ready after shutdown")
+	}
+}
+
+func TestFailingTransportStopsGroup(t *testing.T) {
+	failure := errors.New("listener broken")
+	tests := []struct {
+		name  string
+		serve error
+		want  error
+	}{
+		{name: "failure", serve: failure, want: failure},
+		{name: "closed", serve: nil, want: errTransportClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g run.Group
+			s := newShutdown(time.Second)
+			readinessMode(&g, s)
+			stopped := make(chan struct{})
+			addGraceful(&g, func() error {
+				<-stopped
+				return http.ErrServerClosed
+			}, func() {
+				close(stopped)
+			})
+			addGraceful(&g, func() error {
+				return tt.serve
+			}, func() {})
+
+			if err := wait(t, runGroup(&g)); err != tt.want {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			select {
+			case <-stopped:
+			default:
+				t.Error("other transport was not stopped")
+			}
+			if s.probe.Ready() {
+				t.Error("probe is still ready after failure")
+			}
+		})
+	}
+}
+
+// users grpc server finding user of any id
+type users struct {
+	user_grpc.UnimplementedUserServiceServer
+}
+
+func (*users) GetUser(_ context.Context, req *user_grpc.GetUserRequest) (*user_grpc.GetUserResponse, error) {
+	return &user_grpc.GetUserResponse{User: &user_grpc.User{Id: req.Id}}, nil
+}
+
+// named handler replying its name
+func named(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(name))
+	})
+}
+
+// freeAddr returns local address nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestMuxMode(t *testing.T) {
+	var g run.Group
+	s := newShutdown(time.Second)
+	stop := make(chan struct{})
+	stopOn(&g, stop)
+	addr := freeAddr(t)
+	err := muxMode(&g, log.NewNopLogger(), s, addr, &users{}, named("http"), named("rpc"), named("graphql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := runGroup(&g)
+	defer func() {
+		close(stop)
+		if _, ok := wait(t, result).(signalError); !ok {
+			t.Error("mux did not stop on signal")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	found, err := user_grpc.NewUserServiceClient(conn).GetUser(ctx, &user_grpc.GetUserRequest{Id: "42"})
+	if err != nil || found.User.Id != "42" {
+		t.Fatalf("grpc get user = %v, %v", found, err)
+	}
+	checked, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil || checked.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("grpc health = %v, %v", checked, err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/v1/users/42", `"id":"42"`},
+		{http.MethodPost, "/rpc", "rpc"},
+		{http.MethodPost, "/graphql", "graphql"},
+		{http.MethodGet, "/users/42", "http"},
+		{http.MethodGet, health.ReadinessPath, ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "http://"+addr+tt.path, strings.NewReader("{}"))
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), tt.want) {
+			t.Errorf("%s %s = %d %q, want %q", tt.method, tt.path, resp.StatusCode, body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/migration"
+)
+
+// commandMigrate subcommand managing schema, e.g. "migrate up"
+const commandMigrate = "migrate"
+
+// errMigrateUsage returned when migrate subcommand arguments are invalid
+var errMigrateUsage = errors.New("usage: migrate up | down [steps] | redo | status")
+
+// runMigrate run migrate subcommand with its arguments
+func runMigrate(ctx context.Context, logger log.Logger, session *dbr.Session, args []string) error {
+	migrator, err := migration.NewMigrator(session)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		logMigrations(logger, "applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errMigrateUsage
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		logMigrations(logger, "reverted", reverted)
+		return err
+	case "redo":
+		redone, err := migrator.Redo(ctx)
+		if redone != nil {
+			logMigrations(logger, "redone", []migration.Migration{*redone})
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errMigrateUsage
+	}
+}
+
+// autoMigrate apply pending migrations on startup, advisory lock keeps
+// replicas starting together from migrating at the same time
+func autoMigrate(ctx context.Context, logger log.Logger, session *dbr.Session) error {
+	migrator, err := migration.NewMigrator(session)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	logMigrations(logger, "applied", applied)
+	return err
+}
+
+// logMigrations log every migration with what was done to it
+func logMigrations(logger log.Logger, done string, migrations []migration.Migration) {
+	for _, m := range migrations {
+		_ = level.Info(logger).Log("migration", m.Version, "name", m.Name, "status", done)
+	}
+}
+
+// envBool returns environment boolean of key or fallback when it is unset
+// or invalid
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oklog/run"
+	"google.golang.org/grpc"
+
+	"github.com/muhammadisa/go-kit-boilerplate/utils/health"
+)
+
+// shutdown how transports of run group stop. Group interrupts actors one by
+// one in order they were added, so readiness actor added first flips probe
+// to not ready and waits delay for load balancers to notice before any
+// transport stops, every transport then has timeout to finish in flight work
+type shutdown struct {
+	probe   *health.Probe
+	delay   time.Duration
+	timeout time.Duration
+}
+
+// context returns context ending at shutdown timeout
+func (s shutdown) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), s.timeout)
+}
+
+func readinessMode(g *run.Group, s shutdown) {
+	stopped := make(chan struct{})
+	g.Add(func() error {
+		<-stopped
+		return nil
+	}, func(error) {
+		s.probe.Shutdown()
+		time.Sleep(s.delay)
+		close(stopped)
+	})
+}
+
+// addGraceful add transport to group, interrupting it runs stop in
+// background so transports stop together, and serve only returns once stop
+// is done so group waits for in flight work. Serve ending before interrupt
+// is a failure of transport
+func addGraceful(g *run.Group, serve func() error, stop func()) {
+	stopping := make(chan struct{})
+	stopped := make(chan struct{})
+	g.Add(func() error {
+		err := serve()
+		select {
+		case <-stopping:
+			<-stopped
+			return nil
+		default:
+			if err == nil {
+				err = errTransportClosed
+			}
+			return err
+		}
+	}, func(error) {
+		close(stopping)
+		go func() {
+			defer close(stopped)
+			stop()
+		}()
+	})
+}
+
+// stopHTTP stop accepting connections and wait for in flight requests until
+// shutdown timeout, connections still open then are closed
+func stopHTTP(s shutdown, server *http.Server) {
+	ctx, cancel := s.context()
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		_ = server.Close()
+	}
+}
+
+// stopGRPC stop accepting connections and wait for in flight calls until
+// shutdown timeout, calls still running then are cancelled
+func stopGRPC(s shutdown, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		server.Stop()
+	}
+}
+
+// waitTimeout wait for group until timeout, returns false when it timed out
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// envDuration returns environment duration of key or fallback when it is
+// unset or invalid
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
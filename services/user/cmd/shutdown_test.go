@@ -0,0 +1,174 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/run"
+
+	"github.com/muhammadisa/go-kit-boilerplate/utils/health"
+)
+
+// blocking handler signalling started requests and holding them until
+// release is closed
+func blocking(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+		}
+	})
+}
+
+// get send request in background, its status or error is sent once done
+func get(url string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = &statusError{code: resp.StatusCode}
+			}
+		}
+		done <- err
+	}()
+	return done
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string { return http.StatusText(e.code) }
+
+// restGroup run rest mode of handler under group stopped by closing stop
+func restGroup(t *testing.T, s shutdown, handler http.Handler, stop <-chan struct{}) (string, <-chan error) {
+	t.Helper()
+	var g run.Group
+	stopOn(&g, stop)
+	readinessMode(&g, s)
+	addr := freeAddr(t)
+	if err := restMode(&g, log.NewNopLogger(), s, addr, handler); err != nil {
+		t.Fatal(err)
+	}
+	return "http://" + addr, runGroup(&g)
+}
+
+func TestShutdownDrainsInFlight(t *testing.T) {
+	s := newShutdown(5 * time.Second)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	stop := make(chan struct{})
+	url, result := restGroup(t, s, blocking(started, release), stop)
+
+	inFlight := get(url + "/users")
+	<-started
+	close(stop)
+	for s.probe.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	// Listener closes while request is still served
+	for {
+		if _, err := http.Get(url + health.LivenessPath); err != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-result:
+		t.Fatalf("group stopped with %v before in flight request finished", err)
+	default:
+	}
+
+	close(release)
+	if err := <-inFlight; err != nil {
+		t.Fatalf("in flight request: %v", err)
+	}
+	if _, ok := wait(t, result).(signalError); !ok {
+		t.Fatal("group did not stop on signal")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	s := newShutdown(100 * time.Millisecond)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	stop := make(chan struct{})
+	url, result := restGroup(t, s, blocking(started, release), stop)
+
+	inFlight := get(url + "/users")
+	<-started
+	begin := time.Now()
+	close(stop)
+	if _, ok := wait(t, result).(signalError); !ok {
+		t.Fatal("group did not stop on signal")
+	}
+	if elapsed := time.Since(begin); elapsed < s.timeout {
+		t.Errorf("group stopped after %v, before timeout", elapsed)
+	}
+	if err := <-inFlight; err == nil {
+		t.Error("request outliving timeout was not cut off")
+	}
+}
+
+func TestShutdownDelay(t *testing.T) {
+	s := newShutdown(time.Second)
+	s.delay = 300 * time.Millisecond
+	stop := make(chan struct{})
+	url, result := restGroup(t, s, named("http"), stop)
+
+	close(stop)
+	for s.probe.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	// Transports keep serving while load balancers notice readiness
+	err := <-get(url + health.ReadinessPath)
+	if status, ok := err.(*statusError); !ok || status.code != http.StatusServiceUnavailable {
+		t.Errorf("readiness = %v, want service unavailable", err)
+	}
+	if err := <-get(url + "/users"); err != nil {
+		t.Errorf("request during shutdown delay: %v", err)
+	}
+	if _, ok := wait(t, result).(signalError); !ok {
+		t.Fatal("group did not stop on signal")
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var wg sync.WaitGroup
+	if !waitTimeout(&wg, time.Millisecond) {
+		t.Error("waiting for no workers timed out")
+	}
+	wg.Add(1)
+	if waitTimeout(&wg, 10*time.Millisecond) {
+		t.Error("waiting for running worker did not time out")
+	}
+	wg.Done()
+	if !waitTimeout(&wg, time.Second) {
+		t.Error("waiting for stopped worker timed out")
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	const key = "USER_TEST_DURATION"
+	defer os.Unsetenv(key)
+	for value, want := range map[string]time.Duration{
+		"":      time.Minute,
+		"15s":   15 * time.Second,
+		"0":     0,
+		"never": time.Minute,
+	} {
+		_ = os.Setenv(key, value)
+		if got := envDuration(key, time.Minute); got != want {
+			t.Errorf("envDuration(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
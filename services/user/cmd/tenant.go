@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// commandTenant subcommand provisioning tenants, e.g. "tenant create acme"
+const commandTenant = "tenant"
+
+// errTenantUsage returned when tenant subcommand arguments are invalid
+var errTenantUsage = errors.New("usage: tenant create <slug> [name]")
+
+// runTenant run tenant subcommand with its arguments, id of created tenant
+// is printed so it can be sent as tenant header
+func runTenant(ctx context.Context, tenants user.TenantRepository, args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[0] != "create" {
+		return errTenantUsage
+	}
+	var name string
+	if len(args) == 3 {
+		name = args[2]
+	}
+	tenant, err := user.NewTenant(args[1], name)
+	if err != nil {
+		return err
+	}
+	if err := tenants.CreateTenant(ctx, tenant); err != nil {
+		return err
+	}
+	fmt.Println(tenant.ID)
+	return nil
+}
+
+// ensureTenant create tenant of slug unless it exists, instances starting
+// together may race to create it so slug taken meanwhile is fine
+func ensureTenant(
+	ctx context.Context,
+	logger log.Logger,
+	tenants user.TenantRepository,
+	slug string,
+) error {
+	_, err := tenants.GetTenant(ctx, slug)
+	if err != user.ErrTenantNotFound {
+		return err
+	}
+	tenant, err := user.NewTenant(slug, "")
+	if err != nil {
+		return err
+	}
+	err = tenants.CreateTenant(ctx, tenant)
+	if err == user.ErrTenantSlugTaken {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_ = level.Info(logger).Log("tenant", tenant.Slug, "id", tenant.ID, "status", "created")
+	return nil
+}
@@ -0,0 +1,181 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	amqptransport "github.com/go-kit/kit/transport/amqp"
+	"github.com/streadway/amqp"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
+)
+
+// Queues commands are consumed from, failed commands are dead lettered
+// through dead letter exchange into dead letter queue
+const (
+	CommandQueue       = "user.commands"
+	DeadLetterExchange = "user.commands.dlx"
+	DeadLetterQueue    = "user.commands.dead"
+)
+
+// ConsumerTag tag of command queue consumer, cancelling it on channel stops
+// new deliveries while those already received are still served
+const ConsumerTag = "user-service"
+
+// Commands named by type property of message, body is json encoded request
+// type of delivery package
+const (
+	CommandRegister       = "register"
+	CommandBulkRegister   = "bulk-register"
+	CommandDeleteUser     = "delete-user"
+	CommandRestoreUser    = "restore-user"
+	CommandSuspendUser    = "suspend-user"
+	CommandReactivateUser = "reactivate-user"
+	CommandDeactivateUser = "deactivate-user"
+	CommandChangePassword = "change-password"
+)
+
+// ErrUnknownCommand returned when message type names no command
+var ErrUnknownCommand = errors.New("unknown command")
+
+// DeclareQueues declare command queue dead lettering into its dead letter
+// queue, declaring is idempotent so every instance may do it on start
+func DeclareQueues(ch *amqp.Channel) error {
+	err := ch.ExchangeDeclare(DeadLetterExchange, amqp.ExchangeFanout, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	_, err = ch.QueueDeclare(DeadLetterQueue, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(DeadLetterQueue, "", DeadLetterExchange, false, nil)
+	if err != nil {
+		return err
+	}
+	_, err = ch.QueueDeclare(CommandQueue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange": DeadLetterExchange,
+	})
+	return err
+}
+
+// NewAMQPServe consume commands of command queue with workers consumers until
+// channel is closed. Successful command is replied to when message has reply
+// to and acked, failed command is replied to and dead lettered. Token, tenant
+// and request id are read from message headers named as their http
+// counterparts
+func NewAMQPServe(
+	ch amqptransport.Channel,
+	svcEndpoints delivery.Endpoints,
+	logger log.Logger,
+	workers int,
+) error {
+	var options []amqptransport.SubscriberOption
+	errorLogger := amqptransport.SubscriberErrorLogger(logger)
+	errorEncoder := amqptransport.SubscriberErrorEncoder(encodeError)
+	responsePublisher := amqptransport.SubscriberResponsePublisher(publishResponse)
+	tokenExtractor := amqptransport.SubscriberBefore(auth.AMQPToContext())
+	tenantExtractor := amqptransport.SubscriberBefore(auth.AMQPToTenantContext())
+	clientExtractor := amqptransport.SubscriberBefore(auth.AMQPToClientContext())
+	options = append(options, errorLogger, errorEncoder, responsePublisher, tokenExtractor, tenantExtractor, clientExtractor)
+
+	routes := []struct {
+		command  string
+		endpoint endpoint.Endpoint
+		request  interface{}
+	}{
+		{CommandRegister, svcEndpoints.Register, delivery.CreateRegisterRequest{}},
+		{CommandBulkRegister, svcEndpoints.BulkRegister, delivery.CreateBulkRegisterRequest{}},
+		{CommandDeleteUser, svcEndpoints.DeleteUser, delivery.CreateDeleteUserRequest{}},
+		{CommandRestoreUser, svcEndpoints.RestoreUser, delivery.CreateRestoreUserRequest{}},
+		{CommandSuspendUser, svcEndpoints.SuspendUser, delivery.CreateSuspendUserRequest{}},
+		{CommandReactivateUser, svcEndpoints.ReactivateUser, delivery.CreateReactivateUserRequest{}},
+		{CommandDeactivateUser, svcEndpoints.DeactivateUser, delivery.CreateDeactivateUserRequest{}},
+		{CommandChangePassword, svcEndpoints.ChangePassword, delivery.CreateChangePasswordRequest{}},
+	}
+	handlers := make(map[string]func(*amqp.Delivery), len(routes))
+	for _, route := range routes {
+		subscriber := amqptransport.NewSubscriber(
+			route.endpoint,
+			decodeRequest(route.request),
+			decodeencode.EncodeAMQPResponse,
+			options...,
+		)
+		handlers[route.command] = subscriber.ServeDelivery(ch)
+	}
+
+	deliveries, err := ch.Consume(CommandQueue, ConsumerTag, false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				d := d
+				handler, ok := handlers[d.Type]
+				if !ok {
+					_ = level.Warn(logger).Log("command", d.Type, "err", ErrUnknownCommand)
+					encodeError(context.Background(), ErrUnknownCommand, &d, ch, &amqp.Publishing{})
+					continue
+				}
+				handler(&d)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// decodeRequest create decoder of delivery body into request of same type as
+// given request
+func decodeRequest(request interface{}) amqptransport.DecodeRequestFunc {
+	decode := decodeencode.DecodeRequest(request)
+	return func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decode(d.Body)
+	}
+}
+
+// publishResponse reply to message when it asks for reply, then ack it
+func publishResponse(
+	ctx context.Context,
+	d *amqp.Delivery,
+	ch amqptransport.Channel,
+	pub *amqp.Publishing,
+) error {
+	if err := reply(d, ch, pub); err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+// encodeError reply error to message when it asks for reply, then reject it
+// without requeue so broker dead letters it
+func encodeError(
+	ctx context.Context,
+	err error,
+	d *amqp.Delivery,
+	ch amqptransport.Channel,
+	pub *amqp.Publishing,
+) {
+	decodeencode.EncodeAMQPErrorResponse(ctx, err, pub)
+	_ = reply(d, ch, pub)
+	_ = d.Nack(false, false)
+}
+
+// reply publish to reply to queue of message through default exchange
+func reply(d *amqp.Delivery, ch amqptransport.Channel, pub *amqp.Publishing) error {
+	if d.ReplyTo == "" {
+		return nil
+	}
+	pub.CorrelationId = d.CorrelationId
+	return ch.Publish("", d.ReplyTo, false, false, *pub)
+}
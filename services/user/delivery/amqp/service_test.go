@@ -0,0 +1,181 @@
+package amqp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/streadway/amqp"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	amqpdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/amqp"
+)
+
+// channel serves queued deliveries once and records replies published and
+// how each delivery was settled by its tag
+type channel struct {
+	mutex      sync.Mutex
+	deliveries []amqp.Delivery
+	published  map[string]amqp.Publishing
+	acked      map[uint64]bool
+	nacked     map[uint64]bool
+}
+
+func newChannel(deliveries ...amqp.Delivery) *channel {
+	ch := &channel{
+		published: map[string]amqp.Publishing{},
+		acked:     map[uint64]bool{},
+		nacked:    map[uint64]bool{},
+	}
+	for _, d := range deliveries {
+		d.Acknowledger = ch
+		ch.deliveries = append(ch.deliveries, d)
+	}
+	return ch
+}
+
+func (ch *channel) Publish(_, key string, _, _ bool, msg amqp.Publishing) error {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+	ch.published[key] = msg
+	return nil
+}
+
+func (ch *channel) Consume(queue, consumer string, autoAck, _, _, _ bool, _ amqp.Table) (<-chan amqp.Delivery, error) {
+	deliveries := make(chan amqp.Delivery, len(ch.deliveries))
+	for _, d := range ch.deliveries {
+		deliveries <- d
+	}
+	close(deliveries)
+	return deliveries, nil
+}
+
+func (ch *channel) Ack(tag uint64, _ bool) error {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+	ch.acked[tag] = true
+	return nil
+}
+
+func (ch *channel) Nack(tag uint64, _ bool, requeue bool) error {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+	ch.nacked[tag] = !requeue
+	return nil
+}
+
+func (ch *channel) Reject(tag uint64, requeue bool) error {
+	return ch.Nack(tag, false, requeue)
+}
+
+// command create delivery of command with json body replying to queue of
+// same name as its correlation id
+func command(t *testing.T, tag uint64, commandType, id string, body interface{}) amqp.Delivery {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amqp.Delivery{
+		DeliveryTag:   tag,
+		Type:          commandType,
+		CorrelationId: id,
+		ReplyTo:       "reply." + id,
+		Headers:       amqp.Table{auth.TenantHeader: "acme"},
+		Body:          data,
+	}
+}
+
+func TestAMQPCommands(t *testing.T) {
+	ch := newChannel(
+		command(t, 1, amqpdelivery.CommandRegister, "register", delivery.CreateRegisterRequest{
+			Email:     "ada@example.com",
+			Passwords: "secret",
+		}),
+		command(t, 2, amqpdelivery.CommandDeleteUser, "delete", delivery.CreateDeleteUserRequest{ID: "missing"}),
+		command(t, 3, "drop-tables", "unknown", struct{}{}),
+	)
+	endpoints := delivery.Endpoints{
+		Register: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(delivery.CreateRegisterRequest)
+			if req.Email != "ada@example.com" || req.Passwords != "secret" {
+				t.Errorf("request = %+v", req)
+			}
+			if key, _ := ctx.Value(auth.TenantKeyContextKey).(string); key != "acme" {
+				t.Errorf("tenant = %q, want acme", key)
+			}
+			return delivery.CreateRegisterResponse{Status: "Success"}, nil
+		},
+		DeleteUser: func(context.Context, interface{}) (interface{}, error) {
+			return delivery.CreateDeleteUserResponse{}, user.ErrUserNotFound
+		},
+	}
+	if err := amqpdelivery.NewAMQPServe(ch, endpoints, log.NewNopLogger(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"register", "delete", "unknown"} {
+		if got := ch.published["reply."+id].CorrelationId; got != id {
+			t.Errorf("correlation id of %s reply = %q, want %q", id, got, id)
+		}
+	}
+
+	var registered delivery.CreateRegisterResponse
+	if err := json.Unmarshal(ch.published["reply.register"].Body, &registered); err != nil {
+		t.Fatal(err)
+	}
+	if registered.Status != "Success" {
+		t.Errorf("register reply = %+v", registered)
+	}
+	if !ch.acked[1] || ch.nacked[1] {
+		t.Errorf("register acked = %v nacked = %v, want acked", ch.acked[1], ch.nacked[1])
+	}
+
+	var failed struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	if err := json.Unmarshal(ch.published["reply.delete"].Body, &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Error != user.ErrUserNotFound.Error() || failed.Code != http.StatusNotFound {
+		t.Errorf("delete reply = %+v, want not found", failed)
+	}
+	if err := json.Unmarshal(ch.published["reply.unknown"].Body, &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Error != amqpdelivery.ErrUnknownCommand.Error() {
+		t.Errorf("unknown command reply = %+v", failed)
+	}
+	// Failed commands are rejected without requeue so broker dead letters them
+	for _, tag := range []uint64{2, 3} {
+		if ch.acked[tag] || !ch.nacked[tag] {
+			t.Errorf("delivery %d acked = %v dead lettered = %v, want dead lettered", tag, ch.acked[tag], ch.nacked[tag])
+		}
+	}
+}
+
+func TestAMQPWithoutReplyTo(t *testing.T) {
+	d := command(t, 1, amqpdelivery.CommandRegister, "register", delivery.CreateRegisterRequest{})
+	d.ReplyTo = ""
+	ch := newChannel(d)
+	endpoints := delivery.Endpoints{
+		Register: func(context.Context, interface{}) (interface{}, error) {
+			return delivery.CreateRegisterResponse{Status: "Success"}, nil
+		},
+	}
+	if err := amqpdelivery.NewAMQPServe(ch, endpoints, log.NewNopLogger(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(ch.published) != 0 {
+		t.Errorf("published = %v, want no reply", ch.published)
+	}
+	if !ch.acked[1] {
+		t.Error("command without reply to was not acked")
+	}
+}
@@ -0,0 +1,39 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// makeListAuditEventsEndpoint using go kit endpoint
+func makeListAuditEventsEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateListAuditEventsRequest)
+		filter := user.AuditFilter{
+			ActorID:       req.ActorID,
+			Target:        req.Target,
+			Action:        req.Action,
+			Outcome:       req.Outcome,
+			CreatedAfter:  req.CreatedAfter,
+			CreatedBefore: req.CreatedBefore,
+		}
+		events, nextPageToken, err := s.ListAuditEvents(
+			ctx,
+			filter,
+			req.PageSize,
+			req.PageToken,
+		)
+		if err != nil {
+			return CreateListAuditEventsResponse{}, err
+		}
+		return CreateListAuditEventsResponse{
+			Events:        events,
+			NextPageToken: nextPageToken,
+		}, nil
+	}
+}
@@ -9,15 +9,79 @@ import (
 
 // Endpoints struct
 type Endpoints struct {
-	Register endpoint.Endpoint
-	Login    endpoint.Endpoint
+	Register       endpoint.Endpoint
+	BulkRegister   endpoint.Endpoint
+	Login          endpoint.Endpoint
+	GetMe          endpoint.Endpoint
+	GetUser        endpoint.Endpoint
+	UpdateUser     endpoint.Endpoint
+	ChangePassword endpoint.Endpoint
+	DeleteUser     endpoint.Endpoint
+	ListUsers      endpoint.Endpoint
+	RestoreUser    endpoint.Endpoint
+	SuspendUser    endpoint.Endpoint
+	ReactivateUser endpoint.Endpoint
+	DeactivateUser endpoint.Endpoint
+
+	CreateOrganization endpoint.Endpoint
+	ListOrganizations  endpoint.Endpoint
+	InviteMember       endpoint.Endpoint
+	AcceptInvitation   endpoint.Endpoint
+	DeclineInvitation  endpoint.Endpoint
+	ListMembers        endpoint.Endpoint
+	ChangeMemberRole   endpoint.Endpoint
+	RemoveMember       endpoint.Endpoint
+	LeaveOrganization  endpoint.Endpoint
+
+	ListSessions  endpoint.Endpoint
+	RevokeSession endpoint.Endpoint
+
+	ListAuditEvents endpoint.Endpoint
+
+	CreateWebhook         endpoint.Endpoint
+	ListWebhooks          endpoint.Endpoint
+	DeleteWebhook         endpoint.Endpoint
+	ListWebhookDeliveries endpoint.Endpoint
+	RedeliverWebhook      endpoint.Endpoint
 }
 
 // MakeEndpoints initialize all registered endpoint
 func MakeEndpoints(s user.Service) Endpoints {
 	return Endpoints{
-		Register: makeRegisterEndpoint(s),
-		Login:    makeLoginEndpoint(s),
+		Register:       makeRegisterEndpoint(s),
+		BulkRegister:   makeBulkRegisterEndpoint(s),
+		Login:          makeLoginEndpoint(s),
+		GetMe:          makeGetMeEndpoint(s),
+		GetUser:        makeGetUserEndpoint(s),
+		UpdateUser:     makeUpdateUserEndpoint(s),
+		ChangePassword: makeChangePasswordEndpoint(s),
+		DeleteUser:     makeDeleteUserEndpoint(s),
+		ListUsers:      makeListUsersEndpoint(s),
+		RestoreUser:    makeRestoreUserEndpoint(s),
+		SuspendUser:    makeSuspendUserEndpoint(s),
+		ReactivateUser: makeReactivateUserEndpoint(s),
+		DeactivateUser: makeDeactivateUserEndpoint(s),
+
+		CreateOrganization: makeCreateOrganizationEndpoint(s),
+		ListOrganizations:  makeListOrganizationsEndpoint(s),
+		InviteMember:       makeInviteMemberEndpoint(s),
+		AcceptInvitation:   makeAcceptInvitationEndpoint(s),
+		DeclineInvitation:  makeDeclineInvitationEndpoint(s),
+		ListMembers:        makeListMembersEndpoint(s),
+		ChangeMemberRole:   makeChangeMemberRoleEndpoint(s),
+		RemoveMember:       makeRemoveMemberEndpoint(s),
+		LeaveOrganization:  makeLeaveOrganizationEndpoint(s),
+
+		ListSessions:  makeListSessionsEndpoint(s),
+		RevokeSession: makeRevokeSessionEndpoint(s),
+
+		ListAuditEvents: makeListAuditEventsEndpoint(s),
+
+		CreateWebhook:         makeCreateWebhookEndpoint(s),
+		ListWebhooks:          makeListWebhooksEndpoint(s),
+		DeleteWebhook:         makeDeleteWebhookEndpoint(s),
+		ListWebhookDeliveries: makeListWebhookDeliveriesEndpoint(s),
+		RedeliverWebhook:      makeRedeliverWebhookEndpoint(s),
 	}
 }
 
@@ -33,6 +97,28 @@ func makeRegisterEndpoint(s user.Service) endpoint.Endpoint {
 	}
 }
 
+// makeBulkRegisterEndpoint using go kit endpoint, failing user does not stop
+// registration of the rest
+func makeBulkRegisterEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateBulkRegisterRequest)
+		results := make([]CreateBulkRegisterResult, 0, len(req.Users))
+		for _, u := range req.Users {
+			result := CreateBulkRegisterResult{Email: u.Email}
+			status, err := s.Register(ctx, u.Email, u.Passwords)
+			result.Status = status
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		}
+		return CreateBulkRegisterResponse{Results: results}, nil
+	}
+}
+
 // makeLoginEndpoint using go kit endpoint
 func makeLoginEndpoint(s user.Service) endpoint.Endpoint {
 	return func(
@@ -40,7 +126,179 @@ func makeLoginEndpoint(s user.Service) endpoint.Endpoint {
 		request interface{},
 	) (interface{}, error) {
 		req := request.(CreateLoginRequest)
-		status, err := s.Login(ctx, req.Email, req.Passwords)
-		return CreateLoginResponse{Status: status}, err
+		token, err := s.Login(ctx, req.Email, req.Passwords, req.DeviceLabel)
+		if err != nil {
+			return CreateLoginResponse{}, err
+		}
+		return CreateLoginResponse{Status: "Success", Token: token}, nil
+	}
+}
+
+// makeGetMeEndpoint using go kit endpoint
+func makeGetMeEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		_ interface{},
+	) (interface{}, error) {
+		selectedUser, err := s.GetMe(ctx)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*selectedUser)}, nil
+	}
+}
+
+// makeGetUserEndpoint using go kit endpoint
+func makeGetUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateGetUserRequest)
+		selectedUser, err := s.GetUser(ctx, req.ID)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*selectedUser)}, nil
+	}
+}
+
+// makeUpdateUserEndpoint using go kit endpoint
+func makeUpdateUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateUpdateUserRequest)
+		updatedUser, err := s.UpdateUser(ctx, req.ID, user.Update{
+			Email: req.Email,
+			Name:  req.Name,
+			Bio:   req.Bio,
+			Paths: req.UpdateMask,
+		})
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*updatedUser)}, nil
+	}
+}
+
+// makeChangePasswordEndpoint using go kit endpoint
+func makeChangePasswordEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateChangePasswordRequest)
+		err := s.ChangePassword(ctx, req.ID, req.CurrentPassword, req.NewPassword)
+		if err != nil {
+			return CreateChangePasswordResponse{}, err
+		}
+		return CreateChangePasswordResponse{Status: "Success"}, nil
+	}
+}
+
+// makeDeleteUserEndpoint using go kit endpoint
+func makeDeleteUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateDeleteUserRequest)
+		if err := s.DeleteUser(ctx, req.ID); err != nil {
+			return CreateDeleteUserResponse{}, err
+		}
+		return CreateDeleteUserResponse{Status: "Success"}, nil
+	}
+}
+
+// makeListUsersEndpoint using go kit endpoint
+func makeListUsersEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateListUsersRequest)
+		filter := user.ListFilter{
+			EmailPrefix:   req.EmailPrefix,
+			Status:        req.Status,
+			Role:          req.Role,
+			CreatedAfter:  req.CreatedAfter,
+			CreatedBefore: req.CreatedBefore,
+		}
+		users, nextPageToken, err := s.ListUsers(
+			ctx, filter, req.OrderBy, req.PageSize, req.PageToken,
+		)
+		if err != nil {
+			return CreateListUsersResponse{}, err
+		}
+		profiles := make([]Profile, 0, len(users))
+		for _, u := range users {
+			profiles = append(profiles, NewProfile(u))
+		}
+		return CreateListUsersResponse{
+			Users:         profiles,
+			NextPageToken: nextPageToken,
+		}, nil
+	}
+}
+
+// makeRestoreUserEndpoint using go kit endpoint
+func makeRestoreUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateRestoreUserRequest)
+		restoredUser, err := s.RestoreUser(ctx, req.ID)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*restoredUser)}, nil
+	}
+}
+
+// makeSuspendUserEndpoint using go kit endpoint
+func makeSuspendUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateSuspendUserRequest)
+		suspendedUser, err := s.SuspendUser(ctx, req.ID, req.Reason)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*suspendedUser)}, nil
+	}
+}
+
+// makeReactivateUserEndpoint using go kit endpoint
+func makeReactivateUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateReactivateUserRequest)
+		reactivatedUser, err := s.ReactivateUser(ctx, req.ID, req.Reason)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*reactivatedUser)}, nil
+	}
+}
+
+// makeDeactivateUserEndpoint using go kit endpoint
+func makeDeactivateUserEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateDeactivateUserRequest)
+		deactivatedUser, err := s.DeactivateUser(ctx, req.ID, req.Reason)
+		if err != nil {
+			return CreateUserResponse{}, err
+		}
+		return CreateUserResponse{User: NewProfile(*deactivatedUser)}, nil
 	}
 }
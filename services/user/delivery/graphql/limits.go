@@ -0,0 +1,159 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// Limits of a single request, introspection fields are not counted
+const (
+	MaxDepth      = 5
+	MaxComplexity = 1000
+)
+
+// analyzer computes depth and complexity of operations in document, every
+// field costs one and children of a field with first argument are
+// multiplied by page size they request
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// cost of fragments already walked, so spreading fragments many times
+	// does not multiply the walk
+	costs map[string]cost
+	cycle string
+}
+
+// cost depth and complexity of selection set
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// checkLimits returns error when any operation in document is deeper or more
+// complex than allowed, fragment cycles are rejected here as well since
+// validation of them never ends
+func checkLimits(doc *ast.Document, variables map[string]interface{}) error {
+	a := &analyzer{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		costs:     map[string]cost{},
+	}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for name := range a.fragments {
+		a.fragment(name, map[string]bool{})
+		if a.cycle != "" {
+			return fmt.Errorf("fragment %q spreads itself", a.cycle)
+		}
+	}
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		c := a.selectionSet(operation.SelectionSet, map[string]bool{})
+		if c.depth > MaxDepth {
+			return fmt.Errorf("query depth %d exceeds limit of %d", c.depth, MaxDepth)
+		}
+		if c.complexity > MaxComplexity {
+			return fmt.Errorf("query complexity exceeds limit of %d", MaxComplexity)
+		}
+	}
+	return nil
+}
+
+// selectionSet returns cost of selection set, depth counts levels of fields
+// it selects and fragments on path are tracked to detect cycles
+func (a *analyzer) selectionSet(set *ast.SelectionSet, path map[string]bool) cost {
+	var total cost
+	if set == nil {
+		return total
+	}
+	for _, selection := range set.Selections {
+		var c cost
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			children := a.selectionSet(s.SelectionSet, path)
+			c.depth = 1 + children.depth
+			c.complexity = 1 + a.multiplier(s)*children.complexity
+		case *ast.InlineFragment:
+			c = a.selectionSet(s.SelectionSet, path)
+		case *ast.FragmentSpread:
+			c = a.fragment(s.Name.Value, path)
+		}
+		if c.depth > total.depth {
+			total.depth = c.depth
+		}
+		// Stop adding up once over limit, fan out of fragments could
+		// otherwise overflow
+		total.complexity += c.complexity
+		if total.complexity > MaxComplexity {
+			total.complexity = MaxComplexity + 1
+		}
+	}
+	return total
+}
+
+// fragment returns cost of named fragment, unknown fragments are left to
+// validation and cost nothing
+func (a *analyzer) fragment(name string, path map[string]bool) cost {
+	if c, ok := a.costs[name]; ok {
+		return c
+	}
+	fragment, ok := a.fragments[name]
+	if !ok {
+		return cost{}
+	}
+	if path[name] {
+		a.cycle = name
+		return cost{}
+	}
+	path[name] = true
+	c := a.selectionSet(fragment.SelectionSet, path)
+	delete(path, name)
+	a.costs[name] = c
+	return c
+}
+
+// multiplier returns page size field requests through first argument, users
+// field without it is listed with default page size
+func (a *analyzer) multiplier(field *ast.Field) int {
+	first := 0
+	for _, argument := range field.Arguments {
+		if argument.Name == nil || argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			first, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			if n, ok := a.variables[value.Name.Value].(float64); ok {
+				first = int(n)
+			}
+		}
+		if first <= 0 {
+			first = user.DefaultPageSize
+		}
+	}
+	if first == 0 && field.Name.Value == "users" {
+		first = user.DefaultPageSize
+	}
+	if first == 0 {
+		return 1
+	}
+	if first > user.MaxPageSize {
+		return user.MaxPageSize
+	}
+	return first
+}
@@ -0,0 +1,281 @@
+package graphql
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	gql "github.com/graphql-go/graphql"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
+)
+
+// resolverError endpoint error carrying its graphql error code
+type resolverError struct {
+	err error
+}
+
+func (e resolverError) Error() string { return e.err.Error() }
+
+// Extensions returns code of error under graphql error extensions
+func (e resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": decodeencode.GraphQLErrorCode(e.err)}
+}
+
+var userType = gql.NewObject(gql.ObjectConfig{
+	Name: "User",
+	Fields: gql.Fields{
+		"id":        &gql.Field{Type: gql.NewNonNull(gql.ID)},
+		"email":     &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"name":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"bio":       &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"role":      &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"status":    &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"createdAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: profileField(func(p delivery.Profile) interface{} { return p.CreatedAt })},
+		"updatedAt": &gql.Field{Type: gql.NewNonNull(gql.DateTime), Resolve: profileField(func(p delivery.Profile) interface{} { return p.UpdatedAt })},
+	},
+})
+
+var pageInfoType = gql.NewObject(gql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: gql.Fields{
+		"endCursor":   &gql.Field{Type: gql.String},
+		"hasNextPage": &gql.Field{Type: gql.NewNonNull(gql.Boolean)},
+	},
+})
+
+var userConnectionType = gql.NewObject(gql.ObjectConfig{
+	Name: "UserConnection",
+	Fields: gql.Fields{
+		"nodes":    &gql.Field{Type: gql.NewNonNull(gql.NewList(gql.NewNonNull(userType)))},
+		"pageInfo": &gql.Field{Type: gql.NewNonNull(pageInfoType)},
+	},
+})
+
+var userFilterType = gql.NewInputObject(gql.InputObjectConfig{
+	Name: "UserFilter",
+	Fields: gql.InputObjectConfigFieldMap{
+		"emailPrefix":   &gql.InputObjectFieldConfig{Type: gql.String},
+		"status":        &gql.InputObjectFieldConfig{Type: gql.String},
+		"role":          &gql.InputObjectFieldConfig{Type: gql.String},
+		"createdAfter":  &gql.InputObjectFieldConfig{Type: gql.DateTime},
+		"createdBefore": &gql.InputObjectFieldConfig{Type: gql.DateTime},
+		"orderBy":       &gql.InputObjectFieldConfig{Type: gql.String},
+	},
+})
+
+var updateProfileInputType = gql.NewInputObject(gql.InputObjectConfig{
+	Name:        "UpdateProfileInput",
+	Description: "Only fields present in input are changed",
+	Fields: gql.InputObjectConfigFieldMap{
+		user.FieldEmail: &gql.InputObjectFieldConfig{Type: gql.String},
+		user.FieldName:  &gql.InputObjectFieldConfig{Type: gql.String},
+		user.FieldBio:   &gql.InputObjectFieldConfig{Type: gql.String},
+	},
+})
+
+var registerPayloadType = gql.NewObject(gql.ObjectConfig{
+	Name: "RegisterPayload",
+	Fields: gql.Fields{
+		"status": &gql.Field{Type: gql.NewNonNull(gql.String)},
+	},
+})
+
+var loginPayloadType = gql.NewObject(gql.ObjectConfig{
+	Name: "LoginPayload",
+	Fields: gql.Fields{
+		"status": &gql.Field{Type: gql.NewNonNull(gql.String)},
+		"token":  &gql.Field{Type: gql.NewNonNull(gql.String)},
+	},
+})
+
+// pageInfo struct, resolved by default resolver of page info type
+type pageInfo struct {
+	EndCursor   *string `json:"endCursor"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
+// userConnection struct, resolved by default resolver of user connection type
+type userConnection struct {
+	Nodes    []delivery.Profile `json:"nodes"`
+	PageInfo pageInfo           `json:"pageInfo"`
+}
+
+// newSchema create schema whose resolvers call endpoints, so endpoint
+// middlewares apply to every query and mutation
+func newSchema(svcEndpoints delivery.Endpoints) (gql.Schema, error) {
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"me": &gql.Field{
+				Type: gql.NewNonNull(userType),
+				Resolve: resolve(svcEndpoints.GetMe, func(gql.ResolveParams) interface{} {
+					return delivery.CreateGetMeRequest{}
+				}, userResult),
+			},
+			"user": &gql.Field{
+				Type: gql.NewNonNull(userType),
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.ID)},
+				},
+				Resolve: resolve(svcEndpoints.GetUser, func(p gql.ResolveParams) interface{} {
+					return delivery.CreateGetUserRequest{ID: stringArg(p.Args, "id")}
+				}, userResult),
+			},
+			"users": &gql.Field{
+				Type: gql.NewNonNull(userConnectionType),
+				Args: gql.FieldConfigArgument{
+					"filter": &gql.ArgumentConfig{Type: userFilterType},
+					"first":  &gql.ArgumentConfig{Type: gql.Int},
+					"after":  &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: resolve(svcEndpoints.ListUsers, listUsersRequest, listUsersResult),
+			},
+		},
+	})
+	mutation := gql.NewObject(gql.ObjectConfig{
+		Name: "Mutation",
+		Fields: gql.Fields{
+			"register": &gql.Field{
+				Type: gql.NewNonNull(registerPayloadType),
+				Args: gql.FieldConfigArgument{
+					"email":     &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"passwords": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: resolve(svcEndpoints.Register, func(p gql.ResolveParams) interface{} {
+					return delivery.CreateRegisterRequest{
+						Email:     stringArg(p.Args, "email"),
+						Passwords: stringArg(p.Args, "passwords"),
+					}
+				}, nil),
+			},
+			"login": &gql.Field{
+				Type: gql.NewNonNull(loginPayloadType),
+				Args: gql.FieldConfigArgument{
+					"email":       &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"passwords":   &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+					"deviceLabel": &gql.ArgumentConfig{Type: gql.String},
+				},
+				Resolve: resolve(svcEndpoints.Login, func(p gql.ResolveParams) interface{} {
+					return delivery.CreateLoginRequest{
+						Email:       stringArg(p.Args, "email"),
+						Passwords:   stringArg(p.Args, "passwords"),
+						DeviceLabel: stringArg(p.Args, "deviceLabel"),
+					}
+				}, nil),
+			},
+			"updateProfile": &gql.Field{
+				Type: gql.NewNonNull(userType),
+				Args: gql.FieldConfigArgument{
+					"input": &gql.ArgumentConfig{Type: gql.NewNonNull(updateProfileInputType)},
+				},
+				Resolve: updateProfile(svcEndpoints),
+			},
+		},
+	})
+	return gql.NewSchema(gql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// resolve create resolver calling endpoint with request built from
+// arguments, nil result passes response through unchanged
+func resolve(
+	e endpoint.Endpoint,
+	request func(gql.ResolveParams) interface{},
+	result func(interface{}) interface{},
+) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		response, err := e(p.Context, request(p))
+		if err != nil {
+			return nil, resolverError{err: err}
+		}
+		if result == nil {
+			return response, nil
+		}
+		return result(response), nil
+	}
+}
+
+// updateProfile create resolver changing profile of token owner, update
+// mask is made of fields present in input
+func updateProfile(svcEndpoints delivery.Endpoints) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		input, _ := p.Args["input"].(map[string]interface{})
+		me, err := svcEndpoints.GetMe(p.Context, delivery.CreateGetMeRequest{})
+		if err != nil {
+			return nil, resolverError{err: err}
+		}
+		req := delivery.CreateUpdateUserRequest{
+			ID:    me.(delivery.CreateUserResponse).User.ID,
+			Email: stringArg(input, user.FieldEmail),
+			Name:  stringArg(input, user.FieldName),
+			Bio:   stringArg(input, user.FieldBio),
+		}
+		for _, field := range []string{user.FieldEmail, user.FieldName, user.FieldBio} {
+			if _, ok := input[field]; ok {
+				req.UpdateMask = append(req.UpdateMask, field)
+			}
+		}
+		response, err := svcEndpoints.UpdateUser(p.Context, req)
+		if err != nil {
+			return nil, resolverError{err: err}
+		}
+		return userResult(response), nil
+	}
+}
+
+// listUsersRequest build list users request from filter and cursor arguments
+func listUsersRequest(p gql.ResolveParams) interface{} {
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	req := delivery.CreateListUsersRequest{
+		EmailPrefix:   stringArg(filter, "emailPrefix"),
+		Status:        stringArg(filter, "status"),
+		Role:          stringArg(filter, "role"),
+		CreatedAfter:  timeArg(filter, "createdAfter"),
+		CreatedBefore: timeArg(filter, "createdBefore"),
+		OrderBy:       stringArg(filter, "orderBy"),
+		PageToken:     stringArg(p.Args, "after"),
+	}
+	if first, ok := p.Args["first"].(int); ok {
+		req.PageSize = first
+	}
+	return req
+}
+
+// listUsersResult convert list users response into user connection
+func listUsersResult(response interface{}) interface{} {
+	resp := response.(delivery.CreateListUsersResponse)
+	connection := userConnection{Nodes: resp.Users}
+	if resp.NextPageToken != "" {
+		connection.PageInfo = pageInfo{
+			EndCursor:   &resp.NextPageToken,
+			HasNextPage: true,
+		}
+	}
+	return connection
+}
+
+// userResult returns profile of user response
+func userResult(response interface{}) interface{} {
+	return response.(delivery.CreateUserResponse).User
+}
+
+// profileField create resolver of profile field the default resolver cannot
+// map by json tag
+func profileField(value func(delivery.Profile) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return value(p.Source.(delivery.Profile)), nil
+	}
+}
+
+// stringArg returns string argument, absent or null argument is empty
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+// timeArg returns time argument, absent or null argument is zero time
+func timeArg(args map[string]interface{}, name string) time.Time {
+	value, _ := args[name].(time.Time)
+	return value
+}
@@ -0,0 +1,108 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/log"
+	httptransport "github.com/go-kit/kit/transport/http"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+)
+
+// handler serves graphql queries and mutations of user service
+type handler struct {
+	schema gql.Schema
+	before []httptransport.RequestFunc
+	logger log.Logger
+}
+
+// request body of graphql request
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewGraphQLServe create graphql handler of endpoints, resolvers call
+// endpoints so their middlewares apply
+func NewGraphQLServe(
+	svcEndpoints delivery.Endpoints,
+	logger log.Logger,
+	tenantDomain string,
+	proxies auth.TrustedProxies,
+) http.Handler {
+	// Schema is built of static types, failing means types are invalid
+	schema, err := newSchema(svcEndpoints)
+	if err != nil {
+		panic(err)
+	}
+	return &handler{
+		schema: schema,
+		before: []httptransport.RequestFunc{
+			kitjwt.HTTPToContext(),
+			auth.HTTPToTenantContext(tenantDomain),
+			auth.HTTPToClientContext(proxies),
+		},
+		logger: logger,
+	}
+}
+
+// ServeHTTP serve graphql request, request errors are replied with bad
+// request status and errors of resolvers within data response
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrors(w, gqlerrors.FormatErrors(err))
+		return
+	}
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		h.writeErrors(w, gqlerrors.FormatErrors(err))
+		return
+	}
+	if err := checkLimits(doc, req.Variables); err != nil {
+		h.writeErrors(w, gqlerrors.FormatErrors(err))
+		return
+	}
+	if validation := gql.ValidateDocument(&h.schema, doc, nil); !validation.IsValid {
+		h.writeErrors(w, validation.Errors)
+		return
+	}
+
+	ctx := r.Context()
+	for _, f := range h.before {
+		ctx = f(ctx, r)
+	}
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	for _, resultErr := range result.Errors {
+		_ = h.logger.Log("transport", "GraphQL", "err", resultErr.Message)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// writeErrors reply request errors without data
+func (h *handler) writeErrors(w http.ResponseWriter, errs []gqlerrors.FormattedError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(gql.Result{Errors: errs})
+}
@@ -0,0 +1,130 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/graphql"
+)
+
+// result graphql response with messages of its errors
+type result struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// query post query with variables to handler listing no users
+func query(t *testing.T, q string, variables map[string]interface{}) (int, result) {
+	t.Helper()
+	h := graphql.NewGraphQLServe(delivery.Endpoints{
+		ListUsers: func(context.Context, interface{}) (interface{}, error) {
+			return delivery.CreateListUsersResponse{}, nil
+		},
+	}, log.NewNopLogger(), "", nil)
+	body, _ := json.Marshal(map[string]interface{}{"query": q, "variables": variables})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	var res result
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, res
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		// rejected is part of error message, empty when query is served
+		rejected string
+	}{
+		{
+			name:  "page within complexity",
+			query: `{ users(first: 100) { nodes { id email } } }`,
+		},
+		{
+			name:  "default page size",
+			query: `{ users { nodes { id email } pageInfo { hasNextPage } } }`,
+		},
+		{
+			name:     "page over complexity",
+			query:    `{ users(first: 500) { nodes { id email } } }`,
+			rejected: "complexity",
+		},
+		{
+			name:     "page size over maximum counts as maximum",
+			query:    `{ users(first: 100000) { nodes { id email } } }`,
+			rejected: "complexity",
+		},
+		{
+			name:      "page size of variable",
+			query:     `query($n: Int) { users(first: $n) { nodes { id email } } }`,
+			variables: map[string]interface{}{"n": 500},
+			rejected:  "complexity",
+		},
+		{
+			name: "aliases add up",
+			query: `{
+				a: users(first: 200) { nodes { id email } }
+				b: users(first: 200) { nodes { id email } }
+			}`,
+			rejected: "complexity",
+		},
+		{
+			name: "fragments spread many times add up",
+			query: `
+				fragment page on Query { users(first: 200) { nodes { id email } } }
+				{ ...page ... on Query { ...page } }`,
+			rejected: "complexity",
+		},
+		{
+			name:     "too deep",
+			query:    `{ a { b { c { d { e { f } } } } } }`,
+			rejected: "depth 6",
+		},
+		{
+			name: "depth through fragments",
+			query: `
+				fragment deep on D { e { f } }
+				{ a { b { c { d { ...deep } } } } }`,
+			rejected: "depth 6",
+		},
+		{
+			name: "fragment cycle",
+			query: `
+				fragment a on User { ...b }
+				fragment b on User { ...a }
+				{ me { ...a } }`,
+			rejected: "spreads itself",
+		},
+		{
+			name:  "introspection is not counted",
+			query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, res := query(t, tt.query, tt.variables)
+			if tt.rejected == "" {
+				if status != http.StatusOK || len(res.Errors) != 0 {
+					t.Fatalf("status = %d errors = %+v, want served", status, res.Errors)
+				}
+				return
+			}
+			if status != http.StatusBadRequest || len(res.Errors) != 1 ||
+				!strings.Contains(res.Errors[0].Message, tt.rejected) {
+				t.Fatalf("status = %d errors = %+v, want %q", status, res.Errors, tt.rejected)
+			}
+		})
+	}
+}
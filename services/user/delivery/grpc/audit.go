@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *grpcServer) ListAuditEvents(
+	ctx oldcontext.Context, req *user_grpc.ListAuditEventsRequest,
+) (*user_grpc.ListAuditEventsResponse, error) {
+	_, rep, err := s.listAuditEvents.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListAuditEventsResponse), nil
+}
+
+// decodeListAuditEventsRequest to json
+func decodeListAuditEventsRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ListAuditEventsRequest)
+	listRequest := delivery.CreateListAuditEventsRequest{
+		ActorID:   req.ActorId,
+		Target:    req.Target,
+		Action:    req.Action,
+		Outcome:   req.Outcome,
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+	if req.CreatedAfter != nil {
+		listRequest.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		listRequest.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+	return listRequest, nil
+}
+
+// encodeListAuditEventsResponse to json
+func encodeListAuditEventsResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListAuditEventsResponse)
+	events := make([]*user_grpc.AuditEvent, 0, len(res.Events))
+	for _, event := range res.Events {
+		events = append(events, &user_grpc.AuditEvent{
+			Sequence:  event.Sequence,
+			ActorId:   event.ActorID,
+			Target:    event.Target,
+			Action:    event.Action,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+			Ip:        event.IP,
+			UserAgent: event.UserAgent,
+			RequestId: event.RequestID,
+			CreatedAt: timestamppb.New(event.CreatedAt),
+			PrevHash:  event.PrevHash,
+			Hash:      event.Hash,
+		})
+	}
+	return &user_grpc.ListAuditEventsResponse{
+		Events:        events,
+		NextPageToken: res.NextPageToken,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Identify error and returns grpc status code
+func codeFrom(err error) codes.Code {
+	switch err {
+	case user.ErrUserNotFound,
+		user.ErrTenantNotFound,
+		user.ErrOrganizationNotFound,
+		user.ErrNotMember,
+		user.ErrInvitationNotFound,
+		user.ErrSessionNotFound,
+		user.ErrWebhookNotFound,
+		user.ErrDeliveryNotFound:
+		return codes.NotFound
+	case user.ErrInvalidUserID,
+		user.ErrTenantRequired,
+		user.ErrInvalidOrgID,
+		user.ErrInvalidOrgRole,
+		user.ErrInvalidSessionID,
+		user.ErrInvalidWebhookID,
+		user.ErrInvalidWebhookURL,
+		user.ErrInvalidWebhookEvent,
+		user.ErrInvalidDeliveryID,
+		user.ErrEmptyUpdateMask,
+		user.ErrInvalidUpdateMask,
+		user.ErrInvalidOrderBy,
+		user.ErrInvalidPageToken:
+		return codes.InvalidArgument
+	case user.ErrInvalidCredentials,
+		auth.ErrUnauthenticated,
+		user.ErrSessionRevoked,
+		kitjwt.ErrTokenContextMissing,
+		kitjwt.ErrTokenInvalid,
+		kitjwt.ErrTokenExpired,
+		kitjwt.ErrTokenMalformed,
+		kitjwt.ErrTokenNotActive,
+		kitjwt.ErrUnexpectedSigningMethod:
+		return codes.Unauthenticated
+	case user.ErrPermissionDenied,
+		user.ErrTenantMismatch,
+		user.ErrInvitationEmail,
+		user.ErrAccountPending,
+		user.ErrAccountSuspended,
+		user.ErrAccountLocked,
+		user.ErrAccountDeactivated:
+		return codes.PermissionDenied
+	case user.ErrStatusTransition,
+		user.ErrLastOwner,
+		user.ErrInvitationExpired:
+		return codes.FailedPrecondition
+	case user.ErrAlreadyMember,
+		user.ErrEmailTaken:
+		return codes.AlreadyExists
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	case context.Canceled:
+		return codes.Canceled
+	default:
+		return codes.Unknown
+	}
+}
+
+// encodeError convert business logic error into grpc status error
+func encodeError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codeFrom(err), err.Error())
+}
@@ -0,0 +1,325 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *grpcServer) CreateOrganization(
+	ctx oldcontext.Context, req *user_grpc.CreateOrganizationRequest,
+) (*user_grpc.CreateOrganizationResponse, error) {
+	_, rep, err := s.createOrganization.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.CreateOrganizationResponse), nil
+}
+
+func (s *grpcServer) ListOrganizations(
+	ctx oldcontext.Context, req *user_grpc.ListOrganizationsRequest,
+) (*user_grpc.ListOrganizationsResponse, error) {
+	_, rep, err := s.listOrganizations.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListOrganizationsResponse), nil
+}
+
+func (s *grpcServer) InviteMember(
+	ctx oldcontext.Context, req *user_grpc.InviteMemberRequest,
+) (*user_grpc.InviteMemberResponse, error) {
+	_, rep, err := s.inviteMember.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.InviteMemberResponse), nil
+}
+
+func (s *grpcServer) AcceptInvitation(
+	ctx oldcontext.Context, req *user_grpc.AcceptInvitationRequest,
+) (*user_grpc.AcceptInvitationResponse, error) {
+	_, rep, err := s.acceptInvitation.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.AcceptInvitationResponse), nil
+}
+
+func (s *grpcServer) DeclineInvitation(
+	ctx oldcontext.Context, req *user_grpc.DeclineInvitationRequest,
+) (*user_grpc.DeclineInvitationResponse, error) {
+	_, rep, err := s.declineInvitation.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.DeclineInvitationResponse), nil
+}
+
+func (s *grpcServer) ListMembers(
+	ctx oldcontext.Context, req *user_grpc.ListMembersRequest,
+) (*user_grpc.ListMembersResponse, error) {
+	_, rep, err := s.listMembers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListMembersResponse), nil
+}
+
+func (s *grpcServer) ChangeMemberRole(
+	ctx oldcontext.Context, req *user_grpc.ChangeMemberRoleRequest,
+) (*user_grpc.ChangeMemberRoleResponse, error) {
+	_, rep, err := s.changeMemberRole.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ChangeMemberRoleResponse), nil
+}
+
+func (s *grpcServer) RemoveMember(
+	ctx oldcontext.Context, req *user_grpc.RemoveMemberRequest,
+) (*user_grpc.RemoveMemberResponse, error) {
+	_, rep, err := s.removeMember.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.RemoveMemberResponse), nil
+}
+
+func (s *grpcServer) LeaveOrganization(
+	ctx oldcontext.Context, req *user_grpc.LeaveOrganizationRequest,
+) (*user_grpc.LeaveOrganizationResponse, error) {
+	_, rep, err := s.leaveOrganization.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.LeaveOrganizationResponse), nil
+}
+
+// decodeCreateOrganizationRequest to json
+func decodeCreateOrganizationRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.CreateOrganizationRequest)
+	return delivery.CreateOrganizationRequest{Name: req.Name}, nil
+}
+
+// decodeListOrganizationsRequest to json
+func decodeListOrganizationsRequest(
+	_ context.Context,
+	_ interface{},
+) (interface{}, error) {
+	return delivery.CreateListOrganizationsRequest{}, nil
+}
+
+// decodeInviteMemberRequest to json
+func decodeInviteMemberRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.InviteMemberRequest)
+	return delivery.CreateInviteMemberRequest{
+		OrganizationID: req.OrganizationId,
+		Email:          req.Email,
+		Role:           req.Role,
+	}, nil
+}
+
+// decodeAcceptInvitationRequest to json
+func decodeAcceptInvitationRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.AcceptInvitationRequest)
+	return delivery.CreateAcceptInvitationRequest{Token: req.Token}, nil
+}
+
+// decodeDeclineInvitationRequest to json
+func decodeDeclineInvitationRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.DeclineInvitationRequest)
+	return delivery.CreateDeclineInvitationRequest{Token: req.Token}, nil
+}
+
+// decodeListMembersRequest to json
+func decodeListMembersRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ListMembersRequest)
+	return delivery.CreateListMembersRequest{
+		OrganizationID: req.OrganizationId,
+	}, nil
+}
+
+// decodeChangeMemberRoleRequest to json
+func decodeChangeMemberRoleRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ChangeMemberRoleRequest)
+	return delivery.CreateChangeMemberRoleRequest{
+		OrganizationID: req.OrganizationId,
+		UserID:         req.UserId,
+		Role:           req.Role,
+	}, nil
+}
+
+// decodeRemoveMemberRequest to json
+func decodeRemoveMemberRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.RemoveMemberRequest)
+	return delivery.CreateRemoveMemberRequest{
+		OrganizationID: req.OrganizationId,
+		UserID:         req.UserId,
+	}, nil
+}
+
+// decodeLeaveOrganizationRequest to json
+func decodeLeaveOrganizationRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.LeaveOrganizationRequest)
+	return delivery.CreateLeaveOrganizationRequest{
+		OrganizationID: req.OrganizationId,
+	}, nil
+}
+
+// encodeCreateOrganizationResponse to json
+func encodeCreateOrganizationResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateOrganizationResponse)
+	return &user_grpc.CreateOrganizationResponse{
+		Organization: encodeOrganization(res.Organization),
+	}, nil
+}
+
+// encodeListOrganizationsResponse to json
+func encodeListOrganizationsResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListOrganizationsResponse)
+	organizations := make([]*user_grpc.Organization, 0, len(res.Organizations))
+	for _, organization := range res.Organizations {
+		organizations = append(organizations, encodeOrganization(organization))
+	}
+	return &user_grpc.ListOrganizationsResponse{
+		Organizations: organizations,
+	}, nil
+}
+
+// encodeInviteMemberResponse to json
+func encodeInviteMemberResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateInviteMemberResponse)
+	return &user_grpc.InviteMemberResponse{
+		Invitation: &user_grpc.Invitation{
+			Id:             res.Invitation.ID.String(),
+			OrganizationId: res.Invitation.OrganizationID.String(),
+			Email:          res.Invitation.Email,
+			Role:           res.Invitation.Role,
+			Status:         res.Invitation.Status,
+			InvitedBy:      res.Invitation.InvitedBy.String(),
+			ExpiresAt:      timestamppb.New(res.Invitation.ExpiresAt),
+			CreatedAt:      timestamppb.New(res.Invitation.CreatedAt),
+		},
+		Token: res.Token,
+	}, nil
+}
+
+// encodeAcceptInvitationResponse to json
+func encodeAcceptInvitationResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateMemberResponse)
+	return &user_grpc.AcceptInvitationResponse{
+		Member: encodeMember(res.Member),
+	}, nil
+}
+
+// encodeDeclineInvitationResponse to json
+func encodeDeclineInvitationResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateStatusResponse)
+	return &user_grpc.DeclineInvitationResponse{Status: res.Status}, nil
+}
+
+// encodeListMembersResponse to json
+func encodeListMembersResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListMembersResponse)
+	members := make([]*user_grpc.Member, 0, len(res.Members))
+	for _, member := range res.Members {
+		members = append(members, encodeMember(member))
+	}
+	return &user_grpc.ListMembersResponse{Members: members}, nil
+}
+
+// encodeChangeMemberRoleResponse to json
+func encodeChangeMemberRoleResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateMemberResponse)
+	return &user_grpc.ChangeMemberRoleResponse{
+		Member: encodeMember(res.Member),
+	}, nil
+}
+
+// encodeRemoveMemberResponse to json
+func encodeRemoveMemberResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateStatusResponse)
+	return &user_grpc.RemoveMemberResponse{Status: res.Status}, nil
+}
+
+// encodeLeaveOrganizationResponse to json
+func encodeLeaveOrganizationResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateStatusResponse)
+	return &user_grpc.LeaveOrganizationResponse{Status: res.Status}, nil
+}
+
+// encodeOrganization convert organization into protobuf message
+func encodeOrganization(organization user.Organization) *user_grpc.Organization {
+	return &user_grpc.Organization{
+		Id:        organization.ID.String(),
+		Name:      organization.Name,
+		CreatedBy: organization.CreatedBy.String(),
+		CreatedAt: timestamppb.New(organization.CreatedAt),
+	}
+}
+
+// encodeMember convert membership into protobuf message
+func encodeMember(member user.Membership) *user_grpc.Member {
+	return &user_grpc.Member{
+		OrganizationId: member.OrganizationID.String(),
+		UserId:         member.UserID.String(),
+		Role:           member.Role,
+		CreatedAt:      timestamppb.New(member.CreatedAt),
+	}
+}
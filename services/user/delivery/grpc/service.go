@@ -3,27 +3,67 @@ package grpc
 import (
 	"context"
 
+	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
 	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
 	oldcontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type grpcServer struct {
-	register grpctransport.Handler
-	login    grpctransport.Handler
-	logger   log.Logger
+	register       grpctransport.Handler
+	login          grpctransport.Handler
+	getMe          grpctransport.Handler
+	getUser        grpctransport.Handler
+	updateUser     grpctransport.Handler
+	changePassword grpctransport.Handler
+	deleteUser     grpctransport.Handler
+	listUsers      grpctransport.Handler
+	restoreUser    grpctransport.Handler
+	suspendUser    grpctransport.Handler
+	reactivateUser grpctransport.Handler
+	deactivateUser grpctransport.Handler
+
+	createOrganization grpctransport.Handler
+	listOrganizations  grpctransport.Handler
+	inviteMember       grpctransport.Handler
+	acceptInvitation   grpctransport.Handler
+	declineInvitation  grpctransport.Handler
+	listMembers        grpctransport.Handler
+	changeMemberRole   grpctransport.Handler
+	removeMember       grpctransport.Handler
+	leaveOrganization  grpctransport.Handler
+
+	listSessions  grpctransport.Handler
+	revokeSession grpctransport.Handler
+
+	listAuditEvents grpctransport.Handler
+
+	createWebhook         grpctransport.Handler
+	listWebhooks          grpctransport.Handler
+	deleteWebhook         grpctransport.Handler
+	listWebhookDeliveries grpctransport.Handler
+	redeliverWebhook      grpctransport.Handler
+
+	logger log.Logger
 }
 
 // NewGRPCServer create grpc server
 func NewGRPCServer(
 	svcEndpoints delivery.Endpoints,
 	logger log.Logger,
+	tenantDomain string,
+	proxies auth.TrustedProxies,
 ) user_grpc.UserServiceServer {
 	var options []grpctransport.ServerOption
 	errorLogger := grpctransport.ServerErrorLogger(logger)
-	options = append(options, errorLogger)
+	tokenExtractor := grpctransport.ServerBefore(kitjwt.GRPCToContext())
+	tenantExtractor := grpctransport.ServerBefore(auth.GRPCToTenantContext(tenantDomain))
+	clientExtractor := grpctransport.ServerBefore(auth.GRPCToClientContext(proxies))
+	options = append(options, errorLogger, tokenExtractor, tenantExtractor, clientExtractor)
 
 	return &grpcServer{
 		register: grpctransport.NewServer(
@@ -38,6 +78,168 @@ func NewGRPCServer(
 			encodeLoginResponse,
 			options...,
 		),
+		getMe: grpctransport.NewServer(
+			svcEndpoints.GetMe,
+			decodeGetMeRequest,
+			encodeGetMeResponse,
+			options...,
+		),
+		getUser: grpctransport.NewServer(
+			svcEndpoints.GetUser,
+			decodeGetUserRequest,
+			encodeGetUserResponse,
+			options...,
+		),
+		updateUser: grpctransport.NewServer(
+			svcEndpoints.UpdateUser,
+			decodeUpdateUserRequest,
+			encodeUpdateUserResponse,
+			options...,
+		),
+		changePassword: grpctransport.NewServer(
+			svcEndpoints.ChangePassword,
+			decodeChangePasswordRequest,
+			encodeChangePasswordResponse,
+			options...,
+		),
+		deleteUser: grpctransport.NewServer(
+			svcEndpoints.DeleteUser,
+			decodeDeleteUserRequest,
+			encodeDeleteUserResponse,
+			options...,
+		),
+		listUsers: grpctransport.NewServer(
+			svcEndpoints.ListUsers,
+			decodeListUsersRequest,
+			encodeListUsersResponse,
+			options...,
+		),
+		restoreUser: grpctransport.NewServer(
+			svcEndpoints.RestoreUser,
+			decodeRestoreUserRequest,
+			encodeRestoreUserResponse,
+			options...,
+		),
+		suspendUser: grpctransport.NewServer(
+			svcEndpoints.SuspendUser,
+			decodeSuspendUserRequest,
+			encodeSuspendUserResponse,
+			options...,
+		),
+		reactivateUser: grpctransport.NewServer(
+			svcEndpoints.ReactivateUser,
+			decodeReactivateUserRequest,
+			encodeReactivateUserResponse,
+			options...,
+		),
+		deactivateUser: grpctransport.NewServer(
+			svcEndpoints.DeactivateUser,
+			decodeDeactivateUserRequest,
+			encodeDeactivateUserResponse,
+			options...,
+		),
+		createOrganization: grpctransport.NewServer(
+			svcEndpoints.CreateOrganization,
+			decodeCreateOrganizationRequest,
+			encodeCreateOrganizationResponse,
+			options...,
+		),
+		listOrganizations: grpctransport.NewServer(
+			svcEndpoints.ListOrganizations,
+			decodeListOrganizationsRequest,
+			encodeListOrganizationsResponse,
+			options...,
+		),
+		inviteMember: grpctransport.NewServer(
+			svcEndpoints.InviteMember,
+			decodeInviteMemberRequest,
+			encodeInviteMemberResponse,
+			options...,
+		),
+		acceptInvitation: grpctransport.NewServer(
+			svcEndpoints.AcceptInvitation,
+			decodeAcceptInvitationRequest,
+			encodeAcceptInvitationResponse,
+			options...,
+		),
+		declineInvitation: grpctransport.NewServer(
+			svcEndpoints.DeclineInvitation,
+			decodeDeclineInvitationRequest,
+			encodeDeclineInvitationResponse,
+			options...,
+		),
+		listMembers: grpctransport.NewServer(
+			svcEndpoints.ListMembers,
+			decodeListMembersRequest,
+			encodeListMembersResponse,
+			options...,
+		),
+		changeMemberRole: grpctransport.NewServer(
+			svcEndpoints.ChangeMemberRole,
+			decodeChangeMemberRoleRequest,
+			encodeChangeMemberRoleResponse,
+			options...,
+		),
+		removeMember: grpctransport.NewServer(
+			svcEndpoints.RemoveMember,
+			decodeRemoveMemberRequest,
+			encodeRemoveMemberResponse,
+			options...,
+		),
+		leaveOrganization: grpctransport.NewServer(
+			svcEndpoints.LeaveOrganization,
+			decodeLeaveOrganizationRequest,
+			encodeLeaveOrganizationResponse,
+			options...,
+		),
+		listSessions: grpctransport.NewServer(
+			svcEndpoints.ListSessions,
+			decodeListSessionsRequest,
+			encodeListSessionsResponse,
+			options...,
+		),
+		revokeSession: grpctransport.NewServer(
+			svcEndpoints.RevokeSession,
+			decodeRevokeSessionRequest,
+			encodeRevokeSessionResponse,
+			options...,
+		),
+		listAuditEvents: grpctransport.NewServer(
+			svcEndpoints.ListAuditEvents,
+			decodeListAuditEventsRequest,
+			encodeListAuditEventsResponse,
+			options...,
+		),
+		createWebhook: grpctransport.NewServer(
+			svcEndpoints.CreateWebhook,
+			decodeCreateWebhookRequest,
+			encodeCreateWebhookResponse,
+			options...,
+		),
+		listWebhooks: grpctransport.NewServer(
+			svcEndpoints.ListWebhooks,
+			decodeListWebhooksRequest,
+			encodeListWebhooksResponse,
+			options...,
+		),
+		deleteWebhook: grpctransport.NewServer(
+			svcEndpoints.DeleteWebhook,
+			decodeDeleteWebhookRequest,
+			encodeDeleteWebhookResponse,
+			options...,
+		),
+		listWebhookDeliveries: grpctransport.NewServer(
+			svcEndpoints.ListWebhookDeliveries,
+			decodeListWebhookDeliveriesRequest,
+			encodeListWebhookDeliveriesResponse,
+			options...,
+		),
+		redeliverWebhook: grpctransport.NewServer(
+			svcEndpoints.RedeliverWebhook,
+			decodeRedeliverWebhookRequest,
+			encodeRedeliverWebhookResponse,
+			options...,
+		),
 		logger: logger,
 	}
 }
@@ -47,7 +249,7 @@ func (s *grpcServer) Register(
 ) (*user_grpc.RegisterResponse, error) {
 	_, rep, err := s.register.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return rep.(*user_grpc.RegisterResponse), nil
 }
@@ -57,11 +259,111 @@ func (s *grpcServer) Login(
 ) (*user_grpc.LoginResponse, error) {
 	_, rep, err := s.login.ServeGRPC(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, encodeError(err)
 	}
 	return rep.(*user_grpc.LoginResponse), nil
 }
 
+func (s *grpcServer) GetMe(
+	ctx oldcontext.Context, req *user_grpc.GetMeRequest,
+) (*user_grpc.GetMeResponse, error) {
+	_, rep, err := s.getMe.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.GetMeResponse), nil
+}
+
+func (s *grpcServer) GetUser(
+	ctx oldcontext.Context, req *user_grpc.GetUserRequest,
+) (*user_grpc.GetUserResponse, error) {
+	_, rep, err := s.getUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.GetUserResponse), nil
+}
+
+func (s *grpcServer) UpdateUser(
+	ctx oldcontext.Context, req *user_grpc.UpdateUserRequest,
+) (*user_grpc.UpdateUserResponse, error) {
+	_, rep, err := s.updateUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.UpdateUserResponse), nil
+}
+
+func (s *grpcServer) ChangePassword(
+	ctx oldcontext.Context, req *user_grpc.ChangePasswordRequest,
+) (*user_grpc.ChangePasswordResponse, error) {
+	_, rep, err := s.changePassword.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ChangePasswordResponse), nil
+}
+
+func (s *grpcServer) DeleteUser(
+	ctx oldcontext.Context, req *user_grpc.DeleteUserRequest,
+) (*user_grpc.DeleteUserResponse, error) {
+	_, rep, err := s.deleteUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.DeleteUserResponse), nil
+}
+
+func (s *grpcServer) ListUsers(
+	ctx oldcontext.Context, req *user_grpc.ListUsersRequest,
+) (*user_grpc.ListUsersResponse, error) {
+	_, rep, err := s.listUsers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListUsersResponse), nil
+}
+
+func (s *grpcServer) RestoreUser(
+	ctx oldcontext.Context, req *user_grpc.RestoreUserRequest,
+) (*user_grpc.RestoreUserResponse, error) {
+	_, rep, err := s.restoreUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.RestoreUserResponse), nil
+}
+
+func (s *grpcServer) SuspendUser(
+	ctx oldcontext.Context, req *user_grpc.SuspendUserRequest,
+) (*user_grpc.SuspendUserResponse, error) {
+	_, rep, err := s.suspendUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.SuspendUserResponse), nil
+}
+
+func (s *grpcServer) ReactivateUser(
+	ctx oldcontext.Context, req *user_grpc.ReactivateUserRequest,
+) (*user_grpc.ReactivateUserResponse, error) {
+	_, rep, err := s.reactivateUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ReactivateUserResponse), nil
+}
+
+func (s *grpcServer) DeactivateUser(
+	ctx oldcontext.Context, req *user_grpc.DeactivateUserRequest,
+) (*user_grpc.DeactivateUserResponse, error) {
+	_, rep, err := s.deactivateUser.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.DeactivateUserResponse), nil
+}
+
 // decodeRegisterRequest to json
 func decodeRegisterRequest(
 	_ context.Context,
@@ -81,8 +383,9 @@ func decodeLoginRequest(
 ) (interface{}, error) {
 	req := request.(*user_grpc.LoginRequest)
 	return delivery.CreateLoginRequest{
-		Email:     req.Email,
-		Passwords: req.Passwords,
+		Email:       req.Email,
+		Passwords:   req.Passwords,
+		DeviceLabel: req.DeviceLabel,
 	}, nil
 }
 
@@ -101,5 +404,241 @@ func encodeLoginResponse(
 	response interface{},
 ) (interface{}, error) {
 	res := response.(delivery.CreateLoginResponse)
-	return &user_grpc.LoginResponse{Status: res.Status}, nil
+	return &user_grpc.LoginResponse{
+		Status: res.Status,
+		Token:  res.Token,
+	}, nil
+}
+
+// decodeGetMeRequest to json
+func decodeGetMeRequest(
+	_ context.Context,
+	_ interface{},
+) (interface{}, error) {
+	return delivery.CreateGetMeRequest{}, nil
+}
+
+// decodeGetUserRequest to json
+func decodeGetUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.GetUserRequest)
+	return delivery.CreateGetUserRequest{ID: req.Id}, nil
+}
+
+// decodeUpdateUserRequest to json
+func decodeUpdateUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.UpdateUserRequest)
+	return delivery.CreateUpdateUserRequest{
+		ID:         req.Id,
+		Email:      req.GetUser().GetEmail(),
+		Name:       req.GetUser().GetName(),
+		Bio:        req.GetUser().GetBio(),
+		UpdateMask: req.GetUpdateMask().GetPaths(),
+	}, nil
+}
+
+// decodeChangePasswordRequest to json
+func decodeChangePasswordRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ChangePasswordRequest)
+	return delivery.CreateChangePasswordRequest{
+		ID:              req.Id,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}, nil
+}
+
+// decodeDeleteUserRequest to json
+func decodeDeleteUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.DeleteUserRequest)
+	return delivery.CreateDeleteUserRequest{ID: req.Id}, nil
+}
+
+// decodeRestoreUserRequest to json
+func decodeRestoreUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.RestoreUserRequest)
+	return delivery.CreateRestoreUserRequest{ID: req.Id}, nil
+}
+
+// decodeSuspendUserRequest to json
+func decodeSuspendUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.SuspendUserRequest)
+	return delivery.CreateSuspendUserRequest{
+		ID:     req.Id,
+		Reason: req.Reason,
+	}, nil
+}
+
+// decodeReactivateUserRequest to json
+func decodeReactivateUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ReactivateUserRequest)
+	return delivery.CreateReactivateUserRequest{
+		ID:     req.Id,
+		Reason: req.Reason,
+	}, nil
+}
+
+// decodeDeactivateUserRequest to json
+func decodeDeactivateUserRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.DeactivateUserRequest)
+	return delivery.CreateDeactivateUserRequest{
+		ID:     req.Id,
+		Reason: req.Reason,
+	}, nil
+}
+
+// decodeListUsersRequest to json
+func decodeListUsersRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ListUsersRequest)
+	listRequest := delivery.CreateListUsersRequest{
+		EmailPrefix: req.EmailPrefix,
+		Status:      req.Status,
+		Role:        req.Role,
+		OrderBy:     req.OrderBy,
+		PageSize:    int(req.PageSize),
+		PageToken:   req.PageToken,
+	}
+	if req.CreatedAfter != nil {
+		listRequest.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		listRequest.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+	return listRequest, nil
+}
+
+// encodeGetMeResponse to json
+func encodeGetMeResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.GetMeResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeGetUserResponse to json
+func encodeGetUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.GetUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeUpdateUserResponse to json
+func encodeUpdateUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.UpdateUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeChangePasswordResponse to json
+func encodeChangePasswordResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateChangePasswordResponse)
+	return &user_grpc.ChangePasswordResponse{Status: res.Status}, nil
+}
+
+// encodeDeleteUserResponse to json
+func encodeDeleteUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateDeleteUserResponse)
+	return &user_grpc.DeleteUserResponse{Status: res.Status}, nil
+}
+
+// encodeRestoreUserResponse to json
+func encodeRestoreUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.RestoreUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeSuspendUserResponse to json
+func encodeSuspendUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.SuspendUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeReactivateUserResponse to json
+func encodeReactivateUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.ReactivateUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeDeactivateUserResponse to json
+func encodeDeactivateUserResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateUserResponse)
+	return &user_grpc.DeactivateUserResponse{User: encodeProfile(res.User)}, nil
+}
+
+// encodeListUsersResponse to json
+func encodeListUsersResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListUsersResponse)
+	users := make([]*user_grpc.User, 0, len(res.Users))
+	for _, profile := range res.Users {
+		users = append(users, encodeProfile(profile))
+	}
+	return &user_grpc.ListUsersResponse{
+		Users:         users,
+		NextPageToken: res.NextPageToken,
+	}, nil
+}
+
+// encodeProfile convert profile into protobuf user message
+func encodeProfile(profile delivery.Profile) *user_grpc.User {
+	return &user_grpc.User{
+		Id:        profile.ID,
+		Email:     profile.Email,
+		Name:      profile.Name,
+		Bio:       profile.Bio,
+		Role:      profile.Role,
+		Status:    profile.Status,
+		CreatedAt: timestamppb.New(profile.CreatedAt),
+		UpdatedAt: timestamppb.New(profile.UpdatedAt),
+	}
 }
@@ -0,0 +1,137 @@
+package grpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	grpcdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/grpc"
+)
+
+func TestGatewayClientIP(t *testing.T) {
+	proxies, err := auth.ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ip string
+	server := grpcdelivery.NewGRPCServer(delivery.Endpoints{
+		GetMe: func(ctx context.Context, _ interface{}) (interface{}, error) {
+			ip = user.ClientFromContext(ctx).IP
+			return delivery.CreateUserResponse{}, nil
+		},
+	}, log.NewNopLogger(), "", proxies)
+	gateway := runtime.NewServeMux()
+	if err := user_grpc.RegisterUserServiceHandlerServer(context.Background(), gateway, server); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"direct client", "203.0.113.9:4000", "", "203.0.113.9"},
+		{"client forging forwarded for", "203.0.113.9:4000", "198.51.100.1", "203.0.113.9"},
+		{"trusted proxy", "10.0.0.2:4000", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy chain", "10.0.0.2:4000", "198.51.100.1, 10.0.0.3", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		ip = ""
+		r := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.name, w.Code, w.Body)
+		}
+		if ip != tt.want {
+			t.Errorf("%s: ip = %q, want %q", tt.name, ip, tt.want)
+		}
+	}
+}
+
+func TestGatewayUpdateMask(t *testing.T) {
+	var got delivery.CreateUpdateUserRequest
+	server := grpcdelivery.NewGRPCServer(delivery.Endpoints{
+		UpdateUser: func(_ context.Context, request interface{}) (interface{}, error) {
+			got = request.(delivery.CreateUpdateUserRequest)
+			return delivery.CreateUserResponse{}, nil
+		},
+	}, log.NewNopLogger(), "", nil)
+	gateway := runtime.NewServeMux()
+	if err := user_grpc.RegisterUserServiceHandlerServer(context.Background(), gateway, server); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mask is derived from fields present in body
+	for body, want := range map[string][]string{
+		`{"name":"Ada"}`:              {"name"},
+		`{"name":"Ada","bio":""}`:     {"bio", "name"},
+		`{"email":"ada@example.com"}`: {"email"},
+	} {
+		got = delivery.CreateUpdateUserRequest{}
+		r := httptest.NewRequest(http.MethodPatch, "/v1/users/42", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", body, w.Code, w.Body)
+		}
+		sort.Strings(got.UpdateMask)
+		if got.ID != "42" || !reflect.DeepEqual(got.UpdateMask, want) {
+			t.Errorf("%s: id %q mask %v, want mask %v", body, got.ID, got.UpdateMask, want)
+		}
+	}
+}
+
+func TestContextErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+		// status of gateway reply, zero when client is gone to read none
+		status int
+	}{
+		{context.DeadlineExceeded, codes.DeadlineExceeded, http.StatusGatewayTimeout},
+		{context.Canceled, codes.Canceled, 0},
+	}
+	for _, tt := range tests {
+		server := grpcdelivery.NewGRPCServer(delivery.Endpoints{
+			GetUser: func(context.Context, interface{}) (interface{}, error) {
+				return nil, tt.err
+			},
+		}, log.NewNopLogger(), "", nil)
+		_, err := server.GetUser(context.Background(), &user_grpc.GetUserRequest{Id: "42"})
+		if code := status.Code(err); code != tt.code {
+			t.Errorf("%v: code = %v, want %v", tt.err, code, tt.code)
+		}
+		if tt.status == 0 {
+			continue
+		}
+
+		gateway := runtime.NewServeMux()
+		if err := user_grpc.RegisterUserServiceHandlerServer(context.Background(), gateway, server); err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		gateway.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/users/42", nil))
+		if w.Code != tt.status {
+			t.Errorf("%v: gateway status = %d, want %d", tt.err, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *grpcServer) ListSessions(
+	ctx oldcontext.Context, req *user_grpc.ListSessionsRequest,
+) (*user_grpc.ListSessionsResponse, error) {
+	_, rep, err := s.listSessions.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListSessionsResponse), nil
+}
+
+func (s *grpcServer) RevokeSession(
+	ctx oldcontext.Context, req *user_grpc.RevokeSessionRequest,
+) (*user_grpc.RevokeSessionResponse, error) {
+	_, rep, err := s.revokeSession.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.RevokeSessionResponse), nil
+}
+
+// decodeListSessionsRequest to json
+func decodeListSessionsRequest(
+	_ context.Context,
+	_ interface{},
+) (interface{}, error) {
+	return delivery.CreateListSessionsRequest{}, nil
+}
+
+// decodeRevokeSessionRequest to json
+func decodeRevokeSessionRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.RevokeSessionRequest)
+	return delivery.CreateRevokeSessionRequest{ID: req.Id}, nil
+}
+
+// encodeListSessionsResponse to json
+func encodeListSessionsResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListSessionsResponse)
+	sessions := make([]*user_grpc.Session, 0, len(res.Sessions))
+	for _, session := range res.Sessions {
+		sessions = append(sessions, &user_grpc.Session{
+			Id:          session.ID.String(),
+			UserAgent:   session.UserAgent,
+			Ip:          session.IP,
+			DeviceLabel: session.DeviceLabel,
+			CreatedAt:   timestamppb.New(session.CreatedAt),
+			LastSeenAt:  timestamppb.New(session.LastSeenAt),
+			Current:     session.Current,
+		})
+	}
+	return &user_grpc.ListSessionsResponse{Sessions: sessions}, nil
+}
+
+// encodeRevokeSessionResponse to json
+func encodeRevokeSessionResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateStatusResponse)
+	return &user_grpc.RevokeSessionResponse{Status: res.Status}, nil
+}
@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/protobuf/user_grpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *grpcServer) CreateWebhook(
+	ctx oldcontext.Context, req *user_grpc.CreateWebhookRequest,
+) (*user_grpc.CreateWebhookResponse, error) {
+	_, rep, err := s.createWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.CreateWebhookResponse), nil
+}
+
+func (s *grpcServer) ListWebhooks(
+	ctx oldcontext.Context, req *user_grpc.ListWebhooksRequest,
+) (*user_grpc.ListWebhooksResponse, error) {
+	_, rep, err := s.listWebhooks.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListWebhooksResponse), nil
+}
+
+func (s *grpcServer) DeleteWebhook(
+	ctx oldcontext.Context, req *user_grpc.DeleteWebhookRequest,
+) (*user_grpc.DeleteWebhookResponse, error) {
+	_, rep, err := s.deleteWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.DeleteWebhookResponse), nil
+}
+
+func (s *grpcServer) ListWebhookDeliveries(
+	ctx oldcontext.Context, req *user_grpc.ListWebhookDeliveriesRequest,
+) (*user_grpc.ListWebhookDeliveriesResponse, error) {
+	_, rep, err := s.listWebhookDeliveries.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.ListWebhookDeliveriesResponse), nil
+}
+
+func (s *grpcServer) RedeliverWebhook(
+	ctx oldcontext.Context, req *user_grpc.RedeliverWebhookRequest,
+) (*user_grpc.RedeliverWebhookResponse, error) {
+	_, rep, err := s.redeliverWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return rep.(*user_grpc.RedeliverWebhookResponse), nil
+}
+
+// decodeCreateWebhookRequest to json
+func decodeCreateWebhookRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.CreateWebhookRequest)
+	return delivery.CreateWebhookRequest{URL: req.Url, Events: req.Events}, nil
+}
+
+// decodeListWebhooksRequest to json
+func decodeListWebhooksRequest(
+	_ context.Context,
+	_ interface{},
+) (interface{}, error) {
+	return delivery.CreateListWebhooksRequest{}, nil
+}
+
+// decodeDeleteWebhookRequest to json
+func decodeDeleteWebhookRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.DeleteWebhookRequest)
+	return delivery.CreateDeleteWebhookRequest{ID: req.Id}, nil
+}
+
+// decodeListWebhookDeliveriesRequest to json
+func decodeListWebhookDeliveriesRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.ListWebhookDeliveriesRequest)
+	return delivery.CreateListWebhookDeliveriesRequest{
+		WebhookID: req.WebhookId,
+		Status:    req.Status,
+	}, nil
+}
+
+// decodeRedeliverWebhookRequest to json
+func decodeRedeliverWebhookRequest(
+	_ context.Context,
+	request interface{},
+) (interface{}, error) {
+	req := request.(*user_grpc.RedeliverWebhookRequest)
+	return delivery.CreateRedeliverWebhookRequest{ID: req.Id}, nil
+}
+
+// encodeCreateWebhookResponse to json
+func encodeCreateWebhookResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateWebhookResponse)
+	return &user_grpc.CreateWebhookResponse{
+		Webhook: encodeWebhook(res.Webhook),
+		Secret:  res.Secret,
+	}, nil
+}
+
+// encodeListWebhooksResponse to json
+func encodeListWebhooksResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListWebhooksResponse)
+	webhooks := make([]*user_grpc.Webhook, 0, len(res.Webhooks))
+	for _, webhook := range res.Webhooks {
+		webhooks = append(webhooks, encodeWebhook(webhook))
+	}
+	return &user_grpc.ListWebhooksResponse{Webhooks: webhooks}, nil
+}
+
+// encodeDeleteWebhookResponse to json
+func encodeDeleteWebhookResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateStatusResponse)
+	return &user_grpc.DeleteWebhookResponse{Status: res.Status}, nil
+}
+
+// encodeListWebhookDeliveriesResponse to json
+func encodeListWebhookDeliveriesResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateListWebhookDeliveriesResponse)
+	deliveries := make([]*user_grpc.WebhookDelivery, 0, len(res.Deliveries))
+	for _, webhookDelivery := range res.Deliveries {
+		deliveries = append(deliveries, encodeWebhookDelivery(webhookDelivery))
+	}
+	return &user_grpc.ListWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+}
+
+// encodeRedeliverWebhookResponse to json
+func encodeRedeliverWebhookResponse(
+	_ context.Context,
+	response interface{},
+) (interface{}, error) {
+	res := response.(delivery.CreateWebhookDeliveryResponse)
+	return &user_grpc.RedeliverWebhookResponse{
+		Delivery: encodeWebhookDelivery(res.Delivery),
+	}, nil
+}
+
+// encodeWebhook convert webhook into protobuf message, secret is left out
+func encodeWebhook(webhook user.Webhook) *user_grpc.Webhook {
+	return &user_grpc.Webhook{
+		Id:        webhook.ID.String(),
+		Url:       webhook.URL,
+		Events:    webhook.EventTypes(),
+		CreatedBy: webhook.CreatedBy.String(),
+		CreatedAt: timestamppb.New(webhook.CreatedAt),
+	}
+}
+
+// encodeWebhookDelivery convert delivery into protobuf message
+func encodeWebhookDelivery(webhookDelivery user.WebhookDelivery) *user_grpc.WebhookDelivery {
+	message := &user_grpc.WebhookDelivery{
+		Id:             webhookDelivery.ID.String(),
+		WebhookId:      webhookDelivery.WebhookID.String(),
+		EventId:        webhookDelivery.EventID.String(),
+		EventType:      webhookDelivery.EventType,
+		Payload:        webhookDelivery.Payload,
+		Status:         webhookDelivery.Status,
+		Attempts:       int32(webhookDelivery.Attempts),
+		NextAttemptAt:  timestamppb.New(webhookDelivery.NextAttemptAt),
+		LastStatusCode: int32(webhookDelivery.LastStatusCode),
+		LastError:      webhookDelivery.LastError,
+		CreatedAt:      timestamppb.New(webhookDelivery.CreatedAt),
+	}
+	if webhookDelivery.DeliveredAt != nil {
+		message.DeliveredAt = timestamppb.New(*webhookDelivery.DeliveredAt)
+	}
+	return message
+}
@@ -3,14 +3,22 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	kitjwt "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/log"
 
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"github.com/muhammadisa/go-kit-boilerplate/middleware"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
 	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/graphql"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/jsonrpc"
 	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
 )
 
@@ -19,13 +27,18 @@ func NewHTTPServe(
 	_ context.Context,
 	svcEndpoints delivery.Endpoints,
 	logger log.Logger,
+	tenantDomain string,
+	proxies auth.TrustedProxies,
 ) http.Handler {
 	// Initialize mux router error logger and error
 	r := mux.NewRouter()
 	var options []httptransport.ServerOption
 	errorLogger := httptransport.ServerErrorLogger(logger)
 	errorEncoder := httptransport.ServerErrorEncoder(decodeencode.EncodeErrorResponse)
-	options = append(options, errorLogger, errorEncoder)
+	tokenExtractor := httptransport.ServerBefore(kitjwt.HTTPToContext())
+	tenantExtractor := httptransport.ServerBefore(auth.HTTPToTenantContext(tenantDomain))
+	clientExtractor := httptransport.ServerBefore(auth.HTTPToClientContext(proxies))
+	options = append(options, errorLogger, errorEncoder, tokenExtractor, tenantExtractor, clientExtractor)
 
 	// Attaching middlewares
 	r.Use(middleware.ContentTypeMiddleware)
@@ -33,6 +46,12 @@ func NewHTTPServe(
 	r.Use(mux.CORSMethodMiddleware(r))
 
 	// Creating routes
+	r.Methods("POST").Path("/rpc").Handler(
+		jsonrpc.NewJSONRPCServe(svcEndpoints, logger, tenantDomain, proxies),
+	)
+	r.Methods("POST").Path("/graphql").Handler(
+		graphql.NewGraphQLServe(svcEndpoints, logger, tenantDomain, proxies),
+	)
 	r.Methods("POST").Path("/user/register").Handler(httptransport.NewServer(
 		svcEndpoints.Register,
 		decodeRegisterRequest,
@@ -45,6 +64,84 @@ func NewHTTPServe(
 		decodeencode.EncodeResponse,
 		options...,
 	))
+	r.Methods("GET").Path("/user").Handler(httptransport.NewServer(
+		svcEndpoints.ListUsers,
+		decodeListUsersRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path("/audit").Handler(httptransport.NewServer(
+		svcEndpoints.ListAuditEvents,
+		decodeListAuditEventsRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path("/user/me").Handler(httptransport.NewServer(
+		svcEndpoints.GetMe,
+		decodeGetMeRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path("/user/me/sessions").Handler(httptransport.NewServer(
+		svcEndpoints.ListSessions,
+		decodeListSessionsRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("DELETE").Path("/user/me/sessions/{id}").Handler(httptransport.NewServer(
+		svcEndpoints.RevokeSession,
+		decodeRevokeSessionRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path("/user/{id}").Handler(httptransport.NewServer(
+		svcEndpoints.GetUser,
+		decodeGetUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("PATCH").Path("/user/{id}").Handler(httptransport.NewServer(
+		svcEndpoints.UpdateUser,
+		decodeUpdateUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path("/user/{id}/password").Handler(httptransport.NewServer(
+		svcEndpoints.ChangePassword,
+		decodeChangePasswordRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("DELETE").Path("/user/{id}").Handler(httptransport.NewServer(
+		svcEndpoints.DeleteUser,
+		decodeDeleteUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path("/user/{id}/restore").Handler(httptransport.NewServer(
+		svcEndpoints.RestoreUser,
+		decodeRestoreUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path("/user/{id}/suspend").Handler(httptransport.NewServer(
+		svcEndpoints.SuspendUser,
+		decodeSuspendUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path("/user/{id}/reactivate").Handler(httptransport.NewServer(
+		svcEndpoints.ReactivateUser,
+		decodeReactivateUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path("/user/{id}/deactivate").Handler(httptransport.NewServer(
+		svcEndpoints.DeactivateUser,
+		decodeDeactivateUserRequest,
+		decodeencode.EncodeResponse,
+		options...,
+	))
 
 	return r
 }
@@ -72,3 +169,191 @@ func decodeLoginRequest(
 	}
 	return req, nil
 }
+
+func decodeGetMeRequest(
+	_ context.Context,
+	_ *http.Request,
+) (interface{}, error) {
+	return delivery.CreateGetMeRequest{}, nil
+}
+
+func decodeListSessionsRequest(
+	_ context.Context,
+	_ *http.Request,
+) (interface{}, error) {
+	return delivery.CreateListSessionsRequest{}, nil
+}
+
+func decodeRevokeSessionRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	return delivery.CreateRevokeSessionRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeGetUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	return delivery.CreateGetUserRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeUpdateUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var body map[string]json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+	// Derive update mask from fields present in body
+	var req delivery.CreateUpdateUserRequest
+	for field, value := range body {
+		var target *string
+		switch field {
+		case user.FieldEmail:
+			target = &req.Email
+		case user.FieldName:
+			target = &req.Name
+		case user.FieldBio:
+			target = &req.Bio
+		default:
+			return nil, user.ErrInvalidUpdateMask
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			return nil, err
+		}
+		req.UpdateMask = append(req.UpdateMask, field)
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeChangePasswordRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var req delivery.CreateChangePasswordRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return nil, err
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeDeleteUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	return delivery.CreateDeleteUserRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeRestoreUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	return delivery.CreateRestoreUserRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+func decodeSuspendUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var req delivery.CreateSuspendUserRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeReactivateUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var req delivery.CreateReactivateUserRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeDeactivateUserRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var req delivery.CreateDeactivateUserRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeListUsersRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var err error
+	query := r.URL.Query()
+	req := delivery.CreateListUsersRequest{
+		EmailPrefix: query.Get("email_prefix"),
+		Status:      query.Get("status"),
+		Role:        query.Get("role"),
+		OrderBy:     query.Get("order_by"),
+		PageToken:   query.Get("page_token"),
+	}
+	if value := query.Get("page_size"); value != "" {
+		if req.PageSize, err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+	}
+	if value := query.Get("created_after"); value != "" {
+		if req.CreatedAfter, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	if value := query.Get("created_before"); value != "" {
+		if req.CreatedBefore, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func decodeListAuditEventsRequest(
+	_ context.Context,
+	r *http.Request,
+) (interface{}, error) {
+	var err error
+	query := r.URL.Query()
+	req := delivery.CreateListAuditEventsRequest{
+		ActorID:   query.Get("actor_id"),
+		Target:    query.Get("target"),
+		Action:    query.Get("action"),
+		Outcome:   query.Get("outcome"),
+		PageToken: query.Get("page_token"),
+	}
+	if value := query.Get("page_size"); value != "" {
+		if req.PageSize, err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+	}
+	if value := query.Get("created_after"); value != "" {
+		if req.CreatedAfter, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	if value := query.Get("created_before"); value != "" {
+		if req.CreatedBefore, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,293 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport/http/jsonrpc"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
+)
+
+// MaxBatchSize most calls accepted in one batch request
+const MaxBatchSize = 100
+
+// MaxRequestBytes largest request body accepted, single or batch
+const MaxRequestBytes = 1 << 20
+
+// MaxExpensiveCalls most calls of expensive methods served in one batch,
+// further ones are answered with error without being served
+const MaxExpensiveCalls = 5
+
+// expensiveMethods methods hashing or checking passwords, batching them
+// would let one request run bcrypt hundreds of times
+var expensiveMethods = map[string]bool{
+	"user.register":        true,
+	"user.login":           true,
+	"user.password.change": true,
+}
+
+type contextKey string
+
+// callIDContextKey holds raw id of call being served
+const callIDContextKey contextKey = "JSONRPCCallID"
+
+// handler serves single and batch json rpc 2.0 requests, every call of batch
+// is served by go kit json rpc server as if it was sent alone
+type handler struct {
+	server *jsonrpc.Server
+}
+
+// errorResponse json rpc response of failed call
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Error   jsonrpc.Error   `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// NewJSONRPCServe create json rpc 2.0 handler of endpoints, methods are
+// named after endpoints and params are json encoded request types of
+// delivery package
+func NewJSONRPCServe(
+	svcEndpoints delivery.Endpoints,
+	logger log.Logger,
+	tenantDomain string,
+	proxies auth.TrustedProxies,
+) http.Handler {
+	var options []jsonrpc.ServerOption
+	errorLogger := jsonrpc.ServerErrorLogger(logger)
+	errorEncoder := jsonrpc.ServerErrorEncoder(encodeError)
+	tokenExtractor := jsonrpc.ServerBefore(kitjwt.HTTPToContext())
+	tenantExtractor := jsonrpc.ServerBefore(auth.HTTPToTenantContext(tenantDomain))
+	clientExtractor := jsonrpc.ServerBefore(auth.HTTPToClientContext(proxies))
+	options = append(options, errorLogger, errorEncoder, tokenExtractor, tenantExtractor, clientExtractor)
+
+	methods := []struct {
+		method   string
+		endpoint endpoint.Endpoint
+		request  interface{}
+	}{
+		{"user.register", svcEndpoints.Register, delivery.CreateRegisterRequest{}},
+		{"user.login", svcEndpoints.Login, delivery.CreateLoginRequest{}},
+		{"user.me", svcEndpoints.GetMe, delivery.CreateGetMeRequest{}},
+		{"user.get", svcEndpoints.GetUser, delivery.CreateGetUserRequest{}},
+		{"user.update", svcEndpoints.UpdateUser, delivery.CreateUpdateUserRequest{}},
+		{"user.delete", svcEndpoints.DeleteUser, delivery.CreateDeleteUserRequest{}},
+		{"user.list", svcEndpoints.ListUsers, delivery.CreateListUsersRequest{}},
+		{"user.restore", svcEndpoints.RestoreUser, delivery.CreateRestoreUserRequest{}},
+		{"user.suspend", svcEndpoints.SuspendUser, delivery.CreateSuspendUserRequest{}},
+		{"user.reactivate", svcEndpoints.ReactivateUser, delivery.CreateReactivateUserRequest{}},
+		{"user.deactivate", svcEndpoints.DeactivateUser, delivery.CreateDeactivateUserRequest{}},
+		{"user.password.change", svcEndpoints.ChangePassword, delivery.CreateChangePasswordRequest{}},
+
+		{"organization.create", svcEndpoints.CreateOrganization, delivery.CreateOrganizationRequest{}},
+		{"organization.list", svcEndpoints.ListOrganizations, delivery.CreateListOrganizationsRequest{}},
+		{"organization.invite", svcEndpoints.InviteMember, delivery.CreateInviteMemberRequest{}},
+		{"organization.acceptInvitation", svcEndpoints.AcceptInvitation, delivery.CreateAcceptInvitationRequest{}},
+		{"organization.declineInvitation", svcEndpoints.DeclineInvitation, delivery.CreateDeclineInvitationRequest{}},
+		{"organization.listMembers", svcEndpoints.ListMembers, delivery.CreateListMembersRequest{}},
+		{"organization.changeMemberRole", svcEndpoints.ChangeMemberRole, delivery.CreateChangeMemberRoleRequest{}},
+		{"organization.removeMember", svcEndpoints.RemoveMember, delivery.CreateRemoveMemberRequest{}},
+		{"organization.leave", svcEndpoints.LeaveOrganization, delivery.CreateLeaveOrganizationRequest{}},
+
+		{"session.list", svcEndpoints.ListSessions, delivery.CreateListSessionsRequest{}},
+		{"session.revoke", svcEndpoints.RevokeSession, delivery.CreateRevokeSessionRequest{}},
+
+		{"audit.list", svcEndpoints.ListAuditEvents, delivery.CreateListAuditEventsRequest{}},
+
+		{"webhook.create", svcEndpoints.CreateWebhook, delivery.CreateWebhookRequest{}},
+		{"webhook.list", svcEndpoints.ListWebhooks, delivery.CreateListWebhooksRequest{}},
+		{"webhook.delete", svcEndpoints.DeleteWebhook, delivery.CreateDeleteWebhookRequest{}},
+		{"webhook.listDeliveries", svcEndpoints.ListWebhookDeliveries, delivery.CreateListWebhookDeliveriesRequest{}},
+		{"webhook.redeliver", svcEndpoints.RedeliverWebhook, delivery.CreateRedeliverWebhookRequest{}},
+	}
+	codecs := make(jsonrpc.EndpointCodecMap, len(methods))
+	for _, m := range methods {
+		codecs[m.method] = jsonrpc.EndpointCodec{
+			Endpoint: m.endpoint,
+			Decode:   decodeRequest(m.request),
+			Encode:   encodeResponse,
+		}
+	}
+	return &handler{server: jsonrpc.NewServer(codecs, options...)}
+}
+
+// ServeHTTP serve single or batch request, notifications are served but
+// never replied to
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxRequestBytes))
+	if err != nil {
+		writeError(w, nil, jsonrpc.Error{Code: jsonrpc.ParseError, Message: err.Error()})
+		return
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		reply := h.call(r, trimmed)
+		if reply == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", jsonrpc.ContentType)
+		_, _ = w.Write(reply)
+		return
+	}
+
+	var calls []json.RawMessage
+	if err := json.Unmarshal(trimmed, &calls); err != nil {
+		writeError(w, nil, jsonrpc.Error{Code: jsonrpc.ParseError, Message: err.Error()})
+		return
+	}
+	if len(calls) == 0 || len(calls) > MaxBatchSize {
+		writeError(w, nil, jsonrpc.Error{
+			Code:    jsonrpc.InvalidRequestError,
+			Message: fmt.Sprintf("batch must have between 1 and %d calls", MaxBatchSize),
+		})
+		return
+	}
+	replies := make([]json.RawMessage, 0, len(calls))
+	var expensive int
+	for _, call := range calls {
+		var envelope struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		_ = json.Unmarshal(call, &envelope)
+		if expensiveMethods[envelope.Method] {
+			if expensive++; expensive > MaxExpensiveCalls {
+				if len(envelope.ID) > 0 {
+					replies = append(replies, errorReply(envelope.ID, jsonrpc.Error{
+						Code:    jsonrpc.InvalidRequestError,
+						Message: fmt.Sprintf("batch may make at most %d password checking calls", MaxExpensiveCalls),
+					}))
+				}
+				continue
+			}
+		}
+		if reply := h.call(r, call); reply != nil {
+			replies = append(replies, reply)
+		}
+	}
+	if len(replies) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", jsonrpc.ContentType)
+	_ = json.NewEncoder(w).Encode(replies)
+}
+
+// call serve one call with headers of original request, returns nil reply
+// for notification
+func (h *handler) call(r *http.Request, call json.RawMessage) json.RawMessage {
+	if !json.Valid(call) {
+		return errorReply(nil, jsonrpc.Error{
+			Code:    jsonrpc.ParseError,
+			Message: jsonrpc.ErrorMessage(jsonrpc.ParseError),
+		})
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(call, &envelope); err != nil {
+		return errorReply(nil, jsonrpc.Error{
+			Code:    jsonrpc.InvalidRequestError,
+			Message: jsonrpc.ErrorMessage(jsonrpc.InvalidRequestError),
+		})
+	}
+	id, hasID := envelope["id"]
+	var version string
+	if err := json.Unmarshal(envelope["jsonrpc"], &version); err != nil || version != jsonrpc.Version {
+		return errorReply(id, jsonrpc.Error{
+			Code:    jsonrpc.InvalidRequestError,
+			Message: "jsonrpc must be exactly \"2.0\"",
+		})
+	}
+
+	ctx := context.WithValue(r.Context(), callIDContextKey, id)
+	req := r.Clone(ctx)
+	req.Body = ioutil.NopCloser(bytes.NewReader(call))
+	buffer := newResponseBuffer()
+	h.server.ServeHTTP(buffer, req)
+	if !hasID {
+		return nil
+	}
+	return bytes.TrimSpace(buffer.body.Bytes())
+}
+
+// decodeRequest create decoder of params into request of same type as given
+// request, params failing to decode are reported as invalid params
+func decodeRequest(request interface{}) jsonrpc.DecodeRequestFunc {
+	decode := decodeencode.DecodeRequest(request)
+	return func(_ context.Context, params json.RawMessage) (interface{}, error) {
+		req, err := decode(params)
+		if err != nil {
+			return nil, jsonrpc.Error{Code: jsonrpc.InvalidParamsError, Message: err.Error()}
+		}
+		return req, nil
+	}
+}
+
+// encodeResponse encode endpoint response into result
+func encodeResponse(_ context.Context, response interface{}) (json.RawMessage, error) {
+	return json.Marshal(response)
+}
+
+// encodeError encode error of call with its id, json rpc errors keep their
+// code and domain errors are mapped
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	rpcErr := jsonrpc.Error{
+		Code:    decodeencode.JSONRPCErrorCode(err),
+		Message: err.Error(),
+	}
+	if coder, ok := err.(jsonrpc.ErrorCoder); ok {
+		rpcErr.Code = coder.ErrorCode()
+	}
+	id, _ := ctx.Value(callIDContextKey).(json.RawMessage)
+	writeError(w, id, rpcErr)
+}
+
+// writeError write error response of call with id
+func writeError(w http.ResponseWriter, id json.RawMessage, rpcErr jsonrpc.Error) {
+	w.Header().Set("Content-Type", jsonrpc.ContentType)
+	_, _ = w.Write(errorReply(id, rpcErr))
+}
+
+// errorReply returns encoded error response, missing id is sent as null
+func errorReply(id json.RawMessage, rpcErr jsonrpc.Error) json.RawMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	reply, _ := json.Marshal(errorResponse{
+		JSONRPC: jsonrpc.Version,
+		Error:   rpcErr,
+		ID:      id,
+	})
+	return reply
+}
+
+// responseBuffer response writer keeping reply of call in memory
+type responseBuffer struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}}
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+
+func (b *responseBuffer) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *responseBuffer) WriteHeader(int) {}
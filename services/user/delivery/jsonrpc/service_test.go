@@ -0,0 +1,217 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport/http/jsonrpc"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	jsonrpcdelivery "github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/jsonrpc"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
+)
+
+// reply json rpc response of one call
+type reply struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc.Error  `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// handler serves endpoints counting logins, login of ada succeeds and the
+// rest fail as not found. Getting user always runs out of time
+func handler(logins *int32) http.Handler {
+	return jsonrpcdelivery.NewJSONRPCServe(delivery.Endpoints{
+		Login: func(_ context.Context, request interface{}) (interface{}, error) {
+			atomic.AddInt32(logins, 1)
+			if request.(delivery.CreateLoginRequest).Email != "ada@example.com" {
+				return nil, user.ErrUserNotFound
+			}
+			return delivery.CreateLoginResponse{Status: "ok", Token: "token"}, nil
+		},
+		GetMe: func(context.Context, interface{}) (interface{}, error) {
+			return delivery.CreateUserResponse{}, nil
+		},
+		GetUser: func(context.Context, interface{}) (interface{}, error) {
+			return nil, context.DeadlineExceeded
+		},
+	}, log.NewNopLogger(), "", nil)
+}
+
+// call encode json rpc 2.0 call, notification when id is zero
+func call(id int, method string, params interface{}) string {
+	data, _ := json.Marshal(params)
+	if id == 0 {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","method":%q,"params":%s}`, method, data)
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, data)
+}
+
+// batch join calls into batch request body
+func batch(calls ...string) string {
+	return "[" + strings.Join(calls, ",") + "]"
+}
+
+// post send body to handler, returns status and raw response body
+func post(h http.Handler, body string) (int, []byte) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	return rec.Code, bytes.TrimSpace(rec.Body.Bytes())
+}
+
+func TestJSONRPCSingle(t *testing.T) {
+	var logins int32
+	h := handler(&logins)
+	login := delivery.CreateLoginRequest{Email: "ada@example.com", Passwords: "secret"}
+
+	_, body := post(h, call(1, "user.login", login))
+	var ok reply
+	if err := json.Unmarshal(body, &ok); err != nil {
+		t.Fatalf("reply %q: %v", body, err)
+	}
+	var result delivery.CreateLoginResponse
+	_ = json.Unmarshal(ok.Result, &result)
+	if ok.Error != nil || string(ok.ID) != "1" || result.Token != "token" {
+		t.Fatalf("reply = %s", body)
+	}
+
+	_, body = post(h, call(2, "user.login", delivery.CreateLoginRequest{Email: "bob@example.com"}))
+	var failed reply
+	_ = json.Unmarshal(body, &failed)
+	if failed.Error == nil || failed.Error.Code != decodeencode.JSONRPCNotFound || string(failed.ID) != "2" {
+		t.Fatalf("reply = %s, want not found", body)
+	}
+
+	if status, body := post(h, call(0, "user.login", login)); status != http.StatusNoContent || len(body) != 0 {
+		t.Fatalf("notification status = %d body = %s, want no content", status, body)
+	}
+
+	_, body = post(h, call(4, "user.get", delivery.CreateGetUserRequest{ID: "42"}))
+	var timedOut reply
+	_ = json.Unmarshal(body, &timedOut)
+	if timedOut.Error == nil || timedOut.Error.Code != decodeencode.JSONRPCDeadlineExceeded {
+		t.Fatalf("reply = %s, want deadline exceeded", body)
+	}
+
+	_, body = post(h, `{"jsonrpc":"1.0","id":3,"method":"user.me"}`)
+	var version reply
+	_ = json.Unmarshal(body, &version)
+	if version.Error == nil || version.Error.Code != jsonrpc.InvalidRequestError || string(version.ID) != "3" {
+		t.Fatalf("reply = %s, want invalid request", body)
+	}
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	var logins int32
+	h := handler(&logins)
+
+	_, body := post(h, batch(
+		call(1, "user.me", nil),
+		call(0, "user.me", nil),
+		`{"jsonrpc":"2.0","id":2`,
+		call(3, "user.missing", nil),
+	))
+	// Broken call fails whole batch to parse
+	var broken reply
+	if err := json.Unmarshal(body, &broken); err != nil {
+		t.Fatalf("reply %q: %v", body, err)
+	}
+	if broken.Error == nil || broken.Error.Code != jsonrpc.ParseError {
+		t.Fatalf("reply = %s, want parse error", body)
+	}
+
+	var replies []reply
+
+	_, body = post(h, batch(
+		call(1, "user.me", nil),
+		call(0, "user.me", nil),
+		call(3, "user.missing", nil),
+	))
+	if err := json.Unmarshal(body, &replies); err != nil {
+		t.Fatalf("reply %q: %v", body, err)
+	}
+	if len(replies) != 2 {
+		t.Fatalf("replies = %s, want one per call with id", body)
+	}
+	if replies[0].Error != nil || string(replies[0].ID) != "1" {
+		t.Errorf("reply = %+v", replies[0])
+	}
+	if replies[1].Error == nil || replies[1].Error.Code != jsonrpc.MethodNotFoundError || string(replies[1].ID) != "3" {
+		t.Errorf("reply = %+v, want method not found", replies[1])
+	}
+
+	if status, body := post(h, batch(call(0, "user.me", nil))); status != http.StatusNoContent || len(body) != 0 {
+		t.Fatalf("batch of notifications status = %d body = %s, want no content", status, body)
+	}
+}
+
+func TestJSONRPCBatchLimits(t *testing.T) {
+	var logins int32
+	h := handler(&logins)
+
+	tooMany := make([]string, jsonrpcdelivery.MaxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = call(i+1, "user.me", nil)
+	}
+	for name, body := range map[string]string{
+		"empty":     "[]",
+		"too large": batch(tooMany...),
+	} {
+		_, got := post(h, body)
+		var rejected reply
+		if err := json.Unmarshal(got, &rejected); err != nil {
+			t.Fatalf("%s: reply %q: %v", name, got, err)
+		}
+		if rejected.Error == nil || rejected.Error.Code != jsonrpc.InvalidRequestError || string(rejected.ID) != "null" {
+			t.Errorf("%s: reply = %s, want invalid request", name, got)
+		}
+	}
+	_, got := post(h, batch(tooMany[:jsonrpcdelivery.MaxBatchSize]...))
+	var replies []reply
+	if err := json.Unmarshal(got, &replies); err != nil || len(replies) != jsonrpcdelivery.MaxBatchSize {
+		t.Errorf("full batch got %d replies, err %v", len(replies), err)
+	}
+
+	calls := make([]string, 0, jsonrpcdelivery.MaxExpensiveCalls+2)
+	for i := 0; i < jsonrpcdelivery.MaxExpensiveCalls+2; i++ {
+		calls = append(calls, call(i+1, "user.login", delivery.CreateLoginRequest{Email: "ada@example.com"}))
+	}
+	calls = append(calls, call(0, "user.register", delivery.CreateRegisterRequest{}), call(100, "user.me", nil))
+	_, got = post(h, batch(calls...))
+	if err := json.Unmarshal(got, &replies); err != nil {
+		t.Fatalf("reply %q: %v", got, err)
+	}
+	if logins != jsonrpcdelivery.MaxExpensiveCalls {
+		t.Errorf("logins served = %d, want %d", logins, jsonrpcdelivery.MaxExpensiveCalls)
+	}
+	if len(replies) != jsonrpcdelivery.MaxExpensiveCalls+3 {
+		t.Fatalf("replies = %s", got)
+	}
+	for i, r := range replies {
+		refused := i >= jsonrpcdelivery.MaxExpensiveCalls && i < jsonrpcdelivery.MaxExpensiveCalls+2
+		if refused != (r.Error != nil) {
+			t.Errorf("reply %d = %+v, refused %v", i, r, refused)
+		}
+		if refused && r.Error.Code != jsonrpc.InvalidRequestError {
+			t.Errorf("reply %d code = %d, want invalid request", i, r.Error.Code)
+		}
+	}
+
+	_, got = post(h, call(1, "user.login", delivery.CreateLoginRequest{
+		Email: strings.Repeat("a", jsonrpcdelivery.MaxRequestBytes),
+	}))
+	var tooLarge reply
+	_ = json.Unmarshal(got, &tooLarge)
+	if tooLarge.Error == nil || tooLarge.Error.Code != jsonrpc.ParseError {
+		t.Errorf("reply = %.200s, want parse error", got)
+	}
+}
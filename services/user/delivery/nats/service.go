@@ -0,0 +1,140 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	natstransport "github.com/go-kit/kit/transport/nats"
+	gonats "github.com/nats-io/nats.go"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/utils/decodeencode"
+)
+
+// QueueGroup requests are balanced between subscribers of this group
+const QueueGroup = "user"
+
+// Subjects endpoints are served on, request and reply are json encoded
+// request and response types of delivery package
+const (
+	SubjectRegister       = "user.register"
+	SubjectLogin          = "user.login"
+	SubjectGetMe          = "user.me"
+	SubjectGetUser        = "user.get"
+	SubjectUpdateUser     = "user.update"
+	SubjectDeleteUser     = "user.delete"
+	SubjectListUsers      = "user.list"
+	SubjectRestoreUser    = "user.restore"
+	SubjectSuspendUser    = "user.suspend"
+	SubjectReactivateUser = "user.reactivate"
+	SubjectDeactivateUser = "user.deactivate"
+	SubjectChangePassword = "user.password.change"
+
+	SubjectCreateOrganization = "user.organization.create"
+	SubjectListOrganizations  = "user.organization.list"
+	SubjectInviteMember       = "user.organization.invite"
+	SubjectAcceptInvitation   = "user.organization.invitation.accept"
+	SubjectDeclineInvitation  = "user.organization.invitation.decline"
+	SubjectListMembers        = "user.organization.member.list"
+	SubjectChangeMemberRole   = "user.organization.member.role"
+	SubjectRemoveMember       = "user.organization.member.remove"
+	SubjectLeaveOrganization  = "user.organization.leave"
+
+	SubjectListSessions  = "user.session.list"
+	SubjectRevokeSession = "user.session.revoke"
+
+	SubjectListAuditEvents = "user.audit.list"
+
+	SubjectCreateWebhook         = "user.webhook.create"
+	SubjectListWebhooks          = "user.webhook.list"
+	SubjectDeleteWebhook         = "user.webhook.delete"
+	SubjectListWebhookDeliveries = "user.webhook.delivery.list"
+	SubjectRedeliverWebhook      = "user.webhook.delivery.redeliver"
+)
+
+// NewNATSServe subscribe endpoints to their subjects in queue group, token,
+// tenant and request id are read from message headers named as their http
+// counterparts
+func NewNATSServe(
+	nc *gonats.Conn,
+	svcEndpoints delivery.Endpoints,
+	logger log.Logger,
+) ([]*gonats.Subscription, error) {
+	var options []natstransport.SubscriberOption
+	errorLogger := natstransport.SubscriberErrorLogger(logger)
+	errorEncoder := natstransport.SubscriberErrorEncoder(decodeencode.EncodeNATSErrorResponse)
+	tokenExtractor := natstransport.SubscriberBefore(auth.NATSToContext())
+	tenantExtractor := natstransport.SubscriberBefore(auth.NATSToTenantContext())
+	clientExtractor := natstransport.SubscriberBefore(auth.NATSToClientContext())
+	options = append(options, errorLogger, errorEncoder, tokenExtractor, tenantExtractor, clientExtractor)
+
+	routes := []struct {
+		subject  string
+		endpoint endpoint.Endpoint
+		request  interface{}
+	}{
+		{SubjectRegister, svcEndpoints.Register, delivery.CreateRegisterRequest{}},
+		{SubjectLogin, svcEndpoints.Login, delivery.CreateLoginRequest{}},
+		{SubjectGetMe, svcEndpoints.GetMe, delivery.CreateGetMeRequest{}},
+		{SubjectGetUser, svcEndpoints.GetUser, delivery.CreateGetUserRequest{}},
+		{SubjectUpdateUser, svcEndpoints.UpdateUser, delivery.CreateUpdateUserRequest{}},
+		{SubjectDeleteUser, svcEndpoints.DeleteUser, delivery.CreateDeleteUserRequest{}},
+		{SubjectListUsers, svcEndpoints.ListUsers, delivery.CreateListUsersRequest{}},
+		{SubjectRestoreUser, svcEndpoints.RestoreUser, delivery.CreateRestoreUserRequest{}},
+		{SubjectSuspendUser, svcEndpoints.SuspendUser, delivery.CreateSuspendUserRequest{}},
+		{SubjectReactivateUser, svcEndpoints.ReactivateUser, delivery.CreateReactivateUserRequest{}},
+		{SubjectDeactivateUser, svcEndpoints.DeactivateUser, delivery.CreateDeactivateUserRequest{}},
+		{SubjectChangePassword, svcEndpoints.ChangePassword, delivery.CreateChangePasswordRequest{}},
+
+		{SubjectCreateOrganization, svcEndpoints.CreateOrganization, delivery.CreateOrganizationRequest{}},
+		{SubjectListOrganizations, svcEndpoints.ListOrganizations, delivery.CreateListOrganizationsRequest{}},
+		{SubjectInviteMember, svcEndpoints.InviteMember, delivery.CreateInviteMemberRequest{}},
+		{SubjectAcceptInvitation, svcEndpoints.AcceptInvitation, delivery.CreateAcceptInvitationRequest{}},
+		{SubjectDeclineInvitation, svcEndpoints.DeclineInvitation, delivery.CreateDeclineInvitationRequest{}},
+		{SubjectListMembers, svcEndpoints.ListMembers, delivery.CreateListMembersRequest{}},
+		{SubjectChangeMemberRole, svcEndpoints.ChangeMemberRole, delivery.CreateChangeMemberRoleRequest{}},
+		{SubjectRemoveMember, svcEndpoints.RemoveMember, delivery.CreateRemoveMemberRequest{}},
+		{SubjectLeaveOrganization, svcEndpoints.LeaveOrganization, delivery.CreateLeaveOrganizationRequest{}},
+
+		{SubjectListSessions, svcEndpoints.ListSessions, delivery.CreateListSessionsRequest{}},
+		{SubjectRevokeSession, svcEndpoints.RevokeSession, delivery.CreateRevokeSessionRequest{}},
+
+		{SubjectListAuditEvents, svcEndpoints.ListAuditEvents, delivery.CreateListAuditEventsRequest{}},
+
+		{SubjectCreateWebhook, svcEndpoints.CreateWebhook, delivery.CreateWebhookRequest{}},
+		{SubjectListWebhooks, svcEndpoints.ListWebhooks, delivery.CreateListWebhooksRequest{}},
+		{SubjectDeleteWebhook, svcEndpoints.DeleteWebhook, delivery.CreateDeleteWebhookRequest{}},
+		{SubjectListWebhookDeliveries, svcEndpoints.ListWebhookDeliveries, delivery.CreateListWebhookDeliveriesRequest{}},
+		{SubjectRedeliverWebhook, svcEndpoints.RedeliverWebhook, delivery.CreateRedeliverWebhookRequest{}},
+	}
+
+	subscriptions := make([]*gonats.Subscription, 0, len(routes))
+	for _, route := range routes {
+		subscriber := natstransport.NewSubscriber(
+			route.endpoint,
+			decodeRequest(route.request),
+			decodeencode.EncodeNATSResponse,
+			options...,
+		)
+		subscription, err := nc.QueueSubscribe(route.subject, QueueGroup, subscriber.ServeMsg(nc))
+		if err != nil {
+			for _, subscribed := range subscriptions {
+				_ = subscribed.Unsubscribe()
+			}
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+// decodeRequest create decoder of message data into request of same type as
+// given request
+func decodeRequest(request interface{}) natstransport.DecodeRequestFunc {
+	decode := decodeencode.DecodeRequest(request)
+	return func(_ context.Context, msg *gonats.Msg) (interface{}, error) {
+		return decode(msg.Data)
+	}
+}
@@ -0,0 +1,97 @@
+package nats_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/nats-io/nats-server/v2/test"
+	gonats "github.com/nats-io/nats.go"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/delivery/nats"
+)
+
+// serve run embedded server with endpoints subscribed, connection to it is
+// returned for requests
+func serve(t *testing.T, endpoints delivery.Endpoints) *gonats.Conn {
+	t.Helper()
+	server := test.RunRandClientPortServer()
+	t.Cleanup(server.Shutdown)
+	nc, err := gonats.Connect(server.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	if _, err := nats.NewNATSServe(nc, endpoints, log.NewNopLogger()); err != nil {
+		t.Fatal(err)
+	}
+	return nc
+}
+
+// request send data to subject with headers and decode its reply
+func request(t *testing.T, nc *gonats.Conn, subject string, data interface{}, header gonats.Header, reply interface{}) {
+	t.Helper()
+	msg := gonats.NewMsg(subject)
+	msg.Header = header
+	msg.Data, _ = json.Marshal(data)
+	response, err := nc.RequestMsg(msg, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(response.Data, reply); err != nil {
+		t.Fatalf("reply %q: %v", response.Data, err)
+	}
+}
+
+func TestNATSRequestReply(t *testing.T) {
+	nc := serve(t, delivery.Endpoints{
+		Login: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(delivery.CreateLoginRequest)
+			if req.Email != "ada@example.com" || req.Passwords != "secret" {
+				t.Errorf("request = %+v", req)
+			}
+			if key, _ := ctx.Value(auth.TenantKeyContextKey).(string); key != "acme" {
+				t.Errorf("tenant = %q, want acme", key)
+			}
+			if client := user.ClientFromContext(ctx); client.RequestID != "request-1" {
+				t.Errorf("request id = %q, want request-1", client.RequestID)
+			}
+			return delivery.CreateLoginResponse{Status: "ok", Token: "token"}, nil
+		},
+	})
+
+	header := gonats.Header{}
+	header.Set(auth.TenantHeader, "acme")
+	header.Set(auth.RequestIDHeader, "request-1")
+	var reply delivery.CreateLoginResponse
+	request(t, nc, nats.SubjectLogin, delivery.CreateLoginRequest{
+		Email:     "ada@example.com",
+		Passwords: "secret",
+	}, header, &reply)
+	if reply.Status != "ok" || reply.Token != "token" {
+		t.Fatalf("reply = %+v", reply)
+	}
+}
+
+func TestNATSErrorReply(t *testing.T) {
+	nc := serve(t, delivery.Endpoints{
+		GetUser: func(context.Context, interface{}) (interface{}, error) {
+			return nil, user.ErrUserNotFound
+		},
+	})
+
+	var reply struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}
+	request(t, nc, nats.SubjectGetUser, delivery.CreateGetUserRequest{ID: "missing"}, nil, &reply)
+	if reply.Error != user.ErrUserNotFound.Error() || reply.Code != http.StatusNotFound {
+		t.Fatalf("reply = %+v, want not found", reply)
+	}
+}
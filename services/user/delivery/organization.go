@@ -0,0 +1,147 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// makeCreateOrganizationEndpoint using go kit endpoint
+func makeCreateOrganizationEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateOrganizationRequest)
+		organization, err := s.CreateOrganization(ctx, req.Name)
+		if err != nil {
+			return CreateOrganizationResponse{}, err
+		}
+		return CreateOrganizationResponse{Organization: *organization}, nil
+	}
+}
+
+// makeListOrganizationsEndpoint using go kit endpoint
+func makeListOrganizationsEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		_ interface{},
+	) (interface{}, error) {
+		organizations, err := s.ListOrganizations(ctx)
+		if err != nil {
+			return CreateListOrganizationsResponse{}, err
+		}
+		return CreateListOrganizationsResponse{Organizations: organizations}, nil
+	}
+}
+
+// makeInviteMemberEndpoint using go kit endpoint
+func makeInviteMemberEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateInviteMemberRequest)
+		invitation, token, err := s.InviteMember(
+			ctx, req.OrganizationID, req.Email, req.Role,
+		)
+		if err != nil {
+			return CreateInviteMemberResponse{}, err
+		}
+		return CreateInviteMemberResponse{
+			Invitation: *invitation,
+			Token:      token,
+		}, nil
+	}
+}
+
+// makeAcceptInvitationEndpoint using go kit endpoint
+func makeAcceptInvitationEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateAcceptInvitationRequest)
+		member, err := s.AcceptInvitation(ctx, req.Token)
+		if err != nil {
+			return CreateMemberResponse{}, err
+		}
+		return CreateMemberResponse{Member: *member}, nil
+	}
+}
+
+// makeDeclineInvitationEndpoint using go kit endpoint
+func makeDeclineInvitationEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateDeclineInvitationRequest)
+		if err := s.DeclineInvitation(ctx, req.Token); err != nil {
+			return CreateStatusResponse{}, err
+		}
+		return CreateStatusResponse{Status: "Success"}, nil
+	}
+}
+
+// makeListMembersEndpoint using go kit endpoint
+func makeListMembersEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateListMembersRequest)
+		members, err := s.ListMembers(ctx, req.OrganizationID)
+		if err != nil {
+			return CreateListMembersResponse{}, err
+		}
+		return CreateListMembersResponse{Members: members}, nil
+	}
+}
+
+// makeChangeMemberRoleEndpoint using go kit endpoint
+func makeChangeMemberRoleEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateChangeMemberRoleRequest)
+		member, err := s.ChangeMemberRole(
+			ctx, req.OrganizationID, req.UserID, req.Role,
+		)
+		if err != nil {
+			return CreateMemberResponse{}, err
+		}
+		return CreateMemberResponse{Member: *member}, nil
+	}
+}
+
+// makeRemoveMemberEndpoint using go kit endpoint
+func makeRemoveMemberEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateRemoveMemberRequest)
+		err := s.RemoveMember(ctx, req.OrganizationID, req.UserID)
+		if err != nil {
+			return CreateStatusResponse{}, err
+		}
+		return CreateStatusResponse{Status: "Success"}, nil
+	}
+}
+
+// makeLeaveOrganizationEndpoint using go kit endpoint
+func makeLeaveOrganizationEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateLeaveOrganizationRequest)
+		if err := s.LeaveOrganization(ctx, req.OrganizationID); err != nil {
+			return CreateStatusResponse{}, err
+		}
+		return CreateStatusResponse{Status: "Success"}, nil
+	}
+}
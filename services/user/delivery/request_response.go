@@ -1,5 +1,11 @@
 package delivery
 
+import (
+	"time"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
 // Types for request and responses
 type (
 	// CreateRegisterRequest struct
@@ -11,13 +17,253 @@ type (
 	CreateRegisterResponse struct {
 		Status string `json:"status"`
 	}
+	// CreateBulkRegisterRequest struct
+	CreateBulkRegisterRequest struct {
+		Users []CreateRegisterRequest `json:"users"`
+	}
+	// CreateBulkRegisterResponse struct, one result per user in request order
+	CreateBulkRegisterResponse struct {
+		Results []CreateBulkRegisterResult `json:"results"`
+	}
+	// CreateBulkRegisterResult struct, error is empty when user registered
+	CreateBulkRegisterResult struct {
+		Email  string `json:"email"`
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
 	// CreateLoginRequest struct
 	CreateLoginRequest struct {
-		Email     string `json:"email"`
-		Passwords string `json:"passwords"`
+		Email       string `json:"email"`
+		Passwords   string `json:"passwords"`
+		DeviceLabel string `json:"device_label,omitempty"`
 	}
 	// CreateLoginResponse struct
 	CreateLoginResponse struct {
 		Status string `json:"status"`
+		Token  string `json:"token,omitempty"`
+	}
+	// CreateGetMeRequest struct
+	CreateGetMeRequest struct{}
+	// CreateGetUserRequest struct
+	CreateGetUserRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateUpdateUserRequest struct, only fields in update mask are changed
+	CreateUpdateUserRequest struct {
+		ID         string   `json:"id"`
+		Email      string   `json:"email"`
+		Name       string   `json:"name"`
+		Bio        string   `json:"bio"`
+		UpdateMask []string `json:"update_mask"`
+	}
+	// CreateChangePasswordRequest struct, current password is only required
+	// when user changes own password
+	CreateChangePasswordRequest struct {
+		ID              string `json:"id"`
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	// CreateChangePasswordResponse struct
+	CreateChangePasswordResponse struct {
+		Status string `json:"status"`
+	}
+	// CreateDeleteUserRequest struct
+	CreateDeleteUserRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateUserResponse struct
+	CreateUserResponse struct {
+		User Profile `json:"user"`
+	}
+	// CreateDeleteUserResponse struct
+	CreateDeleteUserResponse struct {
+		Status string `json:"status"`
+	}
+	// CreateRestoreUserRequest struct
+	CreateRestoreUserRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateSuspendUserRequest struct
+	CreateSuspendUserRequest struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	}
+	// CreateReactivateUserRequest struct
+	CreateReactivateUserRequest struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	}
+	// CreateDeactivateUserRequest struct
+	CreateDeactivateUserRequest struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	}
+	// CreateListUsersRequest struct
+	CreateListUsersRequest struct {
+		EmailPrefix   string    `json:"email_prefix"`
+		Status        string    `json:"status"`
+		Role          string    `json:"role"`
+		CreatedAfter  time.Time `json:"created_after"`
+		CreatedBefore time.Time `json:"created_before"`
+		OrderBy       string    `json:"order_by"`
+		PageSize      int       `json:"page_size"`
+		PageToken     string    `json:"page_token"`
+	}
+	// CreateListUsersResponse struct
+	CreateListUsersResponse struct {
+		Users         []Profile `json:"users"`
+		NextPageToken string    `json:"next_page_token"`
+	}
+	// CreateOrganizationRequest struct
+	CreateOrganizationRequest struct {
+		Name string `json:"name"`
+	}
+	// CreateOrganizationResponse struct
+	CreateOrganizationResponse struct {
+		Organization user.Organization `json:"organization"`
+	}
+	// CreateListOrganizationsRequest struct
+	CreateListOrganizationsRequest struct{}
+	// CreateListOrganizationsResponse struct
+	CreateListOrganizationsResponse struct {
+		Organizations []user.Organization `json:"organizations"`
+	}
+	// CreateInviteMemberRequest struct
+	CreateInviteMemberRequest struct {
+		OrganizationID string `json:"organization_id"`
+		Email          string `json:"email"`
+		Role           string `json:"role"`
+	}
+	// CreateInviteMemberResponse struct, token is only returned once
+	CreateInviteMemberResponse struct {
+		Invitation user.Invitation `json:"invitation"`
+		Token      string          `json:"token"`
+	}
+	// CreateAcceptInvitationRequest struct
+	CreateAcceptInvitationRequest struct {
+		Token string `json:"token"`
+	}
+	// CreateDeclineInvitationRequest struct
+	CreateDeclineInvitationRequest struct {
+		Token string `json:"token"`
+	}
+	// CreateListMembersRequest struct
+	CreateListMembersRequest struct {
+		OrganizationID string `json:"organization_id"`
+	}
+	// CreateListMembersResponse struct
+	CreateListMembersResponse struct {
+		Members []user.Membership `json:"members"`
+	}
+	// CreateChangeMemberRoleRequest struct
+	CreateChangeMemberRoleRequest struct {
+		OrganizationID string `json:"organization_id"`
+		UserID         string `json:"user_id"`
+		Role           string `json:"role"`
+	}
+	// CreateRemoveMemberRequest struct
+	CreateRemoveMemberRequest struct {
+		OrganizationID string `json:"organization_id"`
+		UserID         string `json:"user_id"`
+	}
+	// CreateLeaveOrganizationRequest struct
+	CreateLeaveOrganizationRequest struct {
+		OrganizationID string `json:"organization_id"`
+	}
+	// CreateMemberResponse struct
+	CreateMemberResponse struct {
+		Member user.Membership `json:"member"`
+	}
+	// CreateStatusResponse struct
+	CreateStatusResponse struct {
+		Status string `json:"status"`
+	}
+	// CreateListSessionsRequest struct
+	CreateListSessionsRequest struct{}
+	// CreateListSessionsResponse struct
+	CreateListSessionsResponse struct {
+		Sessions []user.Session `json:"sessions"`
+	}
+	// CreateRevokeSessionRequest struct
+	CreateRevokeSessionRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateListAuditEventsRequest struct
+	CreateListAuditEventsRequest struct {
+		ActorID       string    `json:"actor_id"`
+		Target        string    `json:"target"`
+		Action        string    `json:"action"`
+		Outcome       string    `json:"outcome"`
+		CreatedAfter  time.Time `json:"created_after"`
+		CreatedBefore time.Time `json:"created_before"`
+		PageSize      int       `json:"page_size"`
+		PageToken     string    `json:"page_token"`
+	}
+	// CreateListAuditEventsResponse struct
+	CreateListAuditEventsResponse struct {
+		Events        []user.AuditEvent `json:"events"`
+		NextPageToken string            `json:"next_page_token"`
+	}
+	// CreateWebhookRequest struct
+	CreateWebhookRequest struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	// CreateWebhookResponse struct, secret is only revealed once
+	CreateWebhookResponse struct {
+		Webhook user.Webhook `json:"webhook"`
+		Secret  string       `json:"secret"`
+	}
+	// CreateListWebhooksRequest struct
+	CreateListWebhooksRequest struct{}
+	// CreateListWebhooksResponse struct
+	CreateListWebhooksResponse struct {
+		Webhooks []user.Webhook `json:"webhooks"`
+	}
+	// CreateDeleteWebhookRequest struct
+	CreateDeleteWebhookRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateListWebhookDeliveriesRequest struct
+	CreateListWebhookDeliveriesRequest struct {
+		WebhookID string `json:"webhook_id"`
+		Status    string `json:"status"`
+	}
+	// CreateListWebhookDeliveriesResponse struct
+	CreateListWebhookDeliveriesResponse struct {
+		Deliveries []user.WebhookDelivery `json:"deliveries"`
+	}
+	// CreateRedeliverWebhookRequest struct
+	CreateRedeliverWebhookRequest struct {
+		ID string `json:"id"`
+	}
+	// CreateWebhookDeliveryResponse struct
+	CreateWebhookDeliveryResponse struct {
+		Delivery user.WebhookDelivery `json:"delivery"`
+	}
+	// Profile struct, user representation without credentials
+	Profile struct {
+		ID        string    `json:"id"`
+		Email     string    `json:"email"`
+		Name      string    `json:"name"`
+		Bio       string    `json:"bio"`
+		Role      string    `json:"role"`
+		Status    string    `json:"status"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
 	}
 )
+
+// NewProfile convert user model into profile
+func NewProfile(u user.User) Profile {
+	return Profile{
+		ID:        u.ID.String(),
+		Email:     u.Email,
+		Name:      u.Name,
+		Bio:       u.Bio,
+		Role:      u.Role,
+		Status:    u.Status,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
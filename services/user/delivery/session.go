@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// makeListSessionsEndpoint using go kit endpoint
+func makeListSessionsEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		_ interface{},
+	) (interface{}, error) {
+		sessions, err := s.ListSessions(ctx)
+		if err != nil {
+			return CreateListSessionsResponse{}, err
+		}
+		return CreateListSessionsResponse{Sessions: sessions}, nil
+	}
+}
+
+// makeRevokeSessionEndpoint using go kit endpoint
+func makeRevokeSessionEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateRevokeSessionRequest)
+		err := s.RevokeSession(ctx, req.ID)
+		if err != nil {
+			return CreateStatusResponse{}, err
+		}
+		return CreateStatusResponse{Status: "Success"}, nil
+	}
+}
@@ -0,0 +1,82 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// makeCreateWebhookEndpoint using go kit endpoint
+func makeCreateWebhookEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateWebhookRequest)
+		webhook, secret, err := s.CreateWebhook(ctx, req.URL, req.Events)
+		if err != nil {
+			return CreateWebhookResponse{}, err
+		}
+		return CreateWebhookResponse{Webhook: *webhook, Secret: secret}, nil
+	}
+}
+
+// makeListWebhooksEndpoint using go kit endpoint
+func makeListWebhooksEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		_ interface{},
+	) (interface{}, error) {
+		webhooks, err := s.ListWebhooks(ctx)
+		if err != nil {
+			return CreateListWebhooksResponse{}, err
+		}
+		return CreateListWebhooksResponse{Webhooks: webhooks}, nil
+	}
+}
+
+// makeDeleteWebhookEndpoint using go kit endpoint
+func makeDeleteWebhookEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateDeleteWebhookRequest)
+		err := s.DeleteWebhook(ctx, req.ID)
+		if err != nil {
+			return CreateStatusResponse{}, err
+		}
+		return CreateStatusResponse{Status: "Success"}, nil
+	}
+}
+
+// makeListWebhookDeliveriesEndpoint using go kit endpoint
+func makeListWebhookDeliveriesEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateListWebhookDeliveriesRequest)
+		deliveries, err := s.ListWebhookDeliveries(ctx, req.WebhookID, req.Status)
+		if err != nil {
+			return CreateListWebhookDeliveriesResponse{}, err
+		}
+		return CreateListWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+	}
+}
+
+// makeRedeliverWebhookEndpoint using go kit endpoint
+func makeRedeliverWebhookEndpoint(s user.Service) endpoint.Endpoint {
+	return func(
+		ctx context.Context,
+		request interface{},
+	) (interface{}, error) {
+		req := request.(CreateRedeliverWebhookRequest)
+		delivery, err := s.RedeliverWebhook(ctx, req.ID)
+		if err != nil {
+			return CreateWebhookDeliveryResponse{}, err
+		}
+		return CreateWebhookDeliveryResponse{Delivery: *delivery}, nil
+	}
+}
@@ -0,0 +1,50 @@
+package user
+
+import "errors"
+
+// Business logic errors
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidUserID      = errors.New("invalid user id")
+	ErrInvalidCredentials = errors.New("email or password is incorrect")
+	ErrEmailTaken         = errors.New("email is already registered")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrEmptyUpdateMask    = errors.New("update mask is empty")
+	ErrInvalidUpdateMask  = errors.New("update mask contains immutable field")
+	ErrInvalidOrderBy     = errors.New("invalid order by")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+	ErrInvalidStatus      = errors.New("invalid account status")
+	ErrStatusTransition   = errors.New("account status transition is not allowed")
+	ErrAccountPending     = errors.New("account is pending activation")
+	ErrAccountSuspended   = errors.New("account is suspended")
+	ErrAccountLocked      = errors.New("account is locked")
+	ErrAccountDeactivated = errors.New("account is deactivated")
+	ErrTenantRequired     = errors.New("tenant is required")
+	ErrTenantNotFound     = errors.New("tenant not found")
+	ErrTenantMismatch     = errors.New("token does not belong to requested tenant")
+	ErrTenantSlugTaken    = errors.New("tenant slug is already taken")
+	ErrInvalidTenantSlug  = errors.New("tenant slug must be lowercase dns label")
+
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrInvalidOrgID         = errors.New("invalid organization id")
+	ErrInvalidOrgRole       = errors.New("invalid organization role")
+	ErrNotMember            = errors.New("user is not organization member")
+	ErrAlreadyMember        = errors.New("user is already organization member")
+	ErrLastOwner            = errors.New("organization must keep at least one owner")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationExpired    = errors.New("invitation is expired")
+	ErrInvitationEmail      = errors.New("invitation was sent to another email")
+
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrInvalidSessionID = errors.New("invalid session id")
+	ErrSessionRevoked   = errors.New("session is revoked")
+
+	ErrAuditChainBroken = errors.New("audit chain is broken")
+
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrInvalidWebhookID    = errors.New("invalid webhook id")
+	ErrInvalidWebhookURL   = errors.New("webhook url must be absolute http or https url of public host")
+	ErrInvalidWebhookEvent = errors.New("invalid webhook event")
+	ErrDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrInvalidDeliveryID   = errors.New("invalid webhook delivery id")
+)
@@ -0,0 +1,111 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Domain event types
+const (
+	EventUserRegistered  = "UserRegistered"
+	EventUserLoggedIn    = "UserLoggedIn"
+	EventPasswordChanged = "PasswordChanged"
+	EventUserDeleted     = "UserDeleted"
+)
+
+// MaxEventAttempts failed attempts before outbox event is dead lettered
+const MaxEventAttempts = 16
+
+// Event domain event stored in outbox within transaction of the state change
+// it describes. Sequence is assigned by database and orders events, id lets
+// consumers drop duplicates of at least once delivery
+type Event struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	Sequence      int64      `json:"sequence" db:"sequence"`
+	TenantID      uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	AggregateID   uuid.UUID  `json:"aggregate_id" db:"aggregate_id"`
+	Type          string     `json:"type"`
+	Payload       string     `json:"payload"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Attempts      int        `json:"-"`
+	NextAttemptAt time.Time  `json:"-" db:"next_attempt_at"`
+	LastError     string     `json:"-" db:"last_error"`
+	PublishedAt   *time.Time `json:"-" db:"published_at"`
+}
+
+// UserRegisteredPayload payload of UserRegistered event
+type UserRegisteredPayload struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// UserLoggedInPayload payload of UserLoggedIn event
+type UserLoggedInPayload struct {
+	SessionID   uuid.UUID `json:"session_id"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	DeviceLabel string    `json:"device_label"`
+}
+
+// PasswordChangedPayload payload of PasswordChanged event, changed by is id
+// of user or admin who changed it
+type PasswordChangedPayload struct {
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// UserDeletedPayload payload of UserDeleted event
+type UserDeletedPayload struct {
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// OutboxRepository interface for relaying outbox events, runs across tenants.
+// Claimed events are leased to caller so relays of other processes skip
+// them until lease expires
+type OutboxRepository interface {
+	ClaimEvents(ctx context.Context, limit int, now time.Time, lease time.Duration) ([]Event, error)
+	MarkPublished(ctx context.Context, id uuid.UUID, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, nextAttemptAt time.Time, reason string) error
+	MarkDead(ctx context.Context, id uuid.UUID, deadAt time.Time, reason string) error
+}
+
+// Publisher delivers outbox event to other systems
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NewEvent create event of type about aggregate with json encoded payload
+func NewEvent(
+	eventType string,
+	aggregateID uuid.UUID,
+	payload interface{},
+	createdAt time.Time,
+) (Event, error) {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:            uuid.NewV4(),
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       string(content),
+		CreatedAt:     createdAt,
+		NextAttemptAt: createdAt,
+	}, nil
+}
+
+// MarshalJSON encode event with payload embedded as json object
+func (event Event) MarshalJSON() ([]byte, error) {
+	type envelope Event
+	return json.Marshal(struct {
+		envelope
+		Payload json.RawMessage `json:"payload"`
+	}{
+		envelope: envelope(event),
+		Payload:  json.RawMessage(event.Payload),
+	})
+}
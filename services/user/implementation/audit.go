@@ -0,0 +1,48 @@
+package implementation
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// ListAuditEvents logic function, returns audit events page newest first
+// and next page token
+func (service userService) ListAuditEvents(
+	ctx context.Context,
+	filter user.AuditFilter,
+	pageSize int,
+	pageToken string,
+) ([]user.AuditEvent, string, error) {
+	var err error
+	if err = service.requireAdmin(ctx); err != nil {
+		return nil, "", err
+	}
+	query := user.AuditQuery{
+		Filter:   filter,
+		PageSize: pageSize,
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = user.DefaultPageSize
+	}
+	if query.PageSize > user.MaxPageSize {
+		query.PageSize = user.MaxPageSize
+	}
+	if pageToken != "" {
+		query.Before, err = user.DecodeAuditPageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	// Repository returns one extra event to tell whether next page exists
+	events, err := service.audit.ListAuditEvents(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(events) <= query.PageSize {
+		return events, "", nil
+	}
+	events = events[:query.PageSize]
+	last := events[len(events)-1]
+	return events, user.EncodeAuditPageToken(last.Sequence), nil
+}
@@ -0,0 +1,308 @@
+package implementation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// CreateOrganization logic function, creator becomes organization owner
+func (service userService) CreateOrganization(
+	ctx context.Context,
+	name string,
+) (*user.Organization, error) {
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	organization := user.Organization{
+		ID:        uuid.NewV4(),
+		TenantID:  currentUser.TenantID,
+		Name:      name,
+		CreatedBy: currentUser.ID,
+		CreatedAt: now,
+	}
+	owner := user.Membership{
+		TenantID:       currentUser.TenantID,
+		OrganizationID: organization.ID,
+		UserID:         currentUser.ID,
+		Role:           user.OrgRoleOwner,
+		CreatedAt:      now,
+	}
+	err = service.organizations.CreateOrganization(ctx, organization, owner)
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// ListOrganizations logic function, returns organizations of current user
+func (service userService) ListOrganizations(
+	ctx context.Context,
+) ([]user.Organization, error) {
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return service.organizations.ListOrganizations(ctx, currentUser.ID)
+}
+
+// InviteMember logic function, only owner may invite as only owner may
+// change roles. Returns invitation and its token which is only available at
+// creation
+func (service userService) InviteMember(
+	ctx context.Context,
+	organizationID, email, role string,
+) (*user.Invitation, string, error) {
+	if !user.ValidOrgRole(role) {
+		return nil, "", user.ErrInvalidOrgRole
+	}
+	inviter, err := service.requireOrgRole(ctx, organizationID, user.OrgRoleOwner)
+	if err != nil {
+		return nil, "", err
+	}
+	token, tokenHash, err := user.NewInvitationToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	invitation := user.Invitation{
+		ID:             uuid.NewV4(),
+		TenantID:       inviter.TenantID,
+		OrganizationID: inviter.OrganizationID,
+		Email:          strings.ToLower(email),
+		Role:           role,
+		TokenHash:      tokenHash,
+		Status:         user.InvitationPending,
+		InvitedBy:      inviter.UserID,
+		ExpiresAt:      now.Add(user.InvitationDuration),
+		CreatedAt:      now,
+	}
+	if err := service.organizations.CreateInvitation(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+	return &invitation, token, nil
+}
+
+// AcceptInvitation logic function, current user joins organization
+func (service userService) AcceptInvitation(
+	ctx context.Context,
+	token string,
+) (*user.Membership, error) {
+	currentUser, invitation, err := service.invitationFor(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	_, err = service.organizations.GetMembership(
+		ctx, invitation.OrganizationID, currentUser.ID,
+	)
+	if err == nil {
+		return nil, user.ErrAlreadyMember
+	}
+	if err != user.ErrNotMember {
+		return nil, err
+	}
+	member := user.Membership{
+		TenantID:       invitation.TenantID,
+		OrganizationID: invitation.OrganizationID,
+		UserID:         currentUser.ID,
+		Role:           invitation.Role,
+		CreatedAt:      time.Now(),
+	}
+	err = service.organizations.AcceptInvitation(ctx, *invitation, member)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// DeclineInvitation logic function
+func (service userService) DeclineInvitation(
+	ctx context.Context,
+	token string,
+) error {
+	_, invitation, err := service.invitationFor(ctx, token)
+	if err != nil {
+		return err
+	}
+	return service.organizations.DeclineInvitation(ctx, invitation.ID)
+}
+
+// ListMembers logic function, only members may see other members
+func (service userService) ListMembers(
+	ctx context.Context,
+	organizationID string,
+) ([]user.Membership, error) {
+	member, err := service.requireOrgRole(ctx, organizationID, user.OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	return service.organizations.ListMembers(ctx, member.OrganizationID)
+}
+
+// ChangeMemberRole logic function, only owner may change roles
+func (service userService) ChangeMemberRole(
+	ctx context.Context,
+	organizationID, userID, role string,
+) (*user.Membership, error) {
+	if !user.ValidOrgRole(role) {
+		return nil, user.ErrInvalidOrgRole
+	}
+	owner, err := service.requireOrgRole(ctx, organizationID, user.OrgRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	member, err := service.membership(ctx, owner.OrganizationID.String(), userID)
+	if err != nil {
+		return nil, err
+	}
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if member.Role == user.OrgRoleOwner && role != user.OrgRoleOwner {
+			if err := service.keepOwner(ctx, member.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return service.organizations.ChangeMemberRole(
+			ctx, member.OrganizationID, member.UserID, role,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember logic function, admin may remove member with lower or equal role
+// except owner which only owner may remove
+func (service userService) RemoveMember(
+	ctx context.Context,
+	organizationID, userID string,
+) error {
+	remover, err := service.requireOrgRole(ctx, organizationID, user.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	member, err := service.membership(ctx, remover.OrganizationID.String(), userID)
+	if err != nil {
+		return err
+	}
+	if !user.OrgRoleAtLeast(remover.Role, member.Role) {
+		return user.ErrPermissionDenied
+	}
+	return service.removeMember(ctx, *member)
+}
+
+// LeaveOrganization logic function
+func (service userService) LeaveOrganization(
+	ctx context.Context,
+	organizationID string,
+) error {
+	member, err := service.requireOrgRole(ctx, organizationID, user.OrgRoleMember)
+	if err != nil {
+		return err
+	}
+	return service.removeMember(ctx, *member)
+}
+
+// removeMember remove member while keeping at least one owner
+func (service userService) removeMember(
+	ctx context.Context,
+	member user.Membership,
+) error {
+	return service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if member.Role == user.OrgRoleOwner {
+			if err := service.keepOwner(ctx, member.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return service.organizations.RemoveMember(
+			ctx, member.OrganizationID, member.UserID,
+		)
+	})
+}
+
+// keepOwner returns error when organization has only one owner left, owners
+// stay locked until unit of work of context ends so caller removing or
+// demoting one of them within it leaves the rest in place
+func (service userService) keepOwner(
+	ctx context.Context,
+	organizationID uuid.UUID,
+) error {
+	owners, err := service.organizations.LockOwners(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return user.ErrLastOwner
+	}
+	return nil
+}
+
+// requireOrgRole returns membership of current user when its role in
+// organization is at least minimum role
+func (service userService) requireOrgRole(
+	ctx context.Context,
+	organizationID, minimum string,
+) (*user.Membership, error) {
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	member, err := service.membership(ctx, organizationID, currentUser.ID.String())
+	if err == user.ErrNotMember {
+		return nil, user.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.OrgRoleAtLeast(member.Role, minimum) {
+		return nil, user.ErrPermissionDenied
+	}
+	return member, nil
+}
+
+// membership parse ids and returns membership
+func (service userService) membership(
+	ctx context.Context,
+	organizationID, userID string,
+) (*user.Membership, error) {
+	orgID, err := uuid.FromString(organizationID)
+	if err != nil {
+		return nil, user.ErrInvalidOrgID
+	}
+	memberID, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, user.ErrInvalidUserID
+	}
+	return service.organizations.GetMembership(ctx, orgID, memberID)
+}
+
+// invitationFor returns current user and pending invitation addressed to them
+func (service userService) invitationFor(
+	ctx context.Context,
+	token string,
+) (*user.User, *user.Invitation, error) {
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	invitation, err := service.organizations.GetInvitation(
+		ctx, user.HashInvitationToken(token),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.EqualFold(invitation.Email, currentUser.Email) {
+		return nil, nil, user.ErrInvitationEmail
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, nil, user.ErrInvitationExpired
+	}
+	return currentUser, invitation, nil
+}
@@ -0,0 +1,120 @@
+package implementation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// join invites u into organization as role by owner and accepts invitation
+func (f *fixture) join(t *testing.T, owner context.Context, organizationID string, u user.User, role string) {
+	t.Helper()
+	_, token, err := f.service.InviteMember(owner, organizationID, u.Email, role)
+	if err != nil {
+		t.Fatalf("invite %s: %v", u.Email, err)
+	}
+	if _, err := f.service.AcceptInvitation(f.as(u), token); err != nil {
+		t.Fatalf("accept invitation of %s: %v", u.Email, err)
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+	alan := f.register(t, "alan@example.com")
+	owner := f.as(ada)
+	organization, err := f.service.CreateOrganization(owner, "Engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgID := organization.ID.String()
+
+	_, _, err = f.service.InviteMember(owner, orgID, grace.Email, "emperor")
+	expect(t, "invite with unknown role", err, user.ErrInvalidOrgRole)
+	_, _, err = f.service.InviteMember(f.as(alan), orgID, grace.Email, user.OrgRoleMember)
+	expect(t, "invite by outsider", err, user.ErrOrganizationNotFound)
+
+	// Invitation is accepted only by user it was sent to, once
+	_, token, err := f.service.InviteMember(owner, orgID, "GRACE@example.com", user.OrgRoleAdmin)
+	expect(t, "invite", err, nil)
+	_, err = f.service.AcceptInvitation(f.as(alan), token)
+	expect(t, "accept invitation of other email", err, user.ErrInvitationEmail)
+	member, err := f.service.AcceptInvitation(f.as(grace), token)
+	if err != nil || member.Role != user.OrgRoleAdmin {
+		t.Fatalf("accept: %+v, err %v", member, err)
+	}
+	_, err = f.service.AcceptInvitation(f.as(grace), token)
+	expect(t, "accept again", err, user.ErrInvitationNotFound)
+
+	// Admin may not invite, only owner may
+	_, _, err = f.service.InviteMember(f.as(grace), orgID, alan.Email, user.OrgRoleMember)
+	expect(t, "invite by admin", err, user.ErrPermissionDenied)
+
+	_, token, err = f.service.InviteMember(owner, orgID, alan.Email, user.OrgRoleMember)
+	expect(t, "invite", err, nil)
+	expect(t, "decline", f.service.DeclineInvitation(f.as(alan), token), nil)
+	_, err = f.service.AcceptInvitation(f.as(alan), token)
+	expect(t, "accept declined", err, user.ErrInvitationNotFound)
+
+	_, token, err = f.service.InviteMember(owner, orgID, alan.Email, user.OrgRoleMember)
+	expect(t, "invite", err, nil)
+	_, err = f.sess.Update("organization_invitations").
+		Set("expires_at", time.Now().Add(-time.Minute)).
+		Where("email = ? AND status = ?", alan.Email, user.InvitationPending).
+		ExecContext(f.ctx)
+	expect(t, "expire invitation", err, nil)
+	_, err = f.service.AcceptInvitation(f.as(alan), token)
+	expect(t, "accept expired", err, user.ErrInvitationExpired)
+
+	members, err := f.service.ListMembers(f.as(grace), orgID)
+	if err != nil || len(members) != 2 {
+		t.Fatalf("list members: %+v, err %v", members, err)
+	}
+	_, err = f.service.ListMembers(f.as(alan), orgID)
+	expect(t, "list members by outsider", err, user.ErrOrganizationNotFound)
+}
+
+func TestOrganizationRoles(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+	alan := f.register(t, "alan@example.com")
+	owner := f.as(ada)
+	organization, err := f.service.CreateOrganization(owner, "Engines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgID := organization.ID.String()
+	f.join(t, owner, orgID, grace, user.OrgRoleAdmin)
+	f.join(t, owner, orgID, alan, user.OrgRoleMember)
+
+	_, err = f.service.ChangeMemberRole(f.as(grace), orgID, alan.ID.String(), user.OrgRoleAdmin)
+	expect(t, "change role by admin", err, user.ErrPermissionDenied)
+	_, err = f.service.ChangeMemberRole(owner, orgID, ada.ID.String(), user.OrgRoleAdmin)
+	expect(t, "demote last owner", err, user.ErrLastOwner)
+	expect(t, "last owner leaves", f.service.LeaveOrganization(owner, orgID), user.ErrLastOwner)
+
+	// Admin removes members but not owners
+	expect(t, "admin removes owner", f.service.RemoveMember(f.as(grace), orgID, ada.ID.String()), user.ErrPermissionDenied)
+	expect(t, "member removes admin", f.service.RemoveMember(f.as(alan), orgID, grace.ID.String()), user.ErrPermissionDenied)
+	expect(t, "admin removes member", f.service.RemoveMember(f.as(grace), orgID, alan.ID.String()), nil)
+	expect(t, "remove again", f.service.RemoveMember(f.as(grace), orgID, alan.ID.String()), user.ErrNotMember)
+
+	// Once there is another owner the first one may step down
+	promoted, err := f.service.ChangeMemberRole(owner, orgID, grace.ID.String(), user.OrgRoleOwner)
+	if err != nil || promoted.Role != user.OrgRoleOwner {
+		t.Fatalf("promote: %+v, err %v", promoted, err)
+	}
+	_, err = f.service.ChangeMemberRole(owner, orgID, ada.ID.String(), user.OrgRoleMember)
+	expect(t, "demote one of owners", err, nil)
+	expect(t, "remaining owner leaves", f.service.LeaveOrganization(f.as(grace), orgID), user.ErrLastOwner)
+	expect(t, "member leaves", f.service.LeaveOrganization(owner, orgID), nil)
+
+	organizations, err := f.service.ListOrganizations(owner)
+	if err != nil || len(organizations) != 0 {
+		t.Fatalf("organizations of user who left: %+v, err %v", organizations, err)
+	}
+}
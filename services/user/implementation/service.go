@@ -2,7 +2,6 @@ package implementation
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/muhammadisa/go-kit-boilerplate/services/user"
@@ -14,13 +13,39 @@ import (
 
 // userService struct
 type userService struct {
-	repository user.Repository
+	repository    user.Repository
+	organizations user.OrganizationRepository
+	sessions      user.SessionRepository
+	audit         user.AuditRepository
+	webhooks      user.WebhookRepository
+	transactor    user.Transactor
+	secret        string
+	approval      bool
 }
 
-// NewService create instance of userService struct
-func NewService(repo user.Repository) user.Service {
+// NewService create instance of userService struct, with approval users
+// register as pending until admin activates them. Calls hashing or checking
+// passwords run their writes within transactor once that is done, so slow
+// hashing does not hold transaction open
+func NewService(
+	repo user.Repository,
+	organizationRepo user.OrganizationRepository,
+	sessionRepo user.SessionRepository,
+	auditRepo user.AuditRepository,
+	webhookRepo user.WebhookRepository,
+	transactor user.Transactor,
+	secret string,
+	approval bool,
+) user.Service {
 	return &userService{
-		repository: repo,
+		repository:    repo,
+		organizations: organizationRepo,
+		sessions:      sessionRepo,
+		audit:         auditRepo,
+		webhooks:      webhookRepo,
+		transactor:    transactor,
+		secret:        secret,
+		approval:      approval,
 	}
 }
 
@@ -33,31 +58,420 @@ func (service userService) Register(
 	if err != nil {
 		return "", err
 	}
+	status := user.StatusActive
+	if service.approval {
+		status = user.StatusPending
+	}
 	newUUID := uuid.NewV4()
+	now := time.Now()
 	newUser := user.User{
 		ID:        newUUID,
 		Email:     email,
 		Passwords: string(hashedPassword),
-		CreatedAt: time.Now(),
+		Role:      user.RoleUser,
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
-	if err := service.repository.Register(ctx, newUser); err != nil {
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return service.repository.Register(ctx, newUser)
+	})
+	if err != nil {
 		return "", err
 	}
 	return "Success", nil
 }
 
-// Login logic function
+// Login logic function, records session of device and returns signed
+// token bound to it. Active user failing MaxFailedLogins logins in a row is
+// locked
 func (service userService) Login(
 	ctx context.Context,
-	email, passwords string,
+	email, passwords, deviceLabel string,
 ) (string, error) {
 	selectedUser, err := service.repository.Login(ctx, email, passwords)
 	if err != nil {
 		return "", err
 	}
-	err = auth.VerifyPassword(selectedUser.Passwords, passwords)
+	if err := auth.VerifyPassword(selectedUser.Passwords, passwords); err != nil {
+		err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			return service.failLogin(ctx, selectedUser)
+		})
+		if err != nil {
+			return "", err
+		}
+		return "", user.ErrInvalidCredentials
+	}
+	client := user.ClientFromContext(ctx)
+	if deviceLabel == "" {
+		deviceLabel = user.DeviceLabel(client.UserAgent)
+	}
+	now := time.Now()
+	session := user.Session{
+		ID:          uuid.NewV4(),
+		UserID:      selectedUser.ID,
+		UserAgent:   client.UserAgent,
+		IP:          client.IP,
+		DeviceLabel: deviceLabel,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+	}
+	// Count of failed logins starts over even when status refuses login, so
+	// refusal is returned once reset is committed
+	refused := user.StatusError(selectedUser.Status)
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if selectedUser.FailedLogins > 0 {
+			err := service.repository.UpdateUser(ctx, selectedUser.ID, map[string]interface{}{
+				"failed_logins": 0,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if refused != nil {
+			return nil
+		}
+		return service.sessions.CreateSession(ctx, session)
+	})
+	if err != nil {
+		return "", err
+	}
+	if refused != nil {
+		return "", refused
+	}
+	return auth.GenerateToken(
+		service.secret,
+		selectedUser.ID.String(),
+		selectedUser.TenantID.String(),
+		session.ID.String(),
+	)
+}
+
+// failLogin count failed login of user and lock active user reaching
+// MaxFailedLogins revoking its sessions, user changed meanwhile is left as
+// it is. Caller runs it within transaction so count, lock and revocation
+// are committed together
+func (service userService) failLogin(ctx context.Context, selectedUser *user.User) error {
+	failed, err := service.repository.FailLogin(ctx, selectedUser.ID)
 	if err != nil {
-		return "", errors.New("email or password is incorrect")
+		return err
 	}
-	return "Success", nil
+	if failed < user.MaxFailedLogins || selectedUser.Status != user.StatusActive {
+		return nil
+	}
+	change := user.StatusChange{
+		ID:         uuid.NewV4(),
+		UserID:     selectedUser.ID,
+		FromStatus: user.StatusActive,
+		ToStatus:   user.StatusLocked,
+		Reason:     "too many failed logins",
+		ActorID:    user.SystemActor,
+		CreatedAt:  time.Now(),
+	}
+	err = service.repository.ChangeStatus(ctx, change)
+	if err == user.ErrStatusTransition {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return service.sessions.RevokeSessions(ctx, selectedUser.ID, change.CreatedAt)
+}
+
+// GetMe logic function
+func (service userService) GetMe(
+	ctx context.Context,
+) (*user.User, error) {
+	id, err := auth.UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return service.getUser(ctx, id)
+}
+
+// GetUser logic function, only user themselves and admin may read the
+// account since it holds email, role and status
+func (service userService) GetUser(
+	ctx context.Context,
+	id string,
+) (*user.User, error) {
+	if err := service.authorize(ctx, id); err != nil {
+		return nil, err
+	}
+	return service.getUser(ctx, id)
+}
+
+// getUser returns user of id without checking who asks for it
+func (service userService) getUser(
+	ctx context.Context,
+	id string,
+) (*user.User, error) {
+	userID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, user.ErrInvalidUserID
+	}
+	return service.repository.GetUser(ctx, userID)
+}
+
+// UpdateUser logic function, returns user as stored once updated rather
+// than one read before update with changes applied
+func (service userService) UpdateUser(
+	ctx context.Context,
+	id string,
+	update user.Update,
+) (*user.User, error) {
+	if err := service.authorize(ctx, id); err != nil {
+		return nil, err
+	}
+	selectedUser, err := service.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := update.Apply(selectedUser)
+	if err != nil {
+		return nil, err
+	}
+	columns["updated_at"] = time.Now()
+	var updatedUser *user.User
+	err = service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := service.repository.UpdateUser(ctx, selectedUser.ID, columns)
+		if err != nil {
+			return err
+		}
+		// Unit of work wrote so user is read back from primary
+		updatedUser, err = service.repository.GetUser(ctx, selectedUser.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updatedUser, nil
+}
+
+// ChangePassword logic function, user changing own password must give
+// current one while admin may reset password of others without it. Every
+// session of user is revoked together with the change
+func (service userService) ChangePassword(
+	ctx context.Context,
+	id, currentPassword, newPassword string,
+) error {
+	if err := service.authorize(ctx, id); err != nil {
+		return err
+	}
+	selectedUser, err := service.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	currentID, err := auth.UserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if currentID == id {
+		// Users are read without password hash when cached, login reads it
+		credentials, err := service.repository.Login(ctx, selectedUser.Email, currentPassword)
+		if err != nil {
+			return err
+		}
+		err = auth.VerifyPassword(credentials.Passwords, currentPassword)
+		if err != nil {
+			return user.ErrInvalidCredentials
+		}
+	}
+	hashedPassword, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	changedAt := time.Now()
+	return service.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := service.repository.ChangePassword(
+			ctx,
+			selectedUser.ID,
+			string(hashedPassword),
+			currentID,
+			changedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return service.sessions.RevokeSessions(ctx, selectedUser.ID, changedAt)
+	})
+}
+
+// DeleteUser logic function
+func (service userService) DeleteUser(
+	ctx context.Context,
+	id string,
+) error {
+	if err := service.authorize(ctx, id); err != nil {
+		return err
+	}
+	userID, err := uuid.FromString(id)
+	if err != nil {
+		return user.ErrInvalidUserID
+	}
+	return service.repository.DeleteUser(ctx, userID, time.Now())
+}
+
+// RestoreUser logic function, only user deleted within retention period
+// can be restored
+func (service userService) RestoreUser(
+	ctx context.Context,
+	id string,
+) (*user.User, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	userID, err := uuid.FromString(id)
+	if err != nil {
+		return nil, user.ErrInvalidUserID
+	}
+	deletedAfter := time.Now().Add(-user.RetentionPeriod)
+	err = service.repository.RestoreUser(ctx, userID, deletedAfter)
+	if err != nil {
+		return nil, err
+	}
+	return service.repository.GetUser(ctx, userID)
+}
+
+// SuspendUser logic function
+func (service userService) SuspendUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	return service.changeStatus(ctx, id, user.StatusSuspended, reason)
+}
+
+// ReactivateUser logic function, also activates pending user and unlocks
+// locked one
+func (service userService) ReactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	return service.changeStatus(ctx, id, user.StatusActive, reason)
+}
+
+// DeactivateUser logic function
+func (service userService) DeactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (*user.User, error) {
+	return service.changeStatus(ctx, id, user.StatusDeactivated, reason)
+}
+
+// changeStatus move user to status when transition is allowed and
+// record it into status history, sessions of user who may no longer log in
+// are revoked
+func (service userService) changeStatus(
+	ctx context.Context,
+	id, status, reason string,
+) (*user.User, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	actorID, err := auth.UserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selectedUser, err := service.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CanTransition(selectedUser.Status, status) {
+		return nil, user.ErrStatusTransition
+	}
+	change := user.StatusChange{
+		ID:         uuid.NewV4(),
+		UserID:     selectedUser.ID,
+		FromStatus: selectedUser.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ActorID:    actorID,
+		CreatedAt:  time.Now(),
+	}
+	if err := service.repository.ChangeStatus(ctx, change); err != nil {
+		return nil, err
+	}
+	if user.StatusError(status) != nil {
+		err := service.sessions.RevokeSessions(ctx, selectedUser.ID, change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	selectedUser.Status = status
+	selectedUser.FailedLogins = 0
+	selectedUser.UpdatedAt = change.CreatedAt
+	return selectedUser, nil
+}
+
+// ListUsers logic function, returns users page and next page token
+func (service userService) ListUsers(
+	ctx context.Context,
+	filter user.ListFilter,
+	orderBy string,
+	pageSize int,
+	pageToken string,
+) ([]user.User, string, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, "", err
+	}
+	sorts, err := user.ParseOrderBy(orderBy)
+	if err != nil {
+		return nil, "", err
+	}
+	query := user.ListQuery{
+		Filter:   filter,
+		OrderBy:  sorts,
+		PageSize: pageSize,
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = user.DefaultPageSize
+	}
+	if query.PageSize > user.MaxPageSize {
+		query.PageSize = user.MaxPageSize
+	}
+	if pageToken != "" {
+		query.After, err = user.DecodePageToken(pageToken, query)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	// Repository returns one extra row to tell whether next page exists
+	users, err := service.repository.ListUsers(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) <= query.PageSize {
+		return users, "", nil
+	}
+	users = users[:query.PageSize]
+	nextCursor := user.NewCursor(query, users[len(users)-1])
+	return users, user.EncodePageToken(nextCursor), nil
+}
+
+// authorize only allow authenticated user to modify their own account,
+// admin may modify any account
+func (service userService) authorize(ctx context.Context, id string) error {
+	currentID, err := auth.UserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if currentID == id {
+		return nil
+	}
+	return service.requireAdmin(ctx)
+}
+
+// requireAdmin only allow authenticated admin
+func (service userService) requireAdmin(ctx context.Context) error {
+	currentUser, err := service.GetMe(ctx)
+	if err == user.ErrUserNotFound {
+		return auth.ErrUnauthenticated
+	}
+	if err != nil {
+		return err
+	}
+	if currentUser.Role != user.RoleAdmin {
+		return user.ErrPermissionDenied
+	}
+	return nil
 }
@@ -0,0 +1,324 @@
+package implementation_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/implementation"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/migration"
+)
+
+// fixture service over repositories of private in memory sqlite database,
+// ctx is scoped to tenant created in it and carries no token
+type fixture struct {
+	ctx     context.Context
+	sess    *dbr.Session
+	users   user.Repository
+	service user.Service
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	conn, err := repository.Open(repository.DriverSQLite, "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to in memory database opens empty one
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+	sess := conn.NewSession(nil)
+
+	ctx := context.Background()
+	migrator, err := migration.NewMigrator(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tenant := user.Tenant{ID: uuid.NewV4(), Slug: "acme", Name: "Acme", CreatedAt: time.Now()}
+	if err := repository.NewTenantRepository(sess, nil).CreateTenant(ctx, tenant); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fixture{
+		ctx:   user.ContextWithTenant(ctx, tenant.ID),
+		sess:  sess,
+		users: repository.NewUserRepository(sess, nil),
+	}
+	f.service = implementation.NewService(
+		f.users,
+		repository.NewOrganizationRepository(sess),
+		repository.NewSessionRepository(sess),
+		repository.NewAuditRepository(sess),
+		repository.NewWebhookRepository(sess),
+		repository.NewTransactor(sess),
+		"secret",
+		false,
+	)
+	return f
+}
+
+// register registers user of email with password "password" through
+// service and returns it as stored
+func (f *fixture) register(t *testing.T, email string) user.User {
+	t.Helper()
+	if _, err := f.service.Register(f.ctx, email, "password"); err != nil {
+		t.Fatalf("register %s: %v", email, err)
+	}
+	registered, err := f.users.Login(f.ctx, email, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *registered
+}
+
+// admin registers user of email promoted to admin
+func (f *fixture) admin(t *testing.T, email string) user.User {
+	t.Helper()
+	u := f.register(t, email)
+	if err := f.users.UpdateUser(f.ctx, u.ID, map[string]interface{}{"role": user.RoleAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	u.Role = user.RoleAdmin
+	return u
+}
+
+// as returns context of tenant authenticated as u
+func (f *fixture) as(u user.User) context.Context {
+	return context.WithValue(f.ctx, kitjwt.JWTClaimsContextKey, &auth.Claims{
+		TenantID: u.TenantID.String(),
+		StandardClaims: jwt.StandardClaims{
+			Id:      uuid.NewV4().String(),
+			Subject: u.ID.String(),
+		},
+	})
+}
+
+// expect fail test when err is not want
+func expect(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if err != want {
+		t.Fatalf("%s: err = %v, want %v", what, err, want)
+	}
+}
+
+func TestGetUserAuthorization(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+	root := f.admin(t, "root@example.com")
+
+	me, err := f.service.GetMe(f.as(ada))
+	if err != nil || me.ID != ada.ID {
+		t.Fatalf("get me: %+v, err %v", me, err)
+	}
+	_, err = f.service.GetMe(f.ctx)
+	expect(t, "get me without token", err, auth.ErrUnauthenticated)
+	_, err = f.service.GetUser(f.as(ada), ada.ID.String())
+	expect(t, "get own account", err, nil)
+	_, err = f.service.GetUser(f.as(ada), grace.ID.String())
+	expect(t, "get other account", err, user.ErrPermissionDenied)
+	_, err = f.service.GetUser(f.as(root), grace.ID.String())
+	expect(t, "admin get other account", err, nil)
+	_, err = f.service.GetUser(f.as(root), "not-an-id")
+	expect(t, "get invalid id", err, user.ErrInvalidUserID)
+}
+
+func TestUpdateUser(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+	rename := user.Update{Name: "Ada", Paths: []string{user.FieldName}}
+
+	updated, err := f.service.UpdateUser(f.as(ada), ada.ID.String(), rename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := f.users.GetUser(f.ctx, ada.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Returned user is the one stored
+	if updated.Name != rename.Name || updated.Name != stored.Name || !updated.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Fatalf("updated %+v, stored %+v", updated, stored)
+	}
+	if updated.Email != ada.Email {
+		t.Fatalf("email changed to %q by update of name", updated.Email)
+	}
+
+	_, err = f.service.UpdateUser(f.as(grace), ada.ID.String(), rename)
+	expect(t, "update other account", err, user.ErrPermissionDenied)
+	_, err = f.service.UpdateUser(f.as(ada), ada.ID.String(), user.Update{
+		Email: grace.Email,
+		Paths: []string{user.FieldEmail},
+	})
+	expect(t, "update to taken email", err, user.ErrEmailTaken)
+}
+
+func TestDeleteUser(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+
+	expect(t, "delete other account", f.service.DeleteUser(f.as(grace), ada.ID.String()), user.ErrPermissionDenied)
+	expect(t, "delete own account", f.service.DeleteUser(f.as(ada), ada.ID.String()), nil)
+	_, err := f.users.GetUser(f.ctx, ada.ID)
+	expect(t, "get deleted", err, user.ErrUserNotFound)
+}
+
+func TestListUsers(t *testing.T) {
+	f := newFixture(t)
+	root := f.admin(t, "root@example.com")
+	store := func(email string) {
+		now := time.Now()
+		err := f.users.Register(f.ctx, user.User{
+			ID:        uuid.NewV4(),
+			Email:     email,
+			Passwords: "hash",
+			Role:      user.RoleUser,
+			Status:    user.StatusActive,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"} {
+		store(email)
+	}
+
+	_, _, err := f.service.ListUsers(f.as(f.register(t, "ada@example.com")), user.ListFilter{}, "", 10, "")
+	expect(t, "list as user", err, user.ErrPermissionDenied)
+	_, _, err = f.service.ListUsers(f.as(root), user.ListFilter{}, "passwords", 10, "")
+	expect(t, "list by unknown field", err, user.ErrInvalidOrderBy)
+
+	// Users registered while paging come after pages already read
+	filter := user.ListFilter{Role: user.RoleUser}
+	var emails []string
+	token := ""
+	for pages := 0; pages < 5; pages++ {
+		page, next, err := f.service.ListUsers(f.as(root), filter, "email", 2, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range page {
+			emails = append(emails, u.Email)
+		}
+		if pages == 0 {
+			store("0@example.com")
+			store("e@example.com")
+		}
+		if token = next; token == "" {
+			break
+		}
+	}
+	want := []string{"a@example.com", "ada@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"}
+	if strings.Join(emails, " ") != strings.Join(want, " ") {
+		t.Fatalf("listed %v, want %v", emails, want)
+	}
+
+	_, _, err = f.service.ListUsers(f.as(root), user.ListFilter{}, "email", 2, user.EncodePageToken(user.Cursor{}))
+	expect(t, "list with token of other query", err, user.ErrInvalidPageToken)
+}
+
+func TestRestoreUser(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	root := f.admin(t, "root@example.com")
+	expect(t, "delete", f.service.DeleteUser(f.as(ada), ada.ID.String()), nil)
+	_, err := f.service.Login(f.ctx, ada.Email, "password", "")
+	expect(t, "login deleted", err, user.ErrUserNotFound)
+
+	grace := f.register(t, "grace@example.com")
+	_, err = f.service.RestoreUser(f.as(grace), ada.ID.String())
+	expect(t, "restore as user", err, user.ErrPermissionDenied)
+	restored, err := f.service.RestoreUser(f.as(root), ada.ID.String())
+	if err != nil || restored.DeletedAt != nil {
+		t.Fatalf("restore: %+v, err %v", restored, err)
+	}
+	if _, err := f.service.Login(f.ctx, ada.Email, "password", ""); err != nil {
+		t.Fatalf("login restored: %v", err)
+	}
+
+	// Deletions older than retention period are not restored
+	expired := time.Now().Add(-user.RetentionPeriod - time.Hour)
+	expect(t, "delete long ago", f.users.DeleteUser(f.ctx, ada.ID, expired), nil)
+	_, err = f.service.RestoreUser(f.as(root), ada.ID.String())
+	expect(t, "restore expired", err, user.ErrUserNotFound)
+}
+
+// statusHistory returns statuses user moved to in order of their changes
+func (f *fixture) statusHistory(t *testing.T, id uuid.UUID) []string {
+	t.Helper()
+	var statuses []string
+	_, err := f.sess.Select("to_status").
+		From("user_status_history").
+		Where("user_id = ?", id).
+		OrderAsc("created_at").
+		LoadContext(f.ctx, &statuses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return statuses
+}
+
+func TestLoginLockout(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	root := f.admin(t, "root@example.com")
+
+	for i := 0; i < user.MaxFailedLogins; i++ {
+		_, err := f.service.Login(f.ctx, ada.Email, "wrong", "")
+		expect(t, "login with wrong password", err, user.ErrInvalidCredentials)
+	}
+	_, err := f.service.Login(f.ctx, ada.Email, "password", "")
+	expect(t, "login of locked user", err, user.ErrAccountLocked)
+	if history := f.statusHistory(t, ada.ID); strings.Join(history, " ") != user.StatusLocked {
+		t.Fatalf("status history %v, want locked", history)
+	}
+
+	unlocked, err := f.service.ReactivateUser(f.as(root), ada.ID.String(), "verified by phone")
+	if err != nil || unlocked.Status != user.StatusActive || unlocked.FailedLogins != 0 {
+		t.Fatalf("reactivate: %+v, err %v", unlocked, err)
+	}
+	if _, err := f.service.Login(f.ctx, ada.Email, "password", ""); err != nil {
+		t.Fatalf("login of reactivated user: %v", err)
+	}
+}
+
+func TestLoginStatus(t *testing.T) {
+	f := newFixture(t)
+	ada := f.register(t, "ada@example.com")
+	root := f.admin(t, "root@example.com")
+
+	_, err := f.service.SuspendUser(f.as(ada), root.ID.String(), "")
+	expect(t, "suspend as user", err, user.ErrPermissionDenied)
+	_, err = f.service.SuspendUser(f.as(root), ada.ID.String(), "spam")
+	expect(t, "suspend", err, nil)
+	_, err = f.service.Login(f.ctx, ada.Email, "password", "")
+	expect(t, "login of suspended user", err, user.ErrAccountSuspended)
+	_, err = f.service.SuspendUser(f.as(root), ada.ID.String(), "spam")
+	expect(t, "suspend again", err, user.ErrStatusTransition)
+
+	_, err = f.service.DeactivateUser(f.as(root), ada.ID.String(), "closed")
+	expect(t, "deactivate", err, nil)
+	_, err = f.service.Login(f.ctx, ada.Email, "password", "")
+	expect(t, "login of deactivated user", err, user.ErrAccountDeactivated)
+
+	want := []string{user.StatusSuspended, user.StatusDeactivated}
+	if history := f.statusHistory(t, ada.ID); strings.Join(history, " ") != strings.Join(want, " ") {
+		t.Fatalf("status history %v, want %v", history, want)
+	}
+}
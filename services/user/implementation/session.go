@@ -0,0 +1,54 @@
+package implementation
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+)
+
+// ListSessions logic function, returns active sessions of current user
+func (service userService) ListSessions(
+	ctx context.Context,
+) ([]user.Session, error) {
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currentSessionID, err := auth.SessionIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := service.sessions.ListSessions(ctx, currentUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logic function, user may only revoke their own session
+func (service userService) RevokeSession(
+	ctx context.Context,
+	id string,
+) error {
+	sessionID, err := uuid.FromString(id)
+	if err != nil {
+		return user.ErrInvalidSessionID
+	}
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return err
+	}
+	return service.sessions.RevokeSession(
+		ctx,
+		currentUser.ID,
+		sessionID,
+		time.Now(),
+	)
+}
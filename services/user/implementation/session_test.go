@@ -0,0 +1,108 @@
+package implementation_test
+
+import (
+	"context"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository"
+)
+
+// login logs in user of email from client and returns context carrying
+// claims of token it got
+func (f *fixture) login(t *testing.T, email string, client user.Client) context.Context {
+	t.Helper()
+	token, err := f.service.Login(user.ContextWithClient(f.ctx, client), email, "password", "")
+	if err != nil {
+		t.Fatalf("login %s: %v", email, err)
+	}
+	claims := &auth.Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, auth.KeyFunc("secret")); err != nil {
+		t.Fatal(err)
+	}
+	return context.WithValue(f.ctx, kitjwt.JWTClaimsContextKey, claims)
+}
+
+func TestSessions(t *testing.T) {
+	f := newFixture(t)
+	f.register(t, "ada@example.com")
+	grace := f.register(t, "grace@example.com")
+	phone := f.login(t, "ada@example.com", user.Client{IP: "203.0.113.9", UserAgent: "Mozilla/5.0 (iPhone)"})
+	laptop := f.login(t, "ada@example.com", user.Client{IP: "198.51.100.1", UserAgent: "curl/7.68.0"})
+
+	sessions, err := f.service.ListSessions(phone)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("list sessions: %+v, err %v", sessions, err)
+	}
+	devices := map[string]user.Session{}
+	for _, session := range sessions {
+		devices[session.DeviceLabel] = session
+	}
+	iPhone, curl := devices["iPhone"], devices["curl"]
+	if !iPhone.Current || iPhone.IP != "203.0.113.9" || curl.Current || curl.IP != "198.51.100.1" {
+		t.Fatalf("sessions %+v", sessions)
+	}
+
+	expect(t, "revoke session of other user", f.service.RevokeSession(f.as(grace), curl.ID.String()), user.ErrSessionNotFound)
+	expect(t, "revoke invalid id", f.service.RevokeSession(phone, "laptop"), user.ErrInvalidSessionID)
+	expect(t, "revoke", f.service.RevokeSession(phone, curl.ID.String()), nil)
+
+	// Token of revoked session is refused
+	sessionMiddleware := auth.SessionMiddleware(repository.NewSessionRepository(f.sess))
+	call := sessionMiddleware(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, err = call(laptop, nil)
+	expect(t, "call with revoked session", err, user.ErrSessionRevoked)
+	_, err = call(phone, nil)
+	expect(t, "call with live session", err, nil)
+	_, err = call(f.as(grace), nil)
+	expect(t, "call with unknown session", err, auth.ErrUnauthenticated)
+}
+
+func TestSessionsRevokedWithCredentials(t *testing.T) {
+	f := newFixture(t)
+	f.register(t, "ada@example.com")
+	f.register(t, "grace@example.com")
+	root := f.admin(t, "root@example.com")
+	sessionMiddleware := auth.SessionMiddleware(repository.NewSessionRepository(f.sess))
+	call := sessionMiddleware(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+
+	phone := f.login(t, "ada@example.com", user.Client{})
+	laptop := f.login(t, "ada@example.com", user.Client{})
+	id, _ := auth.UserIDFromContext(phone)
+	expect(t, "change with wrong current password", f.service.ChangePassword(phone, id, "wrong", "new password"), user.ErrInvalidCredentials)
+	_, err := call(laptop, nil)
+	expect(t, "call after failed change", err, nil)
+	expect(t, "change password", f.service.ChangePassword(phone, id, "password", "new password"), nil)
+	for name, ctx := range map[string]context.Context{"phone": phone, "laptop": laptop} {
+		_, err := call(ctx, nil)
+		expect(t, "call from "+name+" after change", err, user.ErrSessionRevoked)
+	}
+	_, err = f.service.Login(f.ctx, "ada@example.com", "password", "")
+	expect(t, "login with old password", err, user.ErrInvalidCredentials)
+	_, err = f.service.Login(f.ctx, "ada@example.com", "new password", "")
+	expect(t, "login with new password", err, nil)
+
+	// Admin resets password without current one
+	grace := f.login(t, "grace@example.com", user.Client{})
+	graceID, _ := auth.UserIDFromContext(grace)
+	expect(t, "reset by admin", f.service.ChangePassword(f.as(root), graceID, "", "password"), nil)
+	_, err = call(grace, nil)
+	expect(t, "call after reset", err, user.ErrSessionRevoked)
+
+	// Lockout revokes sessions opened before it
+	grace = f.login(t, "grace@example.com", user.Client{})
+	for i := 0; i < user.MaxFailedLogins; i++ {
+		_, err := f.service.Login(f.ctx, "grace@example.com", "wrong", "")
+		expect(t, "login with wrong password", err, user.ErrInvalidCredentials)
+	}
+	_, err = call(grace, nil)
+	expect(t, "call after lockout", err, user.ErrSessionRevoked)
+}
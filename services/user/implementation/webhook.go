@@ -0,0 +1,106 @@
+package implementation
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// CreateWebhook logic function, returns webhook with its signing secret
+// which is not revealed again
+func (service userService) CreateWebhook(
+	ctx context.Context,
+	url string,
+	events []string,
+) (*user.Webhook, string, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, "", err
+	}
+	if !user.ValidWebhookURL(url) {
+		return nil, "", user.ErrInvalidWebhookURL
+	}
+	subscribed, err := user.ParseWebhookEvents(events)
+	if err != nil {
+		return nil, "", err
+	}
+	currentUser, err := service.GetMe(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := user.NewWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	webhook := user.Webhook{
+		ID:        uuid.NewV4(),
+		TenantID:  currentUser.TenantID,
+		URL:       url,
+		Events:    subscribed,
+		Secret:    secret,
+		CreatedBy: currentUser.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := service.webhooks.CreateWebhook(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+	return &webhook, secret, nil
+}
+
+// ListWebhooks logic function
+func (service userService) ListWebhooks(
+	ctx context.Context,
+) ([]user.Webhook, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return service.webhooks.ListWebhooks(ctx)
+}
+
+// DeleteWebhook logic function
+func (service userService) DeleteWebhook(
+	ctx context.Context,
+	id string,
+) error {
+	if err := service.requireAdmin(ctx); err != nil {
+		return err
+	}
+	webhookID, err := uuid.FromString(id)
+	if err != nil {
+		return user.ErrInvalidWebhookID
+	}
+	return service.webhooks.DeleteWebhook(ctx, webhookID)
+}
+
+// ListWebhookDeliveries logic function, returns latest deliveries of
+// webhook optionally filtered by status
+func (service userService) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID, status string,
+) ([]user.WebhookDelivery, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, err := uuid.FromString(webhookID)
+	if err != nil {
+		return nil, user.ErrInvalidWebhookID
+	}
+	return service.webhooks.ListDeliveries(ctx, id, status, user.MaxPageSize)
+}
+
+// RedeliverWebhook logic function, schedules delivery for immediate attempt
+func (service userService) RedeliverWebhook(
+	ctx context.Context,
+	deliveryID string,
+) (*user.WebhookDelivery, error) {
+	if err := service.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, err := uuid.FromString(deliveryID)
+	if err != nil {
+		return nil, user.ErrInvalidDeliveryID
+	}
+	return service.webhooks.Redeliver(ctx, id, time.Now())
+}
@@ -0,0 +1,155 @@
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Page size bounds for listing users
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ListFilter filtering options for listing users, zero value means unfiltered
+type ListFilter struct {
+	EmailPrefix   string
+	Status        string
+	Role          string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Sort ordering by single field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ListQuery query for listing users, After is nil on first page
+type ListQuery struct {
+	Filter   ListFilter
+	OrderBy  []Sort
+	PageSize int
+	After    *Cursor
+}
+
+// Cursor position of last user on previous page, values follow
+// OrderBy fields and id is used as tie breaker
+type Cursor struct {
+	Fingerprint string   `json:"f"`
+	Values      []string `json:"v"`
+	ID          string   `json:"i"`
+}
+
+// ParseOrderBy parse comma separated order clause, e.g. "created_at desc, email"
+func ParseOrderBy(orderBy string) ([]Sort, error) {
+	var sorts []Sort
+	if strings.TrimSpace(orderBy) == "" {
+		return []Sort{{Field: "created_at"}}, nil
+	}
+	for _, clause := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(clause)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, ErrInvalidOrderBy
+		}
+		sort := Sort{Field: parts[0]}
+		if len(parts) == 2 {
+			switch strings.ToLower(parts[1]) {
+			case "asc":
+			case "desc":
+				sort.Desc = true
+			default:
+				return nil, ErrInvalidOrderBy
+			}
+		}
+		switch sort.Field {
+		case "email", "name", "created_at", "updated_at":
+		default:
+			return nil, ErrInvalidOrderBy
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// SortValue returns value of user sortable field
+func (u User) SortValue(field string) interface{} {
+	switch field {
+	case "email":
+		return u.Email
+	case "name":
+		return u.Name
+	case "created_at":
+		return u.CreatedAt
+	case "updated_at":
+		return u.UpdatedAt
+	default:
+		return nil
+	}
+}
+
+// Fingerprint identify filter and ordering a cursor belongs to
+func (query ListQuery) Fingerprint() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v|%+v", query.Filter, query.OrderBy)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewCursor create cursor positioned after user
+func NewCursor(query ListQuery, last User) Cursor {
+	cursor := Cursor{
+		Fingerprint: query.Fingerprint(),
+		ID:          last.ID.String(),
+	}
+	for _, sort := range query.OrderBy {
+		value := last.SortValue(sort.Field)
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		cursor.Values = append(cursor.Values, fmt.Sprint(value))
+	}
+	return cursor
+}
+
+// Value returns typed cursor value of i-th order field
+func (cursor Cursor) Value(i int, field string) (interface{}, error) {
+	switch field {
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, cursor.Values[i])
+	default:
+		return cursor.Values[i], nil
+	}
+}
+
+// EncodePageToken encode cursor into opaque page token
+func EncodePageToken(cursor Cursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePageToken decode opaque page token for query
+func DecodePageToken(token string, query ListQuery) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	if cursor.Fingerprint != query.Fingerprint() ||
+		len(cursor.Values) != len(query.OrderBy) {
+		return nil, ErrInvalidPageToken
+	}
+	for i, sort := range query.OrderBy {
+		if _, err := cursor.Value(i, sort.Field); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+	}
+	return &cursor, nil
+}
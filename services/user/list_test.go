@@ -0,0 +1,72 @@
+package user_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	for orderBy, want := range map[string][]user.Sort{
+		"":                          {{Field: "created_at"}},
+		"email":                     {{Field: "email"}},
+		"created_at desc, email":    {{Field: "created_at", Desc: true}, {Field: "email"}},
+		" name ASC,updated_at DESC": {{Field: "name"}, {Field: "updated_at", Desc: true}},
+	} {
+		sorts, err := user.ParseOrderBy(orderBy)
+		if err != nil || !reflect.DeepEqual(sorts, want) {
+			t.Errorf("%q: %v, err %v, want %v", orderBy, sorts, err, want)
+		}
+	}
+	for _, orderBy := range []string{"passwords", "email sideways", "email desc extra", "email,", "id; drop table users"} {
+		if _, err := user.ParseOrderBy(orderBy); err != user.ErrInvalidOrderBy {
+			t.Errorf("%q: err = %v, want invalid order by", orderBy, err)
+		}
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	orderBy, err := user.ParseOrderBy("created_at desc, email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := user.ListQuery{Filter: user.ListFilter{Role: user.RoleUser}, OrderBy: orderBy, PageSize: 10}
+	last := user.User{
+		ID:        uuid.NewV4(),
+		Email:     "ada@example.com",
+		CreatedAt: time.Date(2020, 1, 1, 0, 0, 0, 123456789, time.UTC),
+	}
+	token := user.EncodePageToken(user.NewCursor(query, last))
+
+	cursor, err := user.DecodePageToken(token, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdAt, err := cursor.Value(0, "created_at")
+	if err != nil || !createdAt.(time.Time).Equal(last.CreatedAt) {
+		t.Fatalf("created at %v, err %v, want %v", createdAt, err, last.CreatedAt)
+	}
+	if email, _ := cursor.Value(1, "email"); email != last.Email || cursor.ID != last.ID.String() {
+		t.Fatalf("cursor %+v does not point at %s", cursor, last.Email)
+	}
+
+	// Token is refused by query of other filter or ordering
+	otherFilter := query
+	otherFilter.Filter.Role = user.RoleAdmin
+	otherOrder := query
+	otherOrder.OrderBy = orderBy[:1]
+	for name, q := range map[string]user.ListQuery{"filter": otherFilter, "order": otherOrder} {
+		if _, err := user.DecodePageToken(token, q); err != user.ErrInvalidPageToken {
+			t.Errorf("other %s: err = %v, want invalid page token", name, err)
+		}
+	}
+	for _, garbage := range []string{"!", "bm90IGpzb24", token + "x"} {
+		if _, err := user.DecodePageToken(garbage, query); err != user.ErrInvalidPageToken {
+			t.Errorf("%q: err = %v, want invalid page token", garbage, err)
+		}
+	}
+}
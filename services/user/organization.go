@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Organization roles, ordered from most to least privileged
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// InvitationDuration how long invitation token can be accepted
+const InvitationDuration = 7 * 24 * time.Hour
+
+// orgRoleRanks privilege rank of organization roles
+var orgRoleRanks = map[string]int{
+	OrgRoleOwner:  3,
+	OrgRoleAdmin:  2,
+	OrgRoleMember: 1,
+}
+
+// Organization model struct
+type Organization struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	Name      string    `json:"name"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Membership model struct, role is scoped to organization
+type Membership struct {
+	TenantID       uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	OrganizationID uuid.UUID `json:"organization_id" db:"organization_id"`
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Invitation model struct, only hash of token is stored
+type Invitation struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	TenantID       uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	OrganizationID uuid.UUID `json:"organization_id" db:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	TokenHash      string    `json:"-"`
+	Status         string    `json:"status"`
+	InvitedBy      uuid.UUID `json:"invited_by"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OrganizationRepository interface for organization, every query is scoped
+// to tenant of context
+type OrganizationRepository interface {
+	CreateOrganization(ctx context.Context, organization Organization, owner Membership) error
+	GetOrganization(ctx context.Context, id uuid.UUID) (*Organization, error)
+	ListOrganizations(ctx context.Context, userID uuid.UUID) ([]Organization, error)
+	GetMembership(ctx context.Context, organizationID, userID uuid.UUID) (*Membership, error)
+	ListMembers(ctx context.Context, organizationID uuid.UUID) ([]Membership, error)
+	LockOwners(ctx context.Context, organizationID uuid.UUID) (int, error)
+	ChangeMemberRole(ctx context.Context, organizationID, userID uuid.UUID, role string) error
+	RemoveMember(ctx context.Context, organizationID, userID uuid.UUID) error
+	CreateInvitation(ctx context.Context, invitation Invitation) error
+	GetInvitation(ctx context.Context, tokenHash string) (*Invitation, error)
+	AcceptInvitation(ctx context.Context, invitation Invitation, member Membership) error
+	DeclineInvitation(ctx context.Context, id uuid.UUID) error
+}
+
+// ValidOrgRole check whether role is known organization role
+func ValidOrgRole(role string) bool {
+	_, ok := orgRoleRanks[role]
+	return ok
+}
+
+// OrgRoleAtLeast check whether role is as privileged as minimum role
+func OrgRoleAtLeast(role, minimum string) bool {
+	return orgRoleRanks[role] >= orgRoleRanks[minimum]
+}
+
+// NewInvitationToken generate random invitation token and its hash
+func NewInvitationToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashInvitationToken(token), nil
+}
+
+// HashInvitationToken hash invitation token for storage and lookup
+func HashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
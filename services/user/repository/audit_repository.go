@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// appendAttempts times event is chained again after another process took
+// its sequence
+const appendAttempts = 5
+
+type auditRepository struct {
+	Session *dbr.Session
+	// mutex serializes appends of this process so they do not race for
+	// sequence, appends racing from other processes are retried
+	mutex sync.Mutex
+}
+
+// NewAuditRepository create instances of audit repo struct
+func NewAuditRepository(sess *dbr.Session) user.AuditRepository {
+	return &auditRepository{
+		Session: sess,
+	}
+}
+
+// AppendAuditEvent database query logic, chains event to last event of its
+// tenant and inserts it. Sequence is shared by tenants, event losing it to
+// another process is chained again
+func (repo *auditRepository) AppendAuditEvent(
+	ctx context.Context,
+	event user.AuditEvent,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	event.TenantID = tenantID
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for attempt := 1; ; attempt++ {
+		err = repo.append(ctx, event)
+		if !isDuplicate(err) || attempt == appendAttempts {
+			return err
+		}
+	}
+}
+
+// append chain event to last event of its tenant and insert it in own
+// transaction even within unit of work, event of failed call must not be
+// rolled back along with it. Last event overall and of tenant are read in
+// one statement so they come from one snapshot, sequence taken meanwhile
+// fails insert on primary key rather than forking chain
+func (repo *auditRepository) append(ctx context.Context, event user.AuditEvent) error {
+	tx, err := repo.Session.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	var last []user.AuditEvent
+	_, err = tx.Select("sequence", "tenant_id", "hash").
+		From("audit_events").
+		Where(
+			"sequence = (SELECT MAX(sequence) FROM audit_events) OR "+
+				"sequence = (SELECT MAX(sequence) FROM audit_events WHERE tenant_id = ?)",
+			event.TenantID,
+		).
+		LoadContext(ctx, &last)
+	if err != nil {
+		return err
+	}
+	event.Sequence = 1
+	event.PrevHash = ""
+	for _, previous := range last {
+		if previous.Sequence >= event.Sequence {
+			event.Sequence = previous.Sequence + 1
+		}
+		if previous.TenantID == event.TenantID {
+			event.PrevHash = previous.Hash
+		}
+	}
+	event.Hash = event.ChainHash()
+
+	_, err = tx.InsertInto("audit_events").
+		Columns(
+			"sequence", "tenant_id", "actor_id", "target", "action",
+			"outcome", "reason", "ip", "user_agent", "request_id",
+			"created_at", "prev_hash", "hash",
+		).
+		Record(event).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListAuditEvents database query logic, returns up to page size plus one
+// events newest first
+func (repo *auditRepository) ListAuditEvents(
+	ctx context.Context,
+	query user.AuditQuery,
+) ([]user.AuditEvent, error) {
+	var events []user.AuditEvent
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := primary(ctx, repo.Session).Select("*").
+		From("audit_events").
+		Where("tenant_id = ?", tenantID)
+	filter := query.Filter
+	if filter.ActorID != "" {
+		stmt = stmt.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Target != "" {
+		stmt = stmt.Where("target = ?", filter.Target)
+	}
+	if filter.Action != "" {
+		stmt = stmt.Where("action = ?", filter.Action)
+	}
+	if filter.Outcome != "" {
+		stmt = stmt.Where("outcome = ?", filter.Outcome)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		stmt = stmt.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		stmt = stmt.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if query.Before > 0 {
+		stmt = stmt.Where("sequence < ?", query.Before)
+	}
+	_, err = stmt.OrderDesc("sequence").
+		Limit(uint64(query.PageSize+1)).
+		LoadContext(ctx, &events)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestAuditChain(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewAuditRepository(sess)
+	other := user.ContextWithTenant(context.Background(), uuid.NewV4())
+	// Events of tenants interleave in sequence, each tenant has chain of its own
+	for i, eventCtx := range []context.Context{ctx, other, ctx, other, ctx} {
+		err := repo.AppendAuditEvent(eventCtx, user.AuditEvent{
+			ActorID:   "actor",
+			Target:    "target",
+			Action:    user.AuditLogin,
+			Outcome:   user.AuditSuccess,
+			Reason:    string(rune('a' + i)),
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func() []user.AuditEvent {
+		t.Helper()
+		events, err := repo.ListAuditEvents(ctx, user.AuditQuery{PageSize: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+
+	events := list()
+	if len(events) != 3 {
+		t.Fatalf("listed %d events, want 3 of tenant", len(events))
+	}
+	if err := user.VerifyAuditChain(events); err != nil {
+		t.Fatal(err)
+	}
+	if oldest := events[len(events)-1]; oldest.PrevHash != "" {
+		t.Fatalf("first event of tenant chained to %q", oldest.PrevHash)
+	}
+
+	_, err := sess.Update("audit_events").
+		Set("outcome", user.AuditFailure).
+		Where("sequence = ?", events[1].Sequence).
+		ExecContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := user.VerifyAuditChain(list()); !errors.Is(err, user.ErrAuditChainBroken) {
+		t.Fatalf("altered event: err = %v, want broken chain", err)
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Database drivers repositories run on, values are names drivers are
+// registered with in database/sql and the ones DB_DRIVER accepts
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+	// DriverMemory keeps users in memory, the rest of repositories run on
+	// private in memory sqlite database. Users kept in memory write no
+	// events to outbox
+	DriverMemory = "memory"
+)
+
+// ErrUnknownDriver returned when driver is none of supported ones
+var ErrUnknownDriver = errors.New("unknown database driver")
+
+// dialects placeholders and value encoding of each driver, uuids are
+// stored in their text form on all of them
+var dialects = map[string]dbr.Dialect{
+	DriverMySQL:    dialect.MySQL,
+	DriverPostgres: dialect.PostgreSQL,
+	DriverSQLite:   dialect.SQLite3,
+}
+
+// Open create connection of driver with its dialect. Mysql reports rows
+// matched by updates instead of rows changed, as other drivers do, so
+// update leaving row as it was still finds it
+func Open(driver, dsn string) (*dbr.Connection, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+	if driver == DriverMySQL {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientFoundRows = true
+		dsn = cfg.FormatDSN()
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &dbr.Connection{
+		DB:            db,
+		EventReceiver: &dbr.NullEventReceiver{},
+		Dialect:       d,
+	}, nil
+}
+
+// isDuplicate returns whether err is unique or primary key violation of any
+// supported driver
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_DUP_ENTRY
+		return mysqlErr.Number == 1062
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/migration"
+)
+
+// openSQLite open private in memory sqlite database with migrated schema,
+// returned context is scoped to tenant created in it
+func openSQLite(t *testing.T) (*dbr.Session, context.Context) {
+	t.Helper()
+	conn, err := Open(DriverSQLite, "file::memory:?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to in memory database opens empty one
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+	sess := conn.NewSession(nil)
+
+	ctx := context.Background()
+	migrator, err := migration.NewMigrator(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tenant := user.Tenant{ID: uuid.NewV4(), Slug: "acme", Name: "Acme", CreatedAt: time.Now()}
+	if err := NewTenantRepository(sess, nil).CreateTenant(ctx, tenant); err != nil {
+		t.Fatal(err)
+	}
+	return sess, user.ContextWithTenant(ctx, tenant.ID)
+}
+
+func TestIsDuplicate(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	insert := func(id uuid.UUID, slug interface{}) error {
+		_, err := sess.InsertInto("tenants").
+			Pair("id", id).
+			Pair("slug", slug).
+			Pair("name", "Tenant").
+			Pair("created_at", time.Now()).
+			ExecContext(ctx)
+		return err
+	}
+	id := uuid.NewV4()
+	if err := insert(id, "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		duplicate bool
+	}{
+		{"primary key", insert(id, "second"), true},
+		{"unique", insert(uuid.NewV4(), "first"), true},
+		{"not null", insert(uuid.NewV4(), nil), false},
+		{"no error", nil, false},
+	}
+	for _, tt := range tests {
+		if tt.duplicate && tt.err == nil {
+			t.Fatalf("%s: insert succeeded", tt.name)
+		}
+		if got := isDuplicate(tt.err); got != tt.duplicate {
+			t.Errorf("%s: isDuplicate(%v) = %v, want %v", tt.name, tt.err, got, tt.duplicate)
+		}
+	}
+}
+
+func TestDialectPlaceholders(t *testing.T) {
+	id := uuid.FromStringOrNil("5b0b9c5e-8a8f-4a52-9b3c-0ce3c3f4e2a1")
+	tests := []struct {
+		driver string
+		want   string
+	}{
+		{DriverMySQL, `id = '5b0b9c5e-8a8f-4a52-9b3c-0ce3c3f4e2a1' AND email = 'o\'hara@example.com'`},
+		{DriverPostgres, `id = '5b0b9c5e-8a8f-4a52-9b3c-0ce3c3f4e2a1' AND email = 'o''hara@example.com'`},
+		{DriverSQLite, `id = '5b0b9c5e-8a8f-4a52-9b3c-0ce3c3f4e2a1' AND email = 'o''hara@example.com'`},
+	}
+	for _, tt := range tests {
+		got, err := dbr.InterpolateForDialect(
+			"id = ? AND email = ?",
+			[]interface{}{id, "o'hara@example.com"},
+			dialects[tt.driver],
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.driver, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.driver, got, tt.want)
+		}
+	}
+}
+
+func TestUUIDRoundTrip(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	tenantID, _ := user.TenantFromContext(ctx)
+	repo := NewUserRepository(sess, nil)
+	registered := newUser("ada@example.com", time.Now())
+	if err := repo.Register(ctx, registered); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := repo.GetUser(ctx, registered.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != registered.ID || found.TenantID != tenantID {
+		t.Fatalf("got id %s of tenant %s, want %s of %s", found.ID, found.TenantID, registered.ID, tenantID)
+	}
+	var ids []uuid.UUID
+	_, err = sess.Select("id").
+		From("users").
+		Where("id IN ?", []uuid.UUID{registered.ID, uuid.NewV4()}).
+		LoadContext(ctx, &ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != registered.ID {
+		t.Fatalf("ids = %v, want [%s]", ids, registered.ID)
+	}
+}
@@ -0,0 +1,379 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// repository keeps users in memory with the semantics of sql repository,
+// email is unique within tenant including soft deleted users. Changes are
+// not part of unit of work so failed calls do not roll them back. Unlike
+// sql repository it writes no UserRegistered, UserDeleted nor
+// PasswordChanged events to outbox, so in memory mode webhooks and relay
+// never see user changes
+type repository struct {
+	mutex   sync.RWMutex
+	users   map[uuid.UUID]user.User
+	history []user.StatusChange
+}
+
+// NewUserRepository create instances of repo struct
+func NewUserRepository() user.Repository {
+	return &repository{
+		users: map[uuid.UUID]user.User{},
+	}
+}
+
+// Register stores user unless email is taken within tenant
+func (repo *repository) Register(
+	ctx context.Context,
+	newUser user.User,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	newUser.TenantID = tenantID
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	if _, ok := repo.users[newUser.ID]; ok || repo.emailTaken(tenantID, newUser.Email) {
+		return user.ErrEmailTaken
+	}
+	repo.users[newUser.ID] = newUser
+	return nil
+}
+
+// Login returns user of email
+func (repo *repository) Login(
+	ctx context.Context,
+	email, _ string,
+) (*user.User, error) {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	for _, u := range repo.users {
+		if u.TenantID == tenantID && u.Email == email && u.DeletedAt == nil {
+			return &u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+// GetUser returns user of id
+func (repo *repository) GetUser(
+	ctx context.Context,
+	id uuid.UUID,
+) (*user.User, error) {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt != nil {
+		return nil, user.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// UpdateUser updates only given columns of user
+func (repo *repository) UpdateUser(
+	ctx context.Context,
+	id uuid.UUID,
+	columns map[string]interface{},
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt != nil {
+		return user.ErrUserNotFound
+	}
+	for column, value := range columns {
+		switch column {
+		case "email":
+			email, _ := value.(string)
+			if email != u.Email && repo.emailTaken(tenantID, email) {
+				return user.ErrEmailTaken
+			}
+			u.Email = email
+		case "name":
+			u.Name, _ = value.(string)
+		case "bio":
+			u.Bio, _ = value.(string)
+		case "updated_at":
+			u.UpdatedAt, _ = value.(time.Time)
+		case "failed_logins":
+			u.FailedLogins, _ = value.(int)
+		default:
+			return fmt.Errorf("unknown column %q", column)
+		}
+	}
+	repo.users[id] = u
+	return nil
+}
+
+// ChangePassword replaces password hash of user
+func (repo *repository) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	hashedPassword, _ string,
+	changedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt != nil {
+		return user.ErrUserNotFound
+	}
+	u.Passwords = hashedPassword
+	u.UpdatedAt = changedAt
+	repo.users[id] = u
+	return nil
+}
+
+// DeleteUser marks user as soft deleted
+func (repo *repository) DeleteUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt != nil {
+		return user.ErrUserNotFound
+	}
+	u.DeletedAt = &deletedAt
+	repo.users[id] = u
+	return nil
+}
+
+// RestoreUser clears soft delete made after given time
+func (repo *repository) RestoreUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAfter time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt == nil || u.DeletedAt.Before(deletedAfter) {
+		return user.ErrUserNotFound
+	}
+	u.DeletedAt = nil
+	repo.users[id] = u
+	return nil
+}
+
+// PurgeUsers hard deletes users soft deleted before given time across all
+// tenants
+func (repo *repository) PurgeUsers(
+	_ context.Context,
+	deletedBefore time.Time,
+) (int64, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	var purgedUsers int64
+	for id, u := range repo.users {
+		if u.DeletedAt != nil && u.DeletedAt.Before(deletedBefore) {
+			delete(repo.users, id)
+			purgedUsers++
+		}
+	}
+	return purgedUsers, nil
+}
+
+// ChangeStatus updates user status only when it is still in previous status
+// and records the change into history, failed logins start over
+func (repo *repository) ChangeStatus(
+	ctx context.Context,
+	change user.StatusChange,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, change.UserID)
+	if !ok || u.DeletedAt != nil || u.Status != change.FromStatus {
+		return user.ErrStatusTransition
+	}
+	u.Status = change.ToStatus
+	u.FailedLogins = 0
+	u.UpdatedAt = change.CreatedAt
+	repo.users[u.ID] = u
+	repo.history = append(repo.history, change)
+	return nil
+}
+
+// FailLogin counts failed login of user and returns failed logins since last
+// successful one
+func (repo *repository) FailLogin(
+	ctx context.Context,
+	id uuid.UUID,
+) (int, error) {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	u, ok := repo.find(tenantID, id)
+	if !ok || u.DeletedAt != nil {
+		return 0, user.ErrUserNotFound
+	}
+	u.FailedLogins++
+	repo.users[id] = u
+	return u.FailedLogins, nil
+}
+
+// ListUsers returns up to page size plus one users in order of query
+func (repo *repository) ListUsers(
+	ctx context.Context,
+	query user.ListQuery,
+) ([]user.User, error) {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var after []interface{}
+	if query.After != nil {
+		for i, s := range query.OrderBy {
+			value, err := query.After.Value(i, s.Field)
+			if err != nil {
+				return nil, err
+			}
+			after = append(after, value)
+		}
+	}
+
+	repo.mutex.RLock()
+	var users []user.User
+	for _, u := range repo.users {
+		if u.TenantID != tenantID || u.DeletedAt != nil || !matches(u, query.Filter) {
+			continue
+		}
+		if query.After != nil && !isAfter(u, query, after) {
+			continue
+		}
+		users = append(users, u)
+	}
+	repo.mutex.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		for _, s := range query.OrderBy {
+			if c := compare(users[i].SortValue(s.Field), users[j].SortValue(s.Field)); c != 0 {
+				return (c < 0) != s.Desc
+			}
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+	if len(users) > query.PageSize+1 {
+		users = users[:query.PageSize+1]
+	}
+	return users, nil
+}
+
+// isAfter tells whether user goes after cursor values in order of query,
+// id breaks the tie same as in sql keyset condition
+func isAfter(u user.User, query user.ListQuery, after []interface{}) bool {
+	for i, s := range query.OrderBy {
+		c := compare(u.SortValue(s.Field), after[i])
+		if s.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c > 0
+		}
+	}
+	return u.ID.String() > query.After.ID
+}
+
+// matches tells whether user passes filter
+func matches(u user.User, filter user.ListFilter) bool {
+	return strings.HasPrefix(u.Email, filter.EmailPrefix) &&
+		(filter.Status == "" || u.Status == filter.Status) &&
+		(filter.Role == "" || u.Role == filter.Role) &&
+		(filter.CreatedAfter.IsZero() || !u.CreatedAt.Before(filter.CreatedAfter)) &&
+		(filter.CreatedBefore.IsZero() || u.CreatedAt.Before(filter.CreatedBefore))
+}
+
+// compare sort values of same field, returns negative when a goes first
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case time.Time:
+		b, _ := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+// find returns user of id within tenant, soft deleted ones included
+func (repo *repository) find(tenantID, id uuid.UUID) (user.User, bool) {
+	u, ok := repo.users[id]
+	if !ok || u.TenantID != tenantID {
+		return user.User{}, false
+	}
+	return u, true
+}
+
+// emailTaken tells whether any user of tenant has email, caller must hold
+// the lock
+func (repo *repository) emailTaken(tenantID uuid.UUID, email string) bool {
+	for _, u := range repo.users {
+		if u.TenantID == tenantID && u.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// scope returns tenant id of context every query must be filtered by
+func scope(ctx context.Context) (uuid.UUID, error) {
+	return user.TenantFromContext(ctx)
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/memory"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/repositorytest"
+)
+
+func TestUserRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(*testing.T) user.Repository {
+		return memory.NewUserRepository()
+	})
+}
@@ -0,0 +1,56 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gocraft/dbr/v2/dialect"
+)
+
+// Advisory lock held while migrating, name on mysql and key on postgresql
+const (
+	lockName = "user_service_migrate"
+	lockKey  = 7419354201
+)
+
+// ErrLockNotAcquired returned when database refused advisory lock
+var ErrLockNotAcquired = errors.New("migration lock was not acquired")
+
+// lock acquire advisory lock on connection of its own, waiting while other
+// process holds it. Lock belongs to the connection so it is closed once lock
+// is released. Sqlite has no advisory locks, its writers are serialized by
+// lock of database file already
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if m.session.Dialect != dialect.MySQL && m.session.Dialect != dialect.PostgreSQL {
+		return func() {}, nil
+	}
+	conn, err := m.session.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	release := func() {
+		if m.session.Dialect == dialect.MySQL {
+			_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+		} else {
+			_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+		}
+		_ = conn.Close()
+	}
+	if m.session.Dialect == dialect.MySQL {
+		// Negative timeout waits for as long as lock is held
+		var acquired sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", lockName).Scan(&acquired)
+		if err == nil && acquired.Int64 != 1 {
+			err = ErrLockNotAcquired
+		}
+	} else {
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return release, nil
+}
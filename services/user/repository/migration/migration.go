@@ -0,0 +1,333 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+)
+
+// HistoryTable records applied migrations with checksum of their up
+// statements
+const HistoryTable = "schema_migrations"
+
+// Migration errors
+var (
+	ErrUnsupportedDialect = errors.New("migrations are not defined for database dialect")
+	ErrChecksumMismatch   = errors.New("applied migration was modified")
+	ErrUnknownMigration   = errors.New("applied migration is unknown to this build")
+	ErrNothingApplied     = errors.New("no migration is applied")
+)
+
+// Migration versioned schema change, statements run one by one since not
+// every driver accepts several in single exec
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// Checksum of up statements, applied migration must keep it
+func (migration Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(strings.Join(migration.Up, ";\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Migration states reported by status
+const (
+	StatePending  = "pending"
+	StateApplied  = "applied"
+	StateModified = "modified"
+	StateUnknown  = "unknown"
+)
+
+// Status state of migration in database
+type Status struct {
+	Version   int
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
+
+// applied history row of applied migration
+type applied struct {
+	Version   int       `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// schema migrations of one dialect and statement creating history table
+type schema struct {
+	history    string
+	migrations []Migration
+}
+
+// Migrator applies migrations of session dialect, changes hold advisory lock
+// so processes migrating at the same time run one after another
+type Migrator struct {
+	session *dbr.Session
+	schema  schema
+}
+
+// NewMigrator create migrator of session dialect
+func NewMigrator(sess *dbr.Session) (*Migrator, error) {
+	var s schema
+	switch sess.Dialect {
+	case dialect.MySQL:
+		s = mysqlSchema
+	case dialect.PostgreSQL:
+		s = postgresSchema
+	case dialect.SQLite3:
+		s = sqliteSchema
+	default:
+		return nil, ErrUnsupportedDialect
+	}
+	return &Migrator{
+		session: sess,
+		schema:  s,
+	}, nil
+}
+
+// Up apply pending migrations in version order, returns applied ones.
+// Migrations applied by newer build are left alone, modified ones stop it
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(history map[int]applied) error {
+		if err := m.verify(history, false); err != nil {
+			return err
+		}
+		for _, migration := range m.schema.migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down revert last steps applied migrations in reverse version order,
+// returns reverted ones
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(history map[int]applied) error {
+		if err := m.verify(history, true); err != nil {
+			return err
+		}
+		if len(history) == 0 {
+			return ErrNothingApplied
+		}
+		for i := len(m.schema.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.schema.migrations[i]
+			if _, ok := history[migration.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Redo revert last applied migration and apply it again
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var done *Migration
+	err := m.locked(ctx, func(history map[int]applied) error {
+		if err := m.verify(history, true); err != nil {
+			return err
+		}
+		for i := len(m.schema.migrations) - 1; i >= 0; i-- {
+			migration := m.schema.migrations[i]
+			if _, ok := history[migration.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, migration); err != nil {
+				return err
+			}
+			if err := m.apply(ctx, migration); err != nil {
+				return err
+			}
+			done = &migration
+			return nil
+		}
+		return ErrNothingApplied
+	})
+	return done, err
+}
+
+// Status returns state of every known migration followed by applied ones
+// this build does not know
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createHistory(ctx); err != nil {
+		return nil, err
+	}
+	history, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	for _, migration := range m.schema.migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			State:   StatePending,
+		}
+		if row, ok := history[migration.Version]; ok {
+			status.State = StateApplied
+			if row.Checksum != migration.Checksum() {
+				status.State = StateModified
+			}
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(history, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	var unknown []Status
+	for _, row := range history {
+		appliedAt := row.AppliedAt
+		unknown = append(unknown, Status{
+			Version:   row.Version,
+			Name:      row.Name,
+			State:     StateUnknown,
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+	return append(statuses, unknown...), nil
+}
+
+// locked run fn with history of applied migrations while holding lock,
+// history table is created first when missing
+func (m *Migrator) locked(
+	ctx context.Context,
+	fn func(history map[int]applied) error,
+) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := m.createHistory(ctx); err != nil {
+		return err
+	}
+	history, err := m.history(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(history)
+}
+
+// verify applied migrations were not modified since, reverting also needs
+// every applied migration known to have its down statements
+func (m *Migrator) verify(history map[int]applied, reverting bool) error {
+	known := make(map[int]bool, len(m.schema.migrations))
+	for _, migration := range m.schema.migrations {
+		known[migration.Version] = true
+		row, ok := history[migration.Version]
+		if ok && row.Checksum != migration.Checksum() {
+			return fmt.Errorf("%w: %d %s", ErrChecksumMismatch, row.Version, row.Name)
+		}
+	}
+	if !reverting {
+		return nil
+	}
+	for version, row := range history {
+		if !known[version] {
+			return fmt.Errorf("%w: %d %s", ErrUnknownMigration, row.Version, row.Name)
+		}
+	}
+	return nil
+}
+
+// apply run up statements of migration and record it in one transaction,
+// mysql commits schema changes implicitly so failed migration there may be
+// left half applied
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	return m.transaction(ctx, migration, migration.Up, func(tx *dbr.Tx) error {
+		_, err := tx.InsertInto(HistoryTable).
+			Pair("version", migration.Version).
+			Pair("name", migration.Name).
+			Pair("checksum", migration.Checksum()).
+			Pair("applied_at", time.Now().UTC()).
+			ExecContext(ctx)
+		return err
+	})
+}
+
+// revert run down statements of migration and remove it from history in one
+// transaction
+func (m *Migrator) revert(ctx context.Context, migration Migration) error {
+	return m.transaction(ctx, migration, migration.Down, func(tx *dbr.Tx) error {
+		_, err := tx.DeleteFrom(HistoryTable).
+			Where("version = ?", migration.Version).
+			ExecContext(ctx)
+		return err
+	})
+}
+
+// transaction run statements then record in transaction, error tells
+// migration it failed on
+func (m *Migrator) transaction(
+	ctx context.Context,
+	migration Migration,
+	statements []string,
+	record func(tx *dbr.Tx) error,
+) error {
+	tx, err := m.session.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createHistory create history table unless it exists
+func (m *Migrator) createHistory(ctx context.Context) error {
+	_, err := m.session.ExecContext(ctx, m.schema.history)
+	return err
+}
+
+// history returns applied migrations by version
+func (m *Migrator) history(ctx context.Context) (map[int]applied, error) {
+	var rows []applied
+	_, err := m.session.Select("version", "name", "checksum", "applied_at").
+		From(HistoryTable).
+		LoadContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	history := make(map[int]applied, len(rows))
+	for _, row := range rows {
+		history[row.Version] = row
+	}
+	return history, nil
+}
@@ -0,0 +1,262 @@
+package migration
+
+// mysqlSchema uuids are stored as CHAR(36) and times as DATETIME(6)
+var mysqlSchema = schema{
+	history: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at DATETIME(6) NOT NULL
+	)`,
+	migrations: []Migration{
+		{
+			Version: 1,
+			Name:    "create_tenants",
+			Up: []string{
+				`CREATE TABLE tenants (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					slug VARCHAR(63) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					UNIQUE KEY tenants_slug (slug)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE tenants`,
+			},
+		},
+		{
+			Version: 2,
+			Name:    "create_users",
+			Up: []string{
+				`CREATE TABLE users (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					email VARCHAR(255) NOT NULL,
+					passwords VARCHAR(255) NOT NULL,
+					name VARCHAR(255) NOT NULL DEFAULT '',
+					bio VARCHAR(1024) NOT NULL DEFAULT '',
+					role VARCHAR(32) NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					updated_at DATETIME(6) NOT NULL,
+					deleted_at DATETIME(6) NULL,
+					UNIQUE KEY users_tenant_email (tenant_id, email),
+					KEY users_tenant_created (tenant_id, created_at),
+					KEY users_deleted (deleted_at)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE users`,
+			},
+		},
+		{
+			Version: 3,
+			Name:    "create_user_status_history",
+			Up: []string{
+				`CREATE TABLE user_status_history (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					user_id CHAR(36) NOT NULL,
+					from_status VARCHAR(32) NOT NULL,
+					to_status VARCHAR(32) NOT NULL,
+					reason VARCHAR(1024) NOT NULL,
+					actor_id VARCHAR(255) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					KEY user_status_history_user (user_id, created_at)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE user_status_history`,
+			},
+		},
+		{
+			Version: 4,
+			Name:    "create_sessions",
+			Up: []string{
+				`CREATE TABLE sessions (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					user_id CHAR(36) NOT NULL,
+					user_agent VARCHAR(1024) NOT NULL,
+					ip VARCHAR(45) NOT NULL,
+					device_label VARCHAR(255) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					last_seen_at DATETIME(6) NOT NULL,
+					revoked_at DATETIME(6) NULL,
+					KEY sessions_user (tenant_id, user_id)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE sessions`,
+			},
+		},
+		{
+			Version: 5,
+			Name:    "create_organizations",
+			Up: []string{
+				`CREATE TABLE organizations (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					created_by CHAR(36) NOT NULL,
+					created_at DATETIME(6) NOT NULL
+				)`,
+				`CREATE TABLE organization_members (
+					tenant_id CHAR(36) NOT NULL,
+					organization_id CHAR(36) NOT NULL,
+					user_id CHAR(36) NOT NULL,
+					role VARCHAR(32) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					PRIMARY KEY (organization_id, user_id),
+					KEY organization_members_user (tenant_id, user_id)
+				)`,
+				`CREATE TABLE organization_invitations (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					organization_id CHAR(36) NOT NULL,
+					email VARCHAR(255) NOT NULL,
+					role VARCHAR(32) NOT NULL,
+					token_hash CHAR(64) NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					invited_by CHAR(36) NOT NULL,
+					expires_at DATETIME(6) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					UNIQUE KEY organization_invitations_token (token_hash)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE organization_invitations`,
+				`DROP TABLE organization_members`,
+				`DROP TABLE organizations`,
+			},
+		},
+		{
+			Version: 6,
+			Name:    "create_outbox_events",
+			Up: []string{
+				`CREATE TABLE outbox_events (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					sequence BIGINT NOT NULL AUTO_INCREMENT,
+					tenant_id CHAR(36) NOT NULL,
+					aggregate_id CHAR(36) NOT NULL,
+					type VARCHAR(64) NOT NULL,
+					payload TEXT NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					attempts INT NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME(6) NOT NULL,
+					last_error VARCHAR(1024) NOT NULL DEFAULT '',
+					published_at DATETIME(6) NULL,
+					UNIQUE KEY outbox_events_sequence (sequence),
+					KEY outbox_events_pending (published_at, sequence)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE outbox_events`,
+			},
+		},
+		{
+			Version: 7,
+			Name:    "create_audit_events",
+			Up: []string{
+				`CREATE TABLE audit_events (
+					sequence BIGINT NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					actor_id VARCHAR(255) NOT NULL,
+					target VARCHAR(255) NOT NULL,
+					action VARCHAR(64) NOT NULL,
+					outcome VARCHAR(32) NOT NULL,
+					reason VARCHAR(1024) NOT NULL,
+					ip VARCHAR(45) NOT NULL,
+					user_agent VARCHAR(1024) NOT NULL,
+					request_id VARCHAR(255) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					prev_hash CHAR(64) NOT NULL,
+					hash CHAR(64) NOT NULL,
+					KEY audit_events_tenant (tenant_id, sequence)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE audit_events`,
+			},
+		},
+		{
+			Version: 8,
+			Name:    "create_webhooks",
+			Up: []string{
+				`CREATE TABLE webhooks (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					url VARCHAR(2048) NOT NULL,
+					events VARCHAR(1024) NOT NULL,
+					secret VARCHAR(255) NOT NULL,
+					created_by CHAR(36) NOT NULL,
+					created_at DATETIME(6) NOT NULL,
+					KEY webhooks_tenant (tenant_id, created_at)
+				)`,
+				`CREATE TABLE webhook_deliveries (
+					id CHAR(36) NOT NULL PRIMARY KEY,
+					tenant_id CHAR(36) NOT NULL,
+					webhook_id CHAR(36) NOT NULL,
+					event_id CHAR(36) NOT NULL,
+					event_type VARCHAR(64) NOT NULL,
+					payload TEXT NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					attempts INT NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME(6) NOT NULL,
+					last_status_code INT NOT NULL DEFAULT 0,
+					last_error VARCHAR(1024) NOT NULL DEFAULT '',
+					created_at DATETIME(6) NOT NULL,
+					delivered_at DATETIME(6) NULL,
+					KEY webhook_deliveries_webhook (tenant_id, webhook_id, created_at),
+					KEY webhook_deliveries_due (status, next_attempt_at)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE webhook_deliveries`,
+				`DROP TABLE webhooks`,
+			},
+		},
+		{
+			Version: 9,
+			Name:    "add_users_failed_logins",
+			Up: []string{
+				`ALTER TABLE users ADD COLUMN failed_logins INT NOT NULL DEFAULT 0`,
+			},
+			Down: []string{
+				`ALTER TABLE users DROP COLUMN failed_logins`,
+			},
+		},
+		{
+			Version: 10,
+			Name:    "add_outbox_events_lease",
+			Up: []string{
+				`ALTER TABLE outbox_events ADD COLUMN dead_at DATETIME(6) NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN lease_id CHAR(36) NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN leased_until DATETIME(6) NULL`,
+				`CREATE INDEX outbox_events_aggregate ON outbox_events (aggregate_id, sequence)`,
+				`CREATE INDEX outbox_events_lease ON outbox_events (lease_id)`,
+			},
+			Down: []string{
+				`DROP INDEX outbox_events_lease ON outbox_events`,
+				`DROP INDEX outbox_events_aggregate ON outbox_events`,
+				`ALTER TABLE outbox_events DROP COLUMN leased_until`,
+				`ALTER TABLE outbox_events DROP COLUMN lease_id`,
+				`ALTER TABLE outbox_events DROP COLUMN dead_at`,
+			},
+		},
+		{
+			Version: 11,
+			Name:    "add_webhook_deliveries_lease",
+			Up: []string{
+				`ALTER TABLE webhook_deliveries ADD COLUMN lease_id CHAR(36) NULL`,
+				`ALTER TABLE webhook_deliveries ADD COLUMN leased_until DATETIME(6) NULL`,
+				`CREATE INDEX webhook_deliveries_lease ON webhook_deliveries (lease_id)`,
+			},
+			Down: []string{
+				`DROP INDEX webhook_deliveries_lease ON webhook_deliveries`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN leased_until`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN lease_id`,
+			},
+		},
+	},
+}
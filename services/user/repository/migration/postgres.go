@@ -0,0 +1,263 @@
+package migration
+
+// postgresSchema uuids are stored as UUID and times as TIMESTAMP in utc the
+// way dbr encodes them
+var postgresSchema = schema{
+	history: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`,
+	migrations: []Migration{
+		{
+			Version: 1,
+			Name:    "create_tenants",
+			Up: []string{
+				`CREATE TABLE tenants (
+					id UUID NOT NULL PRIMARY KEY,
+					slug VARCHAR(63) NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					CONSTRAINT tenants_slug UNIQUE (slug)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE tenants`,
+			},
+		},
+		{
+			Version: 2,
+			Name:    "create_users",
+			Up: []string{
+				`CREATE TABLE users (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					email VARCHAR(255) NOT NULL,
+					passwords VARCHAR(255) NOT NULL,
+					name VARCHAR(255) NOT NULL DEFAULT '',
+					bio VARCHAR(1024) NOT NULL DEFAULT '',
+					role VARCHAR(32) NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					updated_at TIMESTAMP NOT NULL,
+					deleted_at TIMESTAMP NULL,
+					CONSTRAINT users_tenant_email UNIQUE (tenant_id, email)
+				)`,
+				`CREATE INDEX users_tenant_created ON users (tenant_id, created_at)`,
+				`CREATE INDEX users_deleted ON users (deleted_at)`,
+			},
+			Down: []string{
+				`DROP TABLE users`,
+			},
+		},
+		{
+			Version: 3,
+			Name:    "create_user_status_history",
+			Up: []string{
+				`CREATE TABLE user_status_history (
+					id UUID NOT NULL PRIMARY KEY,
+					user_id UUID NOT NULL,
+					from_status VARCHAR(32) NOT NULL,
+					to_status VARCHAR(32) NOT NULL,
+					reason VARCHAR(1024) NOT NULL,
+					actor_id VARCHAR(255) NOT NULL,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE INDEX user_status_history_user ON user_status_history (user_id, created_at)`,
+			},
+			Down: []string{
+				`DROP TABLE user_status_history`,
+			},
+		},
+		{
+			Version: 4,
+			Name:    "create_sessions",
+			Up: []string{
+				`CREATE TABLE sessions (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					user_id UUID NOT NULL,
+					user_agent VARCHAR(1024) NOT NULL,
+					ip VARCHAR(45) NOT NULL,
+					device_label VARCHAR(255) NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					last_seen_at TIMESTAMP NOT NULL,
+					revoked_at TIMESTAMP NULL
+				)`,
+				`CREATE INDEX sessions_user ON sessions (tenant_id, user_id)`,
+			},
+			Down: []string{
+				`DROP TABLE sessions`,
+			},
+		},
+		{
+			Version: 5,
+			Name:    "create_organizations",
+			Up: []string{
+				`CREATE TABLE organizations (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					name VARCHAR(255) NOT NULL,
+					created_by UUID NOT NULL,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE TABLE organization_members (
+					tenant_id UUID NOT NULL,
+					organization_id UUID NOT NULL,
+					user_id UUID NOT NULL,
+					role VARCHAR(32) NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					PRIMARY KEY (organization_id, user_id)
+				)`,
+				`CREATE INDEX organization_members_user ON organization_members (tenant_id, user_id)`,
+				`CREATE TABLE organization_invitations (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					organization_id UUID NOT NULL,
+					email VARCHAR(255) NOT NULL,
+					role VARCHAR(32) NOT NULL,
+					token_hash CHAR(64) NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					invited_by UUID NOT NULL,
+					expires_at TIMESTAMP NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					CONSTRAINT organization_invitations_token UNIQUE (token_hash)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE organization_invitations`,
+				`DROP TABLE organization_members`,
+				`DROP TABLE organizations`,
+			},
+		},
+		{
+			Version: 6,
+			Name:    "create_outbox_events",
+			Up: []string{
+				`CREATE TABLE outbox_events (
+					id UUID NOT NULL PRIMARY KEY,
+					sequence BIGSERIAL NOT NULL,
+					tenant_id UUID NOT NULL,
+					aggregate_id UUID NOT NULL,
+					type VARCHAR(64) NOT NULL,
+					payload TEXT NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at TIMESTAMP NOT NULL,
+					last_error VARCHAR(1024) NOT NULL DEFAULT '',
+					published_at TIMESTAMP NULL,
+					CONSTRAINT outbox_events_sequence UNIQUE (sequence)
+				)`,
+				`CREATE INDEX outbox_events_pending ON outbox_events (published_at, sequence)`,
+			},
+			Down: []string{
+				`DROP TABLE outbox_events`,
+			},
+		},
+		{
+			Version: 7,
+			Name:    "create_audit_events",
+			Up: []string{
+				`CREATE TABLE audit_events (
+					sequence BIGINT NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					actor_id VARCHAR(255) NOT NULL,
+					target VARCHAR(255) NOT NULL,
+					action VARCHAR(64) NOT NULL,
+					outcome VARCHAR(32) NOT NULL,
+					reason VARCHAR(1024) NOT NULL,
+					ip VARCHAR(45) NOT NULL,
+					user_agent VARCHAR(1024) NOT NULL,
+					request_id VARCHAR(255) NOT NULL,
+					created_at TIMESTAMP NOT NULL,
+					prev_hash CHAR(64) NOT NULL,
+					hash CHAR(64) NOT NULL
+				)`,
+				`CREATE INDEX audit_events_tenant ON audit_events (tenant_id, sequence)`,
+			},
+			Down: []string{
+				`DROP TABLE audit_events`,
+			},
+		},
+		{
+			Version: 8,
+			Name:    "create_webhooks",
+			Up: []string{
+				`CREATE TABLE webhooks (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					url VARCHAR(2048) NOT NULL,
+					events VARCHAR(1024) NOT NULL,
+					secret VARCHAR(255) NOT NULL,
+					created_by UUID NOT NULL,
+					created_at TIMESTAMP NOT NULL
+				)`,
+				`CREATE INDEX webhooks_tenant ON webhooks (tenant_id, created_at)`,
+				`CREATE TABLE webhook_deliveries (
+					id UUID NOT NULL PRIMARY KEY,
+					tenant_id UUID NOT NULL,
+					webhook_id UUID NOT NULL,
+					event_id UUID NOT NULL,
+					event_type VARCHAR(64) NOT NULL,
+					payload TEXT NOT NULL,
+					status VARCHAR(32) NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at TIMESTAMP NOT NULL,
+					last_status_code INTEGER NOT NULL DEFAULT 0,
+					last_error VARCHAR(1024) NOT NULL DEFAULT '',
+					created_at TIMESTAMP NOT NULL,
+					delivered_at TIMESTAMP NULL
+				)`,
+				`CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (tenant_id, webhook_id, created_at)`,
+				`CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
+			},
+			Down: []string{
+				`DROP TABLE webhook_deliveries`,
+				`DROP TABLE webhooks`,
+			},
+		},
+		{
+			Version: 9,
+			Name:    "add_users_failed_logins",
+			Up: []string{
+				`ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0`,
+			},
+			Down: []string{
+				`ALTER TABLE users DROP COLUMN failed_logins`,
+			},
+		},
+		{
+			Version: 10,
+			Name:    "add_outbox_events_lease",
+			Up: []string{
+				`ALTER TABLE outbox_events ADD COLUMN dead_at TIMESTAMP NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN lease_id UUID NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN leased_until TIMESTAMP NULL`,
+				`CREATE INDEX outbox_events_aggregate ON outbox_events (aggregate_id, sequence)`,
+				`CREATE INDEX outbox_events_lease ON outbox_events (lease_id)`,
+			},
+			Down: []string{
+				`DROP INDEX outbox_events_lease`,
+				`DROP INDEX outbox_events_aggregate`,
+				`ALTER TABLE outbox_events DROP COLUMN leased_until`,
+				`ALTER TABLE outbox_events DROP COLUMN lease_id`,
+				`ALTER TABLE outbox_events DROP COLUMN dead_at`,
+			},
+		},
+		{
+			Version: 11,
+			Name:    "add_webhook_deliveries_lease",
+			Up: []string{
+				`ALTER TABLE webhook_deliveries ADD COLUMN lease_id UUID NULL`,
+				`ALTER TABLE webhook_deliveries ADD COLUMN leased_until TIMESTAMP NULL`,
+				`CREATE INDEX webhook_deliveries_lease ON webhook_deliveries (lease_id)`,
+			},
+			Down: []string{
+				`DROP INDEX webhook_deliveries_lease`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN leased_until`,
+				`ALTER TABLE webhook_deliveries DROP COLUMN lease_id`,
+			},
+		},
+	},
+}
@@ -0,0 +1,325 @@
+package migration
+
+// sqliteSchema uuids are stored as TEXT and times as DATETIME so driver
+// parses them back into time
+var sqliteSchema = schema{
+	history: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`,
+	migrations: []Migration{
+		{
+			Version: 1,
+			Name:    "create_tenants",
+			Up: []string{
+				`CREATE TABLE tenants (
+					id TEXT NOT NULL PRIMARY KEY,
+					slug TEXT NOT NULL,
+					name TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					CONSTRAINT tenants_slug UNIQUE (slug)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE tenants`,
+			},
+		},
+		{
+			Version: 2,
+			Name:    "create_users",
+			Up: []string{
+				`CREATE TABLE users (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					email TEXT NOT NULL,
+					passwords TEXT NOT NULL,
+					name TEXT NOT NULL DEFAULT '',
+					bio TEXT NOT NULL DEFAULT '',
+					role TEXT NOT NULL,
+					status TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					updated_at DATETIME NOT NULL,
+					deleted_at DATETIME NULL,
+					CONSTRAINT users_tenant_email UNIQUE (tenant_id, email)
+				)`,
+				`CREATE INDEX users_tenant_created ON users (tenant_id, created_at)`,
+				`CREATE INDEX users_deleted ON users (deleted_at)`,
+			},
+			Down: []string{
+				`DROP TABLE users`,
+			},
+		},
+		{
+			Version: 3,
+			Name:    "create_user_status_history",
+			Up: []string{
+				`CREATE TABLE user_status_history (
+					id TEXT NOT NULL PRIMARY KEY,
+					user_id TEXT NOT NULL,
+					from_status TEXT NOT NULL,
+					to_status TEXT NOT NULL,
+					reason TEXT NOT NULL,
+					actor_id TEXT NOT NULL,
+					created_at DATETIME NOT NULL
+				)`,
+				`CREATE INDEX user_status_history_user ON user_status_history (user_id, created_at)`,
+			},
+			Down: []string{
+				`DROP TABLE user_status_history`,
+			},
+		},
+		{
+			Version: 4,
+			Name:    "create_sessions",
+			Up: []string{
+				`CREATE TABLE sessions (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					user_id TEXT NOT NULL,
+					user_agent TEXT NOT NULL,
+					ip TEXT NOT NULL,
+					device_label TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					last_seen_at DATETIME NOT NULL,
+					revoked_at DATETIME NULL
+				)`,
+				`CREATE INDEX sessions_user ON sessions (tenant_id, user_id)`,
+			},
+			Down: []string{
+				`DROP TABLE sessions`,
+			},
+		},
+		{
+			Version: 5,
+			Name:    "create_organizations",
+			Up: []string{
+				`CREATE TABLE organizations (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					name TEXT NOT NULL,
+					created_by TEXT NOT NULL,
+					created_at DATETIME NOT NULL
+				)`,
+				`CREATE TABLE organization_members (
+					tenant_id TEXT NOT NULL,
+					organization_id TEXT NOT NULL,
+					user_id TEXT NOT NULL,
+					role TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					PRIMARY KEY (organization_id, user_id)
+				)`,
+				`CREATE INDEX organization_members_user ON organization_members (tenant_id, user_id)`,
+				`CREATE TABLE organization_invitations (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					organization_id TEXT NOT NULL,
+					email TEXT NOT NULL,
+					role TEXT NOT NULL,
+					token_hash TEXT NOT NULL,
+					status TEXT NOT NULL,
+					invited_by TEXT NOT NULL,
+					expires_at DATETIME NOT NULL,
+					created_at DATETIME NOT NULL,
+					CONSTRAINT organization_invitations_token UNIQUE (token_hash)
+				)`,
+			},
+			Down: []string{
+				`DROP TABLE organization_invitations`,
+				`DROP TABLE organization_members`,
+				`DROP TABLE organizations`,
+			},
+		},
+		{
+			Version: 6,
+			Name:    "create_outbox_events",
+			Up: []string{
+				// Only integer primary key can autoincrement
+				`CREATE TABLE outbox_events (
+					sequence INTEGER PRIMARY KEY AUTOINCREMENT,
+					id TEXT NOT NULL,
+					tenant_id TEXT NOT NULL,
+					aggregate_id TEXT NOT NULL,
+					type TEXT NOT NULL,
+					payload TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME NOT NULL,
+					last_error TEXT NOT NULL DEFAULT '',
+					published_at DATETIME NULL,
+					CONSTRAINT outbox_events_id UNIQUE (id)
+				)`,
+				`CREATE INDEX outbox_events_pending ON outbox_events (published_at, sequence)`,
+			},
+			Down: []string{
+				`DROP TABLE outbox_events`,
+			},
+		},
+		{
+			Version: 7,
+			Name:    "create_audit_events",
+			Up: []string{
+				`CREATE TABLE audit_events (
+					sequence INTEGER NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					actor_id TEXT NOT NULL,
+					target TEXT NOT NULL,
+					action TEXT NOT NULL,
+					outcome TEXT NOT NULL,
+					reason TEXT NOT NULL,
+					ip TEXT NOT NULL,
+					user_agent TEXT NOT NULL,
+					request_id TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					prev_hash TEXT NOT NULL,
+					hash TEXT NOT NULL
+				)`,
+				`CREATE INDEX audit_events_tenant ON audit_events (tenant_id, sequence)`,
+			},
+			Down: []string{
+				`DROP TABLE audit_events`,
+			},
+		},
+		{
+			Version: 8,
+			Name:    "create_webhooks",
+			Up: []string{
+				`CREATE TABLE webhooks (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					url TEXT NOT NULL,
+					events TEXT NOT NULL,
+					secret TEXT NOT NULL,
+					created_by TEXT NOT NULL,
+					created_at DATETIME NOT NULL
+				)`,
+				`CREATE INDEX webhooks_tenant ON webhooks (tenant_id, created_at)`,
+				`CREATE TABLE webhook_deliveries (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					webhook_id TEXT NOT NULL,
+					event_id TEXT NOT NULL,
+					event_type TEXT NOT NULL,
+					payload TEXT NOT NULL,
+					status TEXT NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME NOT NULL,
+					last_status_code INTEGER NOT NULL DEFAULT 0,
+					last_error TEXT NOT NULL DEFAULT '',
+					created_at DATETIME NOT NULL,
+					delivered_at DATETIME NULL
+				)`,
+				`CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (tenant_id, webhook_id, created_at)`,
+				`CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
+			},
+			Down: []string{
+				`DROP TABLE webhook_deliveries`,
+				`DROP TABLE webhooks`,
+			},
+		},
+		{
+			Version: 9,
+			Name:    "add_users_failed_logins",
+			Up: []string{
+				`ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0`,
+			},
+			// Bundled sqlite cannot drop column so table is rebuilt
+			Down: []string{
+				`CREATE TABLE users_rebuilt (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					email TEXT NOT NULL,
+					passwords TEXT NOT NULL,
+					name TEXT NOT NULL DEFAULT '',
+					bio TEXT NOT NULL DEFAULT '',
+					role TEXT NOT NULL,
+					status TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					updated_at DATETIME NOT NULL,
+					deleted_at DATETIME NULL,
+					CONSTRAINT users_tenant_email UNIQUE (tenant_id, email)
+				)`,
+				`INSERT INTO users_rebuilt
+					SELECT id, tenant_id, email, passwords, name, bio, role, status,
+						created_at, updated_at, deleted_at
+					FROM users`,
+				`DROP TABLE users`,
+				`ALTER TABLE users_rebuilt RENAME TO users`,
+				`CREATE INDEX users_tenant_created ON users (tenant_id, created_at)`,
+				`CREATE INDEX users_deleted ON users (deleted_at)`,
+			},
+		},
+		{
+			Version: 10,
+			Name:    "add_outbox_events_lease",
+			Up: []string{
+				`ALTER TABLE outbox_events ADD COLUMN dead_at DATETIME NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN lease_id TEXT NULL`,
+				`ALTER TABLE outbox_events ADD COLUMN leased_until DATETIME NULL`,
+				`CREATE INDEX outbox_events_aggregate ON outbox_events (aggregate_id, sequence)`,
+				`CREATE INDEX outbox_events_lease ON outbox_events (lease_id)`,
+			},
+			// Bundled sqlite cannot drop column so table is rebuilt
+			Down: []string{
+				`CREATE TABLE outbox_events_rebuilt (
+					sequence INTEGER PRIMARY KEY AUTOINCREMENT,
+					id TEXT NOT NULL,
+					tenant_id TEXT NOT NULL,
+					aggregate_id TEXT NOT NULL,
+					type TEXT NOT NULL,
+					payload TEXT NOT NULL,
+					created_at DATETIME NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME NOT NULL,
+					last_error TEXT NOT NULL DEFAULT '',
+					published_at DATETIME NULL,
+					CONSTRAINT outbox_events_id UNIQUE (id)
+				)`,
+				`INSERT INTO outbox_events_rebuilt
+					SELECT sequence, id, tenant_id, aggregate_id, type, payload,
+						created_at, attempts, next_attempt_at, last_error, published_at
+					FROM outbox_events`,
+				`DROP TABLE outbox_events`,
+				`ALTER TABLE outbox_events_rebuilt RENAME TO outbox_events`,
+				`CREATE INDEX outbox_events_pending ON outbox_events (published_at, sequence)`,
+			},
+		},
+		{
+			Version: 11,
+			Name:    "add_webhook_deliveries_lease",
+			Up: []string{
+				`ALTER TABLE webhook_deliveries ADD COLUMN lease_id TEXT NULL`,
+				`ALTER TABLE webhook_deliveries ADD COLUMN leased_until DATETIME NULL`,
+				`CREATE INDEX webhook_deliveries_lease ON webhook_deliveries (lease_id)`,
+			},
+			// Bundled sqlite cannot drop column so table is rebuilt
+			Down: []string{
+				`CREATE TABLE webhook_deliveries_rebuilt (
+					id TEXT NOT NULL PRIMARY KEY,
+					tenant_id TEXT NOT NULL,
+					webhook_id TEXT NOT NULL,
+					event_id TEXT NOT NULL,
+					event_type TEXT NOT NULL,
+					payload TEXT NOT NULL,
+					status TEXT NOT NULL,
+					attempts INTEGER NOT NULL DEFAULT 0,
+					next_attempt_at DATETIME NOT NULL,
+					last_status_code INTEGER NOT NULL DEFAULT 0,
+					last_error TEXT NOT NULL DEFAULT '',
+					created_at DATETIME NOT NULL,
+					delivered_at DATETIME NULL
+				)`,
+				`INSERT INTO webhook_deliveries_rebuilt
+					SELECT id, tenant_id, webhook_id, event_id, event_type, payload,
+						status, attempts, next_attempt_at, last_status_code, last_error,
+						created_at, delivered_at
+					FROM webhook_deliveries`,
+				`DROP TABLE webhook_deliveries`,
+				`ALTER TABLE webhook_deliveries_rebuilt RENAME TO webhook_deliveries`,
+				`CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (tenant_id, webhook_id, created_at)`,
+				`CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
+			},
+		},
+	},
+}
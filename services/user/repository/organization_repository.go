@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type organizationRepository struct {
+	Session *dbr.Session
+}
+
+// NewOrganizationRepository create instances of organization repo struct
+func NewOrganizationRepository(sess *dbr.Session) user.OrganizationRepository {
+	return &organizationRepository{
+		Session: sess,
+	}
+}
+
+// CreateOrganization database query logic, inserts organization with its owner
+func (repo *organizationRepository) CreateOrganization(
+	ctx context.Context,
+	organization user.Organization,
+	owner user.Membership,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	organization.TenantID = tenantID
+	owner.TenantID = tenantID
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		_, err := tx.InsertInto("organizations").
+			Columns("id", "tenant_id", "name", "created_by", "created_at").
+			Record(organization).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.InsertInto("organization_members").
+			Columns("tenant_id", "organization_id", "user_id", "role", "created_at").
+			Record(owner).
+			ExecContext(ctx)
+		return err
+	})
+}
+
+// GetOrganization database query logic
+func (repo *organizationRepository) GetOrganization(
+	ctx context.Context,
+	id uuid.UUID,
+) (*user.Organization, error) {
+	var selectedOrganization *user.Organization
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := primary(ctx, repo.Session).Select("*").
+		From("organizations").
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		LoadContext(ctx, &selectedOrganization)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, user.ErrOrganizationNotFound
+	}
+	return selectedOrganization, nil
+}
+
+// ListOrganizations database query logic, returns organizations user is member of
+func (repo *organizationRepository) ListOrganizations(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]user.Organization, error) {
+	var organizations []user.Organization
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = primary(ctx, repo.Session).Select("o.*").
+		From(dbr.I("organizations").As("o")).
+		Join(dbr.I("organization_members").As("m"), "m.organization_id = o.id").
+		Where("o.tenant_id = ? AND m.tenant_id = ? AND m.user_id = ?", tenantID, tenantID, userID).
+		OrderAsc("o.created_at").
+		LoadContext(ctx, &organizations)
+	if err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// GetMembership database query logic
+func (repo *organizationRepository) GetMembership(
+	ctx context.Context,
+	organizationID, userID uuid.UUID,
+) (*user.Membership, error) {
+	var selectedMembership *user.Membership
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := primary(ctx, repo.Session).Select("*").
+		From("organization_members").
+		Where(
+			"tenant_id = ? AND organization_id = ? AND user_id = ?",
+			tenantID, organizationID, userID,
+		).
+		LoadContext(ctx, &selectedMembership)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, user.ErrNotMember
+	}
+	return selectedMembership, nil
+}
+
+// ListMembers database query logic
+func (repo *organizationRepository) ListMembers(
+	ctx context.Context,
+	organizationID uuid.UUID,
+) ([]user.Membership, error) {
+	var members []user.Membership
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = primary(ctx, repo.Session).Select("*").
+		From("organization_members").
+		Where("tenant_id = ? AND organization_id = ?", tenantID, organizationID).
+		OrderAsc("created_at").
+		LoadContext(ctx, &members)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// LockOwners database query logic, counts owners locking their rows until
+// unit of work of context ends so concurrent removals and demotions of
+// owners wait for it and count owners left after it. Sqlite has no row
+// locks, its writers take whole database in turn
+func (repo *organizationRepository) LockOwners(
+	ctx context.Context,
+	organizationID uuid.UUID,
+) (int, error) {
+	var owners []string
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := runner(ctx, repo.Session).Select("user_id").
+		From("organization_members").
+		Where(
+			"tenant_id = ? AND organization_id = ? AND role = ?",
+			tenantID, organizationID, user.OrgRoleOwner,
+		)
+	if repo.Session.Dialect != dialect.SQLite3 {
+		stmt = stmt.Suffix("FOR UPDATE")
+	}
+	if _, err := stmt.LoadContext(ctx, &owners); err != nil {
+		return 0, err
+	}
+	return len(owners), nil
+}
+
+// ChangeMemberRole database query logic
+func (repo *organizationRepository) ChangeMemberRole(
+	ctx context.Context,
+	organizationID, userID uuid.UUID,
+	role string,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("organization_members").
+		Set("role", role).
+		Where(
+			"tenant_id = ? AND organization_id = ? AND user_id = ?",
+			tenantID, organizationID, userID,
+		).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrNotMember)
+}
+
+// RemoveMember database query logic
+func (repo *organizationRepository) RemoveMember(
+	ctx context.Context,
+	organizationID, userID uuid.UUID,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).DeleteFrom("organization_members").
+		Where(
+			"tenant_id = ? AND organization_id = ? AND user_id = ?",
+			tenantID, organizationID, userID,
+		).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrNotMember)
+}
+
+// CreateInvitation database query logic
+func (repo *organizationRepository) CreateInvitation(
+	ctx context.Context,
+	invitation user.Invitation,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	invitation.TenantID = tenantID
+
+	_, err = runner(ctx, repo.Session).InsertInto("organization_invitations").
+		Columns(
+			"id", "tenant_id", "organization_id", "email", "role",
+			"token_hash", "status", "invited_by", "expires_at", "created_at",
+		).
+		Record(invitation).
+		ExecContext(ctx)
+	return err
+}
+
+// GetInvitation database query logic, looks up pending invitation by token hash
+func (repo *organizationRepository) GetInvitation(
+	ctx context.Context,
+	tokenHash string,
+) (*user.Invitation, error) {
+	var selectedInvitation *user.Invitation
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := primary(ctx, repo.Session).Select("*").
+		From("organization_invitations").
+		Where(
+			"tenant_id = ? AND token_hash = ? AND status = ?",
+			tenantID, tokenHash, user.InvitationPending,
+		).
+		LoadContext(ctx, &selectedInvitation)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, user.ErrInvitationNotFound
+	}
+	return selectedInvitation, nil
+}
+
+// AcceptInvitation database query logic, marks invitation accepted and
+// inserts membership
+func (repo *organizationRepository) AcceptInvitation(
+	ctx context.Context,
+	invitation user.Invitation,
+	member user.Membership,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	member.TenantID = tenantID
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.Update("organization_invitations").
+			Set("status", user.InvitationAccepted).
+			Where(
+				"tenant_id = ? AND id = ? AND status = ?",
+				tenantID, invitation.ID, user.InvitationPending,
+			).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, user.ErrInvitationNotFound); err != nil {
+			return err
+		}
+		_, err = tx.InsertInto("organization_members").
+			Columns("tenant_id", "organization_id", "user_id", "role", "created_at").
+			Record(member).
+			ExecContext(ctx)
+		if isDuplicate(err) {
+			return user.ErrAlreadyMember
+		}
+		return err
+	})
+}
+
+// DeclineInvitation database query logic
+func (repo *organizationRepository) DeclineInvitation(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("organization_invitations").
+		Set("status", user.InvitationDeclined).
+		Where(
+			"tenant_id = ? AND id = ? AND status = ?",
+			tenantID, id, user.InvitationPending,
+		).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrInvitationNotFound)
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type outboxRepository struct {
+	Session *dbr.Session
+}
+
+// NewOutboxRepository create instances of outbox repo struct
+func NewOutboxRepository(sess *dbr.Session) user.OutboxRepository {
+	return &outboxRepository{
+		Session: sess,
+	}
+}
+
+// ClaimEvents database query logic, leases up to limit events due now which
+// head unpublished events of their aggregate, so aggregate waiting for
+// retry holds back only its own events. Events leased by another relay and
+// dead lettered ones are skipped
+func (repo *outboxRepository) ClaimEvents(
+	ctx context.Context,
+	limit int,
+	now time.Time,
+	lease time.Duration,
+) ([]user.Event, error) {
+	var ids []uuid.UUID
+	_, err := primary(ctx, repo.Session).Select("e.id").
+		From(dbr.I("outbox_events").As("e")).
+		Where("e.published_at IS NULL AND e.dead_at IS NULL").
+		Where("e.next_attempt_at <= ?", now).
+		Where("e.leased_until IS NULL OR e.leased_until <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events h
+			WHERE h.aggregate_id = e.aggregate_id
+			AND h.published_at IS NULL AND h.dead_at IS NULL
+			AND h.sequence < e.sequence)`).
+		OrderAsc("e.sequence").
+		Limit(uint64(limit)).
+		LoadContext(ctx, &ids)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	// Lease is taken only where it is still free, relay racing for the same
+	// events gets those it leased first
+	leaseID := uuid.NewV4()
+	_, err = runner(ctx, repo.Session).Update("outbox_events").
+		Set("lease_id", leaseID).
+		Set("leased_until", now.Add(lease)).
+		Where("id IN ?", ids).
+		Where("leased_until IS NULL OR leased_until <= ?", now).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []user.Event
+	_, err = primary(ctx, repo.Session).Select("*").
+		From("outbox_events").
+		Where("lease_id = ?", leaseID).
+		OrderAsc("sequence").
+		LoadContext(ctx, &events)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkPublished database query logic
+func (repo *outboxRepository) MarkPublished(
+	ctx context.Context,
+	id uuid.UUID,
+	publishedAt time.Time,
+) error {
+	_, err := runner(ctx, repo.Session).Update("outbox_events").
+		Set("published_at", publishedAt).
+		Where("id = ?", id).
+		ExecContext(ctx)
+	return err
+}
+
+// MarkFailed database query logic, counts failed attempt and schedules retry,
+// lease is released so retry does not wait for it
+func (repo *outboxRepository) MarkFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	nextAttemptAt time.Time,
+	reason string,
+) error {
+	_, err := runner(ctx, repo.Session).Update("outbox_events").
+		Set("attempts", dbr.Expr("attempts + 1")).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("last_error", reason).
+		Set("leased_until", nil).
+		Where("id = ?", id).
+		ExecContext(ctx)
+	return err
+}
+
+// MarkDead database query logic, counts last failed attempt and dead letters
+// event so later events of its aggregate are relayed
+func (repo *outboxRepository) MarkDead(
+	ctx context.Context,
+	id uuid.UUID,
+	deadAt time.Time,
+	reason string,
+) error {
+	_, err := runner(ctx, repo.Session).Update("outbox_events").
+		Set("attempts", dbr.Expr("attempts + 1")).
+		Set("dead_at", deadAt).
+		Set("last_error", reason).
+		Set("leased_until", nil).
+		Where("id = ?", id).
+		ExecContext(ctx)
+	return err
+}
+
+// insertEvent stores event into outbox, runner is transaction of the state
+// change event describes
+func insertEvent(
+	ctx context.Context,
+	runner dbr.SessionRunner,
+	tenantID uuid.UUID,
+	event user.Event,
+) error {
+	event.TenantID = tenantID
+	_, err := runner.InsertInto("outbox_events").
+		Columns(
+			"id", "tenant_id", "aggregate_id", "type", "payload",
+			"created_at", "attempts", "next_attempt_at",
+		).
+		Record(event).
+		ExecContext(ctx)
+	return err
+}
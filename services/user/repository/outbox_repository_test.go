@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestOutboxWrittenWithChange(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	users := NewUserRepository(sess, nil)
+	outbox := NewOutboxRepository(sess)
+	ada := newUser("ada@example.com", time.Now())
+	if err := users.Register(ctx, ada); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change rolled back takes its event along
+	failed := errors.New("failed")
+	err := NewTransactor(sess).WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := users.Register(ctx, newUser("grace@example.com", time.Now())); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("rolled back register: err = %v", err)
+	}
+
+	events, err := outbox.ClaimEvents(ctx, 10, time.Now(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != user.EventUserRegistered || events[0].AggregateID != ada.ID {
+		t.Fatalf("claimed %+v, want UserRegistered of %s", events, ada.Email)
+	}
+}
+
+func TestOutboxClaimOrder(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	users := NewUserRepository(sess, nil)
+	outbox := NewOutboxRepository(sess)
+	ada := newUser("ada@example.com", time.Now())
+	grace := newUser("grace@example.com", time.Now())
+	for _, u := range []user.User{ada, grace} {
+		if err := users.Register(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := users.DeleteUser(ctx, ada.ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	claim := func(at time.Time) []user.Event {
+		t.Helper()
+		events, err := outbox.ClaimEvents(ctx, 10, at, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+	aggregates := func(events []user.Event) map[uuid.UUID]string {
+		claimed := map[uuid.UUID]string{}
+		for _, event := range events {
+			claimed[event.AggregateID] = event.Type
+		}
+		return claimed
+	}
+
+	// Only heads of aggregates are claimed
+	heads := claim(now)
+	if claimed := aggregates(heads); len(heads) != 2 ||
+		claimed[ada.ID] != user.EventUserRegistered || claimed[grace.ID] != user.EventUserRegistered {
+		t.Fatalf("claimed %+v, want registration of each user", heads)
+	}
+	// Leased events are not claimed again until lease ends
+	if again := claim(now); len(again) != 0 {
+		t.Fatalf("claimed leased events %+v", again)
+	}
+
+	// Head waiting for retry holds back later events of its aggregate only
+	for _, event := range heads {
+		var err error
+		if event.AggregateID == ada.ID {
+			err = outbox.MarkFailed(ctx, event.ID, now.Add(time.Hour), "unavailable")
+		} else {
+			err = outbox.MarkPublished(ctx, event.ID, now)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if held := claim(now.Add(2 * time.Minute)); len(held) != 0 {
+		t.Fatalf("claimed %+v behind event waiting for retry", held)
+	}
+	retried := claim(now.Add(2 * time.Hour))
+	if len(retried) != 1 || retried[0].Attempts != 1 || retried[0].LastError != "unavailable" {
+		t.Fatalf("claimed %+v, want retry of failed registration", retried)
+	}
+
+	// Dead lettered head lets the rest of its aggregate through
+	if err := outbox.MarkDead(ctx, retried[0].ID, now, "gone"); err != nil {
+		t.Fatal(err)
+	}
+	if rest := claim(now.Add(3 * time.Hour)); len(rest) != 1 || rest[0].Type != user.EventUserDeleted {
+		t.Fatalf("claimed %+v, want deletion of %s", rest, ada.Email)
+	}
+}
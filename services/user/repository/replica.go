@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gocraft/dbr/v2"
+)
+
+// replica read only session with health of its last check
+type replica struct {
+	session *dbr.Session
+	healthy int32
+}
+
+// Replicas routes reads across healthy replica sessions in turn, reads fall
+// back to primary while none of them is healthy. Replicas are healthy until
+// their first failed check
+type Replicas struct {
+	replicas []*replica
+	next     uint32
+	logger   log.Logger
+	interval time.Duration
+}
+
+// NewReplicas create instance of Replicas struct
+func NewReplicas(
+	sessions []*dbr.Session,
+	logger log.Logger,
+	interval time.Duration,
+) *Replicas {
+	replicas := make([]*replica, 0, len(sessions))
+	for _, sess := range sessions {
+		replicas = append(replicas, &replica{session: sess, healthy: 1})
+	}
+	return &Replicas{
+		replicas: replicas,
+		logger:   log.With(logger, "worker", "replicas"),
+		interval: interval,
+	}
+}
+
+// Run check health of replicas every interval until context is done, check
+// of replica times out after interval
+func (r *Replicas) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		for i, rep := range r.replicas {
+			r.check(ctx, i, rep)
+		}
+	}
+}
+
+// check ping replica and log when its health changed
+func (r *Replicas) check(ctx context.Context, i int, rep *replica) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+	err := rep.session.PingContext(ctx)
+	healthy := int32(1)
+	if err != nil {
+		healthy = 0
+	}
+	if atomic.SwapInt32(&rep.healthy, healthy) == healthy {
+		return
+	}
+	if err != nil {
+		_ = level.Warn(r.logger).Log("replica", i, "healthy", false, "err", err)
+		return
+	}
+	_ = level.Info(r.logger).Log("replica", i, "healthy", true)
+}
+
+// pick returns next healthy replica session, nil when there is none
+func (r *Replicas) pick() *dbr.Session {
+	if r == nil || len(r.replicas) == 0 {
+		return nil
+	}
+	start := atomic.AddUint32(&r.next, 1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint32(i))%uint32(len(r.replicas))]
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			return rep.session
+		}
+	}
+	return nil
+}
+
+// Close close sessions of all replicas
+func (r *Replicas) Close() error {
+	var err error
+	for _, rep := range r.replicas {
+		if closeErr := rep.session.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+type readPrimaryContextKey struct{}
+
+// ReadPrimary returns context whose reads go to primary instead of replicas,
+// for reads kept after they return such as cache fills which must not keep
+// what replica has not caught up with yet
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryContextKey{}, true)
+}
+
+// load run select built on session reads of context go to into value. Unit
+// of work that wrote already reads through its transaction on primary so it
+// sees its own writes, as do contexts of ReadPrimary, other reads go to
+// replica. Select failing on replica is retried once on primary rather than
+// waiting for next health check
+func load(
+	ctx context.Context,
+	sess *dbr.Session,
+	replicas *Replicas,
+	value interface{},
+	build func(runner dbr.SessionRunner) *dbr.SelectStmt,
+) (int, error) {
+	uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork)
+	if ok && uow.wrote() {
+		return build(uow.tx).LoadContext(ctx, value)
+	}
+	if ctx.Value(readPrimaryContextKey{}) != nil {
+		return build(primary(ctx, sess)).LoadContext(ctx, value)
+	}
+	if replica := replicas.pick(); replica != nil {
+		count, err := build(replica).LoadContext(ctx, value)
+		if err == nil || ctx.Err() != nil {
+			return count, err
+		}
+		_ = level.Warn(replicas.logger).Log("msg", "read failed on replica, retrying on primary", "err", err)
+		// Slice may hold rows loaded before failure
+		reset := reflect.ValueOf(value).Elem()
+		reset.Set(reflect.Zero(reset.Type()))
+	}
+	return build(primary(ctx, sess)).LoadContext(ctx, value)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestReadPrimary(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	// Replica which has not caught up with user registered on primary
+	lagging, _ := openSQLite(t)
+	replicas := NewReplicas([]*dbr.Session{lagging}, log.NewNopLogger(), time.Minute)
+	repo := NewUserRepository(sess, replicas)
+	registered := newUser("ada@example.com", time.Now())
+	if err := repo.Register(ctx, registered); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetUser(ctx, registered.ID); err != user.ErrUserNotFound {
+		t.Fatalf("get from replica: err = %v, want user not found", err)
+	}
+	if _, err := repo.GetUser(ReadPrimary(ctx), registered.ID); err != nil {
+		t.Fatalf("get from primary: %v", err)
+	}
+	if _, err := repo.Login(ReadPrimary(ctx), registered.Email, ""); err != nil {
+		t.Fatalf("login from primary: %v", err)
+	}
+}
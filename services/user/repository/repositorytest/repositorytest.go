@@ -0,0 +1,215 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// Factory create empty user repository for single test
+type Factory func(t *testing.T) user.Repository
+
+// Run check repositories created by factory keep contract of user
+// repository, every implementation runs it so they behave the same
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo user.Repository)
+	}{
+		{"UniqueEmail", testUniqueEmail},
+		{"NotFound", testNotFound},
+		{"SoftDelete", testSoftDelete},
+		{"StatusChange", testStatusChange},
+		{"ListPaging", testListPaging},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+// now time every user of suite is created at, whole seconds are kept by
+// every database
+var now = time.Now().UTC().Truncate(time.Second)
+
+// tenant create context of new tenant
+func tenant() context.Context {
+	return user.ContextWithTenant(context.Background(), uuid.NewV4())
+}
+
+// register store new active user of email in tenant of context
+func register(t *testing.T, ctx context.Context, repo user.Repository, email string, createdAt time.Time) user.User {
+	t.Helper()
+	u := user.User{
+		ID:        uuid.NewV4(),
+		Email:     email,
+		Passwords: "hash",
+		Role:      user.RoleUser,
+		Status:    user.StatusActive,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	if err := repo.Register(ctx, u); err != nil {
+		t.Fatalf("register %s: %v", email, err)
+	}
+	return u
+}
+
+// expect fail test when err is not want
+func expect(t *testing.T, what string, err, want error) {
+	t.Helper()
+	if err != want {
+		t.Fatalf("%s: err = %v, want %v", what, err, want)
+	}
+}
+
+func testUniqueEmail(t *testing.T, repo user.Repository) {
+	ctx := tenant()
+	ada := register(t, ctx, repo, "ada@example.com", now)
+	grace := register(t, ctx, repo, "grace@example.com", now)
+
+	taken := ada
+	taken.ID = uuid.NewV4()
+	expect(t, "register taken email", repo.Register(ctx, taken), user.ErrEmailTaken)
+	expect(t, "update to taken email", repo.UpdateUser(ctx, grace.ID, map[string]interface{}{
+		"email": ada.Email,
+	}), user.ErrEmailTaken)
+	// Update leaving user as it was still finds it
+	expect(t, "update to own email", repo.UpdateUser(ctx, grace.ID, map[string]interface{}{
+		"email": grace.Email,
+	}), nil)
+	// Email is unique within tenant only
+	register(t, tenant(), repo, ada.Email, now)
+	// Soft deleted users keep their email
+	expect(t, "delete", repo.DeleteUser(ctx, ada.ID, now), nil)
+	expect(t, "register email of deleted user", repo.Register(ctx, taken), user.ErrEmailTaken)
+}
+
+func testNotFound(t *testing.T, repo user.Repository) {
+	ctx := tenant()
+	other := register(t, tenant(), repo, "ada@example.com", now)
+	for _, id := range []uuid.UUID{uuid.NewV4(), other.ID} {
+		_, err := repo.GetUser(ctx, id)
+		expect(t, "get", err, user.ErrUserNotFound)
+		_, err = repo.FailLogin(ctx, id)
+		expect(t, "fail login", err, user.ErrUserNotFound)
+		expect(t, "update", repo.UpdateUser(ctx, id, map[string]interface{}{"name": "Ada"}), user.ErrUserNotFound)
+		expect(t, "change password", repo.ChangePassword(ctx, id, "hash", "", now), user.ErrUserNotFound)
+		expect(t, "delete", repo.DeleteUser(ctx, id, now), user.ErrUserNotFound)
+		expect(t, "restore", repo.RestoreUser(ctx, id, time.Time{}), user.ErrUserNotFound)
+	}
+	_, err := repo.Login(ctx, other.Email, "")
+	expect(t, "login", err, user.ErrUserNotFound)
+	_, err = repo.GetUser(context.Background(), other.ID)
+	expect(t, "get without tenant", err, user.ErrTenantRequired)
+}
+
+func testSoftDelete(t *testing.T, repo user.Repository) {
+	ctx := tenant()
+	ada := register(t, ctx, repo, "ada@example.com", now)
+	deletedAt := now.Add(time.Hour)
+	expect(t, "delete", repo.DeleteUser(ctx, ada.ID, deletedAt), nil)
+	expect(t, "delete again", repo.DeleteUser(ctx, ada.ID, deletedAt), user.ErrUserNotFound)
+	_, err := repo.GetUser(ctx, ada.ID)
+	expect(t, "get deleted", err, user.ErrUserNotFound)
+	expect(t, "update deleted", repo.UpdateUser(ctx, ada.ID, map[string]interface{}{"name": "Ada"}), user.ErrUserNotFound)
+	_, err = repo.Login(ctx, ada.Email, "")
+	expect(t, "login deleted", err, user.ErrUserNotFound)
+	listed, err := repo.ListUsers(ctx, user.ListQuery{OrderBy: []user.Sort{{Field: "created_at"}}, PageSize: 10})
+	expect(t, "list", err, nil)
+	if len(listed) != 0 {
+		t.Fatalf("listed %d deleted users", len(listed))
+	}
+
+	// Only deletions made since given time are restored
+	expect(t, "restore later deletion", repo.RestoreUser(ctx, ada.ID, deletedAt.Add(time.Second)), user.ErrUserNotFound)
+	expect(t, "restore", repo.RestoreUser(ctx, ada.ID, deletedAt), nil)
+	expect(t, "restore again", repo.RestoreUser(ctx, ada.ID, deletedAt), user.ErrUserNotFound)
+	if _, err := repo.GetUser(ctx, ada.ID); err != nil {
+		t.Fatalf("get restored: %v", err)
+	}
+
+	expect(t, "delete restored", repo.DeleteUser(ctx, ada.ID, deletedAt), nil)
+	purged, err := repo.PurgeUsers(ctx, deletedAt)
+	if err != nil || purged != 0 {
+		t.Fatalf("purge before deletion: purged %d, err %v", purged, err)
+	}
+	purged, err = repo.PurgeUsers(ctx, deletedAt.Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Fatalf("purge after deletion: purged %d, err %v, want 1", purged, err)
+	}
+	expect(t, "restore purged", repo.RestoreUser(ctx, ada.ID, time.Time{}), user.ErrUserNotFound)
+	// Purged user no longer holds its email
+	register(t, ctx, repo, ada.Email, now)
+}
+
+func testStatusChange(t *testing.T, repo user.Repository) {
+	ctx := tenant()
+	ada := register(t, ctx, repo, "ada@example.com", now)
+	failed, err := repo.FailLogin(ctx, ada.ID)
+	if err != nil || failed != 1 {
+		t.Fatalf("fail login: failed %d, err %v", failed, err)
+	}
+	change := func(from, to string) error {
+		return repo.ChangeStatus(ctx, user.StatusChange{
+			ID:         uuid.NewV4(),
+			UserID:     ada.ID,
+			FromStatus: from,
+			ToStatus:   to,
+			CreatedAt:  now,
+		})
+	}
+
+	expect(t, "suspend", change(user.StatusActive, user.StatusSuspended), nil)
+	// Status is changed only from the one caller saw
+	expect(t, "suspend again", change(user.StatusActive, user.StatusSuspended), user.ErrStatusTransition)
+	found, err := repo.GetUser(ctx, ada.ID)
+	expect(t, "get", err, nil)
+	if found.Status != user.StatusSuspended || found.FailedLogins != 0 {
+		t.Fatalf("status %s with %d failed logins, want suspended with none", found.Status, found.FailedLogins)
+	}
+	expect(t, "delete", repo.DeleteUser(ctx, ada.ID, now), nil)
+	expect(t, "reactivate deleted", change(user.StatusSuspended, user.StatusActive), user.ErrStatusTransition)
+}
+
+func testListPaging(t *testing.T, repo user.Repository) {
+	ctx := tenant()
+	register(t, tenant(), repo, "other@example.com", now)
+	// Pairs of users share creation time so id breaks ties
+	for i := 0; i < 5; i++ {
+		register(t, ctx, repo, fmt.Sprintf("user%d@example.com", i), now.Add(time.Duration(i/2)*time.Second))
+	}
+
+	query := user.ListQuery{OrderBy: []user.Sort{{Field: "created_at", Desc: true}}, PageSize: 2}
+	var listed []user.User
+	for pages := 0; pages < 5; pages++ {
+		page, err := repo.ListUsers(ctx, query)
+		expect(t, "list", err, nil)
+		if len(page) <= query.PageSize {
+			listed = append(listed, page...)
+			break
+		}
+		page = page[:query.PageSize]
+		listed = append(listed, page...)
+		cursor := user.NewCursor(query, page[len(page)-1])
+		query.After = &cursor
+	}
+
+	if len(listed) != 5 {
+		t.Fatalf("listed %d users, want 5 of tenant", len(listed))
+	}
+	for i := 1; i < len(listed); i++ {
+		previous, u := listed[i-1], listed[i]
+		if u.CreatedAt.After(previous.CreatedAt) ||
+			u.CreatedAt.Equal(previous.CreatedAt) && u.ID.String() <= previous.ID.String() {
+			t.Fatalf("%s listed after %s", u.Email, previous.Email)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type sessionRepository struct {
+	Session *dbr.Session
+}
+
+// NewSessionRepository create instances of session repo struct
+func NewSessionRepository(sess *dbr.Session) user.SessionRepository {
+	return &sessionRepository{
+		Session: sess,
+	}
+}
+
+// CreateSession database query logic, inserts session with UserLoggedIn event
+func (repo *sessionRepository) CreateSession(
+	ctx context.Context,
+	session user.Session,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	session.TenantID = tenantID
+	event, err := user.NewEvent(
+		user.EventUserLoggedIn,
+		session.UserID,
+		user.UserLoggedInPayload{
+			SessionID:   session.ID,
+			IP:          session.IP,
+			UserAgent:   session.UserAgent,
+			DeviceLabel: session.DeviceLabel,
+		},
+		session.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		_, err := tx.InsertInto("sessions").
+			Columns(
+				"id", "tenant_id", "user_id", "user_agent", "ip",
+				"device_label", "created_at", "last_seen_at",
+			).
+			Record(session).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, tenantID, event)
+	})
+}
+
+// GetSession database query logic
+func (repo *sessionRepository) GetSession(
+	ctx context.Context,
+	id uuid.UUID,
+) (*user.Session, error) {
+	var selectedSession *user.Session
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := primary(ctx, repo.Session).Select("*").
+		From("sessions").
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		LoadContext(ctx, &selectedSession)
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, user.ErrSessionNotFound
+	}
+	return selectedSession, nil
+}
+
+// ListSessions database query logic, returns active sessions of user most
+// recently seen first
+func (repo *sessionRepository) ListSessions(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]user.Session, error) {
+	var sessions []user.Session
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = primary(ctx, repo.Session).Select("*").
+		From("sessions").
+		Where(
+			"tenant_id = ? AND user_id = ? AND revoked_at IS NULL",
+			tenantID, userID,
+		).
+		OrderDesc("last_seen_at").
+		LoadContext(ctx, &sessions)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession database query logic, updates last seen time of session
+func (repo *sessionRepository) TouchSession(
+	ctx context.Context,
+	id uuid.UUID,
+	seenAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = runner(ctx, repo.Session).Update("sessions").
+		Set("last_seen_at", seenAt).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		ExecContext(ctx)
+	return err
+}
+
+// RevokeSession database query logic, only active session owned by user
+// can be revoked
+func (repo *sessionRepository) RevokeSession(
+	ctx context.Context,
+	userID, id uuid.UUID,
+	revokedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("sessions").
+		Set("revoked_at", revokedAt).
+		Where(
+			"tenant_id = ? AND user_id = ? AND id = ? AND revoked_at IS NULL",
+			tenantID, userID, id,
+		).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrSessionNotFound)
+}
+
+// RevokeSessions database query logic, revokes every active session of user
+func (repo *sessionRepository) RevokeSessions(
+	ctx context.Context,
+	userID uuid.UUID,
+	revokedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = runner(ctx, repo.Session).Update("sessions").
+		Set("revoked_at", revokedAt).
+		Where(
+			"tenant_id = ? AND user_id = ? AND revoked_at IS NULL",
+			tenantID, userID,
+		).
+		ExecContext(ctx)
+	return err
+}
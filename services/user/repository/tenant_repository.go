@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type tenantRepository struct {
+	Session  *dbr.Session
+	Replicas *Replicas
+}
+
+// NewTenantRepository create instances of tenant repo struct, tenants are
+// read from replicas when there are any
+func NewTenantRepository(sess *dbr.Session, replicas *Replicas) user.TenantRepository {
+	return &tenantRepository{
+		Session:  sess,
+		Replicas: replicas,
+	}
+}
+
+// GetTenant database query logic, key is either tenant id or slug
+func (repo *tenantRepository) GetTenant(
+	ctx context.Context,
+	key string,
+) (*user.Tenant, error) {
+	var selectedTenant *user.Tenant
+
+	rowsAffected, err := load(ctx, repo.Session, repo.Replicas, &selectedTenant, func(r dbr.SessionRunner) *dbr.SelectStmt {
+		stmt := r.Select("*").From("tenants")
+		// Slug is not compared with id column, uuid typed one rejects it
+		if id, err := uuid.FromString(key); err == nil {
+			return stmt.Where("id = ? OR slug = ?", id, key)
+		}
+		return stmt.Where("slug = ?", key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, user.ErrTenantNotFound
+	}
+	return selectedTenant, nil
+}
+
+// CreateTenant database query logic
+func (repo *tenantRepository) CreateTenant(
+	ctx context.Context,
+	tenant user.Tenant,
+) error {
+	_, err := runner(ctx, repo.Session).InsertInto("tenants").
+		Columns("id", "slug", "name", "created_at").
+		Record(tenant).
+		ExecContext(ctx)
+	if isDuplicate(err) {
+		return user.ErrTenantSlugTaken
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// unitOfWork transaction shared through context, savepoints counts nested
+// units so every one of them gets its own savepoint name and primary tells
+// whether it was run on already, reads are pinned to primary from then on.
+// Committed runs after commit callbacks once transaction commits
+type unitOfWork struct {
+	tx         *dbr.Tx
+	savepoints int
+	primary    int32
+	mutex      sync.Mutex
+	committed  []func()
+}
+
+// wrote tells whether unit of work ran anything on primary
+func (uow *unitOfWork) wrote() bool {
+	return atomic.LoadInt32(&uow.primary) == 1
+}
+
+type unitOfWorkContextKey struct{}
+
+type transactor struct {
+	Session *dbr.Session
+}
+
+// NewTransactor create transactor starting transactions on session
+func NewTransactor(sess *dbr.Session) user.Transactor {
+	return &transactor{
+		Session: sess,
+	}
+}
+
+// WithinTransaction run fn in transaction committed when fn returns nil and
+// rolled back otherwise, within another unit of work fn runs in savepoint
+func (t *transactor) WithinTransaction(
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) error {
+	return withinTransaction(ctx, t.Session, fn)
+}
+
+// withinTransaction run fn in unit of work of context or in new transaction
+// of session when context has none
+func withinTransaction(
+	ctx context.Context,
+	sess *dbr.Session,
+	fn func(ctx context.Context) error,
+) error {
+	if uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork); ok {
+		return uow.savepoint(ctx, fn)
+	}
+	tx, err := sess.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.RollbackUnlessCommitted()
+
+	uow := &unitOfWork{tx: tx}
+	if err := fn(context.WithValue(ctx, unitOfWorkContextKey{}, uow)); err != nil {
+		return contextError(ctx, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return contextError(ctx, err)
+	}
+	uow.mutex.Lock()
+	committed := uow.committed
+	uow.mutex.Unlock()
+	for _, fn := range committed {
+		fn()
+	}
+	return nil
+}
+
+// AfterCommit run fn once unit of work of context commits, right away when
+// context has none. Callbacks of rolled back unit of work never run, those
+// of rolled back savepoint still run when the transaction commits
+func AfterCommit(ctx context.Context, fn func()) {
+	uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork)
+	if !ok {
+		fn()
+		return
+	}
+	uow.mutex.Lock()
+	uow.committed = append(uow.committed, fn)
+	uow.mutex.Unlock()
+}
+
+// Pinned tells whether reads of context are pinned to primary since its unit
+// of work wrote already, they may see changes not committed yet
+func Pinned(ctx context.Context) bool {
+	uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork)
+	return ok && uow.wrote()
+}
+
+// contextError returns error of context when it is done, transaction is
+// rolled back once its context ends and calls after that fail with error
+// telling only that transaction is done
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// savepoint run fn in savepoint released when fn returns nil and rolled back
+// to otherwise, transaction itself stays open either way
+func (uow *unitOfWork) savepoint(
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) error {
+	uow.savepoints++
+	name := fmt.Sprintf("sp_%d", uow.savepoints)
+	if _, err := uow.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	released := false
+	defer func() {
+		if !released {
+			_, _ = uow.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if _, err := uow.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return err
+	}
+	released = true
+	return nil
+}
+
+// primary returns transaction of unit of work in context, or session when
+// called outside of one. Unlike runner it leaves reads of unit of work
+// free to go to replicas, so it is meant for reads only
+func primary(ctx context.Context, sess *dbr.Session) dbr.SessionRunner {
+	if uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork); ok {
+		return uow.tx
+	}
+	return sess
+}
+
+// runner returns transaction of unit of work in context, or session when
+// called outside of one, reads of unit of work are pinned to primary from
+// then on so they see what it wrote
+func runner(ctx context.Context, sess *dbr.Session) dbr.SessionRunner {
+	if uow, ok := ctx.Value(unitOfWorkContextKey{}).(*unitOfWork); ok {
+		atomic.StoreInt32(&uow.primary, 1)
+		return uow.tx
+	}
+	return sess
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gocraft/dbr/v2"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestTransactionCommitAndRollback(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewUserRepository(sess, nil)
+	transactor := NewTransactor(sess)
+	ada := newUser("ada@example.com", time.Now())
+	grace := newUser("grace@example.com", time.Now())
+
+	failed := errors.New("failed")
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Register(ctx, ada); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("err = %v, want %v", err, failed)
+	}
+	if _, err := repo.GetUser(ctx, ada.ID); err != user.ErrUserNotFound {
+		t.Fatalf("user of rolled back transaction: err = %v, want not found", err)
+	}
+
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Register(ctx, ada); err != nil {
+			return err
+		}
+		return repo.Register(ctx, grace)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []user.User{ada, grace} {
+		if _, err := repo.GetUser(ctx, u.ID); err != nil {
+			t.Fatalf("user %s of committed transaction: %v", u.Email, err)
+		}
+	}
+}
+
+func TestSavepoints(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewUserRepository(sess, nil)
+	transactor := NewTransactor(sess)
+	ada := newUser("ada@example.com", time.Now())
+	grace := newUser("grace@example.com", time.Now())
+	linus := newUser("linus@example.com", time.Now())
+
+	failed := errors.New("failed")
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Register(ctx, ada); err != nil {
+			return err
+		}
+		// Failed nested unit rolls back to its savepoint only
+		err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Register(ctx, grace); err != nil {
+				return err
+			}
+			return failed
+		})
+		if err != failed {
+			t.Errorf("nested err = %v, want %v", err, failed)
+		}
+		// Nested unit after rolled back one still commits with outer one
+		return transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			return repo.Register(ctx, linus)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []user.User{ada, linus} {
+		if _, err := repo.GetUser(ctx, u.ID); err != nil {
+			t.Errorf("user %s: %v", u.Email, err)
+		}
+	}
+	if _, err := repo.GetUser(ctx, grace.ID); err != user.ErrUserNotFound {
+		t.Errorf("user of rolled back savepoint: err = %v, want not found", err)
+	}
+}
+
+func TestAfterCommit(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	transactor := NewTransactor(sess)
+	var ran []string
+
+	AfterCommit(ctx, func() { ran = append(ran, "outside") })
+	if len(ran) != 1 {
+		t.Fatalf("callback outside unit of work ran %v, want right away", ran)
+	}
+
+	failed := errors.New("failed")
+	_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+		return failed
+	})
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, func() { ran = append(ran, "committed") })
+		_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { ran = append(ran, "savepoint") })
+			return failed
+		})
+		if len(ran) != 1 {
+			t.Errorf("callbacks ran %v before commit", ran)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"outside", "committed", "savepoint"}
+	if len(ran) != len(want) || ran[1] != want[1] || ran[2] != want[2] {
+		t.Fatalf("callbacks ran %v, want %v", ran, want)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	// Replica which never sees writes of primary
+	lagging, _ := openSQLite(t)
+	replicas := NewReplicas([]*dbr.Session{lagging}, log.NewNopLogger(), time.Minute)
+	repo := NewUserRepository(sess, replicas)
+	ada := newUser("ada@example.com", time.Now())
+
+	err := NewTransactor(sess).WithinTransaction(ctx, func(ctx context.Context) error {
+		if Pinned(ctx) {
+			t.Error("unit of work pinned before it wrote")
+		}
+		if err := repo.Register(ctx, ada); err != nil {
+			return err
+		}
+		if !Pinned(ctx) {
+			t.Error("unit of work not pinned after it wrote")
+		}
+		// Read of unit of work sees its own uncommitted write
+		_, err := repo.GetUser(ctx, ada.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Pinned(ctx) {
+		t.Error("context outside unit of work pinned")
+	}
+}
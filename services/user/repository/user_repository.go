@@ -2,58 +2,521 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
 
 	"github.com/muhammadisa/go-kit-boilerplate/services/user"
 )
 
 type repository struct {
-	Session *dbr.Session
+	Session  *dbr.Session
+	Replicas *Replicas
 }
 
-// NewUserRepository create instances of repo struct
-func NewUserRepository(sess *dbr.Session) user.Repository {
+// NewUserRepository create instances of repo struct, lookups and listing
+// are read from replicas when there are any
+func NewUserRepository(sess *dbr.Session, replicas *Replicas) user.Repository {
 	return &repository{
-		Session: sess,
+		Session:  sess,
+		Replicas: replicas,
 	}
 }
 
-// Register database query logic
+// Register database query logic, inserts user with UserRegistered event
 func (repo *repository) Register(
-	_ context.Context,
-	user user.User,
+	ctx context.Context,
+	newUser user.User,
 ) error {
-	var err error
-
-	_, err = repo.Session.InsertInto("users").
-		Columns("id", "email", "passwords", "created_at").
-		Record(user).
-		Exec()
+	tenantID, err := scope(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	newUser.TenantID = tenantID
+	event, err := user.NewEvent(
+		user.EventUserRegistered,
+		newUser.ID,
+		user.UserRegisteredPayload{Email: newUser.Email, Role: newUser.Role},
+		newUser.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		_, err := tx.InsertInto("users").
+			Columns("id", "tenant_id", "email", "passwords", "role", "status", "created_at", "updated_at").
+			Record(newUser).
+			ExecContext(ctx)
+		if isDuplicate(err) {
+			return user.ErrEmailTaken
+		}
+		if err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, tenantID, event)
+	})
 }
 
 // Login database query logic
 func (repo *repository) Login(
-	_ context.Context,
+	ctx context.Context,
 	email, _ string,
 ) (*user.User, error) {
-	var err error
 	var selectedUser *user.User
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	rowsAffected, err := repo.Session.Select("*").
-		From("users").
-		Where("email = ?", email).
-		Load(&selectedUser)
+	rowsAffected, err := load(ctx, repo.Session, repo.Replicas, &selectedUser, func(r dbr.SessionRunner) *dbr.SelectStmt {
+		return r.Select("*").
+			From("users").
+			Where("tenant_id = ? AND email = ? AND deleted_at IS NULL", tenantID, email)
+	})
+	if err != nil {
+		return nil, err
+	}
 	if rowsAffected == 0 {
-		return nil, errors.New("user not found")
+		return nil, user.ErrUserNotFound
+	}
+	return selectedUser, nil
+}
+
+// GetUser database query logic
+func (repo *repository) GetUser(
+	ctx context.Context,
+	id uuid.UUID,
+) (*user.User, error) {
+	var selectedUser *user.User
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	rowsAffected, err := load(ctx, repo.Session, repo.Replicas, &selectedUser, func(r dbr.SessionRunner) *dbr.SelectStmt {
+		return r.Select("*").
+			From("users").
+			Where("tenant_id = ? AND id = ? AND deleted_at IS NULL", tenantID, id)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if rowsAffected == 0 {
+		return nil, user.ErrUserNotFound
+	}
 	return selectedUser, nil
 }
+
+// UpdateUser database query logic, only given columns are updated. Users
+// of other tenants and deleted ones are not found
+func (repo *repository) UpdateUser(
+	ctx context.Context,
+	id uuid.UUID,
+	columns map[string]interface{},
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("users").
+		SetMap(columns).
+		Where("tenant_id = ? AND id = ? AND deleted_at IS NULL", tenantID, id).
+		ExecContext(ctx)
+	if isDuplicate(err) {
+		return user.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrUserNotFound)
+}
+
+// ChangePassword database query logic, replaces password hash with
+// PasswordChanged event
+func (repo *repository) ChangePassword(
+	ctx context.Context,
+	id uuid.UUID,
+	hashedPassword, changedBy string,
+	changedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	event, err := user.NewEvent(
+		user.EventPasswordChanged,
+		id,
+		user.PasswordChangedPayload{ChangedBy: changedBy, ChangedAt: changedAt},
+		changedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.Update("users").
+			Set("passwords", hashedPassword).
+			Set("updated_at", changedAt).
+			Where("tenant_id = ? AND id = ? AND deleted_at IS NULL", tenantID, id).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, user.ErrUserNotFound); err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, tenantID, event)
+	})
+}
+
+// DeleteUser database query logic, marks user as soft deleted with
+// UserDeleted event
+func (repo *repository) DeleteUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	event, err := user.NewEvent(
+		user.EventUserDeleted,
+		id,
+		user.UserDeletedPayload{DeletedAt: deletedAt},
+		deletedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.Update("users").
+			Set("deleted_at", deletedAt).
+			Where("tenant_id = ? AND id = ? AND deleted_at IS NULL", tenantID, id).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, user.ErrUserNotFound); err != nil {
+			return err
+		}
+		return insertEvent(ctx, tx, tenantID, event)
+	})
+}
+
+// RestoreUser database query logic, clears soft delete made after given time
+func (repo *repository) RestoreUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAfter time.Time,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("users").
+		Set("deleted_at", nil).
+		Where("tenant_id = ? AND id = ? AND deleted_at >= ?", tenantID, id, deletedAfter).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, user.ErrUserNotFound)
+}
+
+// PurgeUsers database query logic, hard deletes users soft deleted before
+// given time across all tenants together with everything recorded about
+// them: sessions, memberships, invitations sent to their email, status
+// history and their outbox events with webhook deliveries of those.
+// Organizations losing their last owner get one of remaining members
+// promoted, admins first, and are deleted when no member remains
+func (repo *repository) PurgeUsers(
+	ctx context.Context,
+	deletedBefore time.Time,
+) (int64, error) {
+	var purgedUsers int64
+	err := withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		purged := tx.Select("id").
+			From("users").
+			Where("deleted_at < ?", deletedBefore)
+		var owned []uuid.UUID
+		_, err := tx.Select("DISTINCT organization_id").
+			From("organization_members").
+			Where("role = ? AND user_id IN ?", user.OrgRoleOwner, purged).
+			LoadContext(ctx, &owned)
+		if err != nil {
+			return err
+		}
+		deletes := []*dbr.DeleteStmt{
+			tx.DeleteFrom("webhook_deliveries").
+				Where("event_id IN ?", tx.Select("id").
+					From("outbox_events").
+					Where("aggregate_id IN ?", purged)),
+			tx.DeleteFrom("outbox_events").
+				Where("aggregate_id IN ?", purged),
+			tx.DeleteFrom("sessions").
+				Where("user_id IN ?", purged),
+			tx.DeleteFrom("organization_members").
+				Where("user_id IN ?", purged),
+			tx.DeleteFrom("user_status_history").
+				Where("user_id IN ?", purged),
+			tx.DeleteFrom("organization_invitations").
+				Where(
+					"EXISTS (SELECT 1 FROM users WHERE users.tenant_id = organization_invitations.tenant_id "+
+						"AND users.email = organization_invitations.email AND users.deleted_at < ?)",
+					deletedBefore,
+				),
+		}
+		for _, stmt := range deletes {
+			if _, err := stmt.ExecContext(ctx); err != nil {
+				return err
+			}
+		}
+		result, err := tx.DeleteFrom("users").
+			Where("deleted_at < ?", deletedBefore).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		purgedUsers, err = result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		for _, organizationID := range owned {
+			if err := keepOwner(ctx, tx, organizationID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return purgedUsers, err
+}
+
+// keepOwner promote longest standing admin, or member when there is none, of
+// organization left without owner. Organization left without members is
+// deleted with its invitations
+func keepOwner(ctx context.Context, tx dbr.SessionRunner, organizationID uuid.UUID) error {
+	var owners int
+	err := tx.Select("COUNT(*)").
+		From("organization_members").
+		Where("organization_id = ? AND role = ?", organizationID, user.OrgRoleOwner).
+		LoadOneContext(ctx, &owners)
+	if err != nil || owners > 0 {
+		return err
+	}
+	var successors []uuid.UUID
+	_, err = tx.Select("user_id").
+		From("organization_members").
+		Where("organization_id = ?", organizationID).
+		OrderBy(fmt.Sprintf("CASE role WHEN '%s' THEN 0 ELSE 1 END", user.OrgRoleAdmin)).
+		OrderAsc("created_at").
+		Limit(1).
+		LoadContext(ctx, &successors)
+	if err != nil {
+		return err
+	}
+	if len(successors) == 1 {
+		_, err := tx.Update("organization_members").
+			Set("role", user.OrgRoleOwner).
+			Where("organization_id = ? AND user_id = ?", organizationID, successors[0]).
+			ExecContext(ctx)
+		return err
+	}
+	_, err = tx.DeleteFrom("organization_invitations").
+		Where("organization_id = ?", organizationID).
+		ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.DeleteFrom("organizations").
+		Where("id = ?", organizationID).
+		ExecContext(ctx)
+	return err
+}
+
+// ChangeStatus database query logic, updates user status only when it is
+// still in previous status and records the change into history. Count of
+// failed logins starts over with new status
+func (repo *repository) ChangeStatus(
+	ctx context.Context,
+	change user.StatusChange,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.Update("users").
+			Set("status", change.ToStatus).
+			Set("failed_logins", 0).
+			Set("updated_at", change.CreatedAt).
+			Where(
+				"tenant_id = ? AND id = ? AND status = ? AND deleted_at IS NULL",
+				tenantID, change.UserID, change.FromStatus,
+			).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return user.ErrStatusTransition
+		}
+		_, err = tx.InsertInto("user_status_history").
+			Columns("id", "user_id", "from_status", "to_status", "reason", "actor_id", "created_at").
+			Record(change).
+			ExecContext(ctx)
+		return err
+	})
+}
+
+// FailLogin database query logic, counts failed login of user and returns
+// failed logins since last successful one
+func (repo *repository) FailLogin(
+	ctx context.Context,
+	id uuid.UUID,
+) (int, error) {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var failed int
+	err = withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.Update("users").
+			Set("failed_logins", dbr.Expr("failed_logins + 1")).
+			Where("tenant_id = ? AND id = ? AND deleted_at IS NULL", tenantID, id).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, user.ErrUserNotFound); err != nil {
+			return err
+		}
+		return tx.Select("failed_logins").
+			From("users").
+			Where("tenant_id = ? AND id = ?", tenantID, id).
+			LoadOneContext(ctx, &failed)
+	})
+	return failed, err
+}
+
+// ListUsers database query logic, returns up to page size plus one users
+func (repo *repository) ListUsers(
+	ctx context.Context,
+	query user.ListQuery,
+) ([]user.User, error) {
+	var users []user.User
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var after dbr.Builder
+	if query.After != nil {
+		after, err = afterCursor(query.OrderBy, *query.After)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = load(ctx, repo.Session, repo.Replicas, &users, func(r dbr.SessionRunner) *dbr.SelectStmt {
+		stmt := r.Select("*").
+			From("users").
+			Where("tenant_id = ? AND deleted_at IS NULL", tenantID)
+		filter := query.Filter
+		if filter.EmailPrefix != "" {
+			stmt = stmt.Where("email LIKE ? ESCAPE '!'", escapeLike(filter.EmailPrefix)+"%")
+		}
+		if filter.Status != "" {
+			stmt = stmt.Where("status = ?", filter.Status)
+		}
+		if filter.Role != "" {
+			stmt = stmt.Where("role = ?", filter.Role)
+		}
+		if !filter.CreatedAfter.IsZero() {
+			stmt = stmt.Where("created_at >= ?", filter.CreatedAfter)
+		}
+		if !filter.CreatedBefore.IsZero() {
+			stmt = stmt.Where("created_at < ?", filter.CreatedBefore)
+		}
+		if after != nil {
+			stmt = stmt.Where(after)
+		}
+		for _, sort := range query.OrderBy {
+			stmt = stmt.OrderDir(sort.Field, !sort.Desc)
+		}
+		return stmt.OrderAsc("id").Limit(uint64(query.PageSize + 1))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// afterCursor build keyset condition selecting rows after cursor, e.g.
+// (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func afterCursor(sorts []user.Sort, cursor user.Cursor) (dbr.Builder, error) {
+	var conditions []dbr.Builder
+	var equals []dbr.Builder
+	for i, sort := range sorts {
+		value, err := cursor.Value(i, sort.Field)
+		if err != nil {
+			return nil, err
+		}
+		next := dbr.Gt(sort.Field, value)
+		if sort.Desc {
+			next = dbr.Lt(sort.Field, value)
+		}
+		conditions = append(conditions, and(equals, next))
+		equals = append(equals, dbr.Eq(sort.Field, value))
+	}
+	conditions = append(conditions, and(equals, dbr.Gt("id", cursor.ID)))
+	return dbr.Or(conditions...), nil
+}
+
+// and join copy of equals with last condition
+func and(equals []dbr.Builder, last dbr.Builder) dbr.Builder {
+	conditions := make([]dbr.Builder, 0, len(equals)+1)
+	conditions = append(conditions, equals...)
+	return dbr.And(append(conditions, last)...)
+}
+
+// scope returns tenant id of context every query must be filtered by
+func scope(ctx context.Context) (uuid.UUID, error) {
+	return user.TenantFromContext(ctx)
+}
+
+// requireAffected returns not found error when no row affected
+func requireAffected(result sql.Result, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// escapeLike escape LIKE wildcard characters, escape character is given
+// explicitly since backslash is not one on every dialect
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
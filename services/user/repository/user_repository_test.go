@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/migration"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/repositorytest"
+)
+
+// newUser create active user of email registered at given time
+func newUser(email string, createdAt time.Time) user.User {
+	return user.User{
+		ID:        uuid.NewV4(),
+		Email:     email,
+		Passwords: "hash",
+		Role:      user.RoleUser,
+		Status:    user.StatusActive,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) user.Repository {
+		sess, _ := openSQLite(t)
+		return NewUserRepository(sess, nil)
+	})
+}
+
+// TestUserRepositoryContractSQL runs contract against mysql and postgres
+// databases named by TEST_MYSQL_DSN and TEST_POSTGRES_DSN, users of those
+// databases are deleted by every test
+func TestUserRepositoryContractSQL(t *testing.T) {
+	for driver, env := range map[string]string{
+		DriverMySQL:    "TEST_MYSQL_DSN",
+		DriverPostgres: "TEST_POSTGRES_DSN",
+	} {
+		driver, dsn := driver, os.Getenv(env)
+		t.Run(driver, func(t *testing.T) {
+			if dsn == "" {
+				t.Skip(env + " is not set")
+			}
+			repositorytest.Run(t, func(t *testing.T) user.Repository {
+				conn, err := Open(driver, dsn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { _ = conn.Close() })
+				sess := conn.NewSession(nil)
+				migrator, err := migration.NewMigrator(sess)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := migrator.Up(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := sess.DeleteFrom("users").Exec(); err != nil {
+					t.Fatal(err)
+				}
+				return NewUserRepository(sess, nil)
+			})
+		})
+	}
+}
+
+func TestListUsersCursor(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewUserRepository(sess, nil)
+	// Pairs of users share creation time so id breaks ties
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		registered := newUser(fmt.Sprintf("user%d@example.com", i), base.Add(time.Duration(i/2)*time.Millisecond))
+		if err := repo.Register(ctx, registered); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		orderBy string
+		less    func(a, b user.User) bool
+	}{
+		{"created ascending", "created_at", func(a, b user.User) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID.String() < b.ID.String()
+		}},
+		{"created descending", "created_at desc", func(a, b user.User) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID.String() < b.ID.String()
+		}},
+		{"email descending", "email desc", func(a, b user.User) bool {
+			return a.Email > b.Email
+		}},
+	}
+	for _, tt := range tests {
+		orderBy, err := user.ParseOrderBy(tt.orderBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := user.ListQuery{OrderBy: orderBy, PageSize: 2}
+		var listed []user.User
+		for pages := 0; ; pages++ {
+			if pages > 7 {
+				t.Fatalf("%s: paging does not end", tt.name)
+			}
+			page, err := repo.ListUsers(ctx, query)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			more := len(page) > query.PageSize
+			if more {
+				page = page[:query.PageSize]
+			}
+			listed = append(listed, page...)
+			if !more {
+				break
+			}
+			cursor := user.NewCursor(query, page[len(page)-1])
+			query.After = &cursor
+		}
+
+		if len(listed) != 7 {
+			t.Fatalf("%s: listed %d users, want 7", tt.name, len(listed))
+		}
+		seen := map[uuid.UUID]bool{}
+		for i, u := range listed {
+			if seen[u.ID] {
+				t.Fatalf("%s: %s listed twice", tt.name, u.Email)
+			}
+			seen[u.ID] = true
+			if i > 0 && !tt.less(listed[i-1], u) {
+				t.Fatalf("%s: %s listed after %s", tt.name, u.Email, listed[i-1].Email)
+			}
+		}
+	}
+}
+
+func TestListUsersEmailPrefix(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewUserRepository(sess, nil)
+	now := time.Now()
+	for _, email := range []string{"a_b@example.com", "axb@example.com", "100%@example.com", "100x@example.com"} {
+		if err := repo.Register(ctx, newUser(email, now)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Wildcard characters of prefix match only themselves
+	for prefix, want := range map[string][]string{
+		"a_":   {"a_b@example.com"},
+		"100%": {"100%@example.com"},
+		"a":    {"a_b@example.com", "axb@example.com"},
+	} {
+		listed, err := repo.ListUsers(ctx, user.ListQuery{
+			Filter:   user.ListFilter{EmailPrefix: prefix},
+			OrderBy:  []user.Sort{{Field: "email"}},
+			PageSize: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var emails []string
+		for _, u := range listed {
+			emails = append(emails, u.Email)
+		}
+		if !reflect.DeepEqual(emails, want) {
+			t.Errorf("prefix %q listed %v, want %v", prefix, emails, want)
+		}
+	}
+}
+
+func TestQueryContext(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	repo := NewUserRepository(sess, nil)
+	ada := newUser("ada@example.com", time.Now())
+	if err := repo.Register(ctx, ada); err != nil {
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	expired, cancelExpired := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	for name, tt := range map[string]struct {
+		ctx  context.Context
+		want error
+	}{
+		"canceled": {canceled, context.Canceled},
+		"expired":  {expired, context.DeadlineExceeded},
+	} {
+		calls := map[string]error{
+			"register": repo.Register(tt.ctx, newUser("grace@example.com", time.Now())),
+			"update":   repo.UpdateUser(tt.ctx, ada.ID, map[string]interface{}{"name": "Ada"}),
+			"delete":   repo.DeleteUser(tt.ctx, ada.ID, time.Now()),
+		}
+		_, calls["get"] = repo.GetUser(tt.ctx, ada.ID)
+		_, calls["login"] = repo.Login(tt.ctx, ada.Email, "")
+		_, calls["list"] = repo.ListUsers(tt.ctx, user.ListQuery{PageSize: 10})
+		calls["transaction"] = NewTransactor(sess).WithinTransaction(tt.ctx, func(ctx context.Context) error {
+			return repo.Register(ctx, newUser("grace@example.com", time.Now()))
+		})
+		for call, err := range calls {
+			if err != tt.want {
+				t.Errorf("%s %s: err = %v, want %v", name, call, err, tt.want)
+			}
+		}
+	}
+	if got, err := repo.GetUser(ctx, ada.ID); err != nil || got.Name != "" {
+		t.Fatalf("user changed by calls of done context: %+v, err %v", got, err)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	sess, ctx := openSQLite(t)
+	ada := newUser("ada@example.com", time.Now())
+	if err := NewUserRepository(sess, nil).Register(ctx, ada); err != nil {
+		t.Fatal(err)
+	}
+	// Default timeout of session ends queries whose context has none
+	timed := sess.Connection.NewSession(nil)
+	timed.Timeout = time.Nanosecond
+	if _, err := NewUserRepository(timed, nil).GetUser(ctx, ada.ID); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocraft/dbr/v2"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+type webhookRepository struct {
+	Session *dbr.Session
+}
+
+// NewWebhookRepository create instances of webhook repo struct
+func NewWebhookRepository(sess *dbr.Session) user.WebhookRepository {
+	return &webhookRepository{
+		Session: sess,
+	}
+}
+
+// CreateWebhook database query logic
+func (repo *webhookRepository) CreateWebhook(
+	ctx context.Context,
+	webhook user.Webhook,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	webhook.TenantID = tenantID
+
+	_, err = runner(ctx, repo.Session).InsertInto("webhooks").
+		Columns("id", "tenant_id", "url", "events", "secret", "created_by", "created_at").
+		Record(webhook).
+		ExecContext(ctx)
+	return err
+}
+
+// ListWebhooks database query logic
+func (repo *webhookRepository) ListWebhooks(
+	ctx context.Context,
+) ([]user.Webhook, error) {
+	var webhooks []user.Webhook
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = primary(ctx, repo.Session).Select("*").
+		From("webhooks").
+		Where("tenant_id = ?", tenantID).
+		OrderAsc("created_at").
+		LoadContext(ctx, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook database query logic, deletes webhook with its deliveries
+func (repo *webhookRepository) DeleteWebhook(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return err
+	}
+	return withinTransaction(ctx, repo.Session, func(ctx context.Context) error {
+		tx := runner(ctx, repo.Session)
+		result, err := tx.DeleteFrom("webhooks").
+			Where("tenant_id = ? AND id = ?", tenantID, id).
+			ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, user.ErrWebhookNotFound); err != nil {
+			return err
+		}
+		_, err = tx.DeleteFrom("webhook_deliveries").
+			Where("tenant_id = ? AND webhook_id = ?", tenantID, id).
+			ExecContext(ctx)
+		return err
+	})
+}
+
+// Subscribers database query logic, returns webhooks of tenant subscribed
+// to event type
+func (repo *webhookRepository) Subscribers(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	eventType string,
+) ([]user.Webhook, error) {
+	var webhooks, subscribers []user.Webhook
+	_, err := primary(ctx, repo.Session).Select("*").
+		From("webhooks").
+		Where("tenant_id = ?", tenantID).
+		LoadContext(ctx, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(eventType) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	return subscribers, nil
+}
+
+// CreateDeliveries database query logic, inserts deliveries at once
+func (repo *webhookRepository) CreateDeliveries(
+	ctx context.Context,
+	deliveries []user.WebhookDelivery,
+) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	stmt := runner(ctx, repo.Session).InsertInto("webhook_deliveries").
+		Columns(
+			"id", "tenant_id", "webhook_id", "event_id", "event_type",
+			"payload", "status", "attempts", "next_attempt_at", "created_at",
+		)
+	for i := range deliveries {
+		stmt = stmt.Record(&deliveries[i])
+	}
+	_, err := stmt.ExecContext(ctx)
+	return err
+}
+
+// ClaimDeliveries database query logic, leases up to limit pending
+// deliveries across tenants whose next attempt is due, deliveries leased by
+// another dispatcher are skipped
+func (repo *webhookRepository) ClaimDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]user.DueDelivery, error) {
+	var ids []uuid.UUID
+	_, err := primary(ctx, repo.Session).Select("id").
+		From("webhook_deliveries").
+		Where("status = ? AND next_attempt_at <= ?", user.DeliveryPending, now).
+		Where("leased_until IS NULL OR leased_until <= ?", now).
+		OrderAsc("next_attempt_at").
+		Limit(uint64(limit)).
+		LoadContext(ctx, &ids)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	// Lease is taken only where it is still free, dispatcher racing for the
+	// same deliveries gets those it leased first
+	leaseID := uuid.NewV4()
+	_, err = runner(ctx, repo.Session).Update("webhook_deliveries").
+		Set("lease_id", leaseID).
+		Set("leased_until", now.Add(lease)).
+		Where("id IN ?", ids).
+		Where("leased_until IS NULL OR leased_until <= ?", now).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []user.DueDelivery
+	_, err = primary(ctx, repo.Session).Select("d.*", "w.url", "w.secret").
+		From(dbr.I("webhook_deliveries").As("d")).
+		Join(dbr.I("webhooks").As("w"), "w.id = d.webhook_id").
+		Where("d.lease_id = ?", leaseID).
+		OrderAsc("d.next_attempt_at").
+		LoadContext(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt database query logic, stores outcome of delivery attempt and
+// releases its lease
+func (repo *webhookRepository) RecordAttempt(
+	ctx context.Context,
+	id uuid.UUID,
+	attempt user.DeliveryAttempt,
+) error {
+	stmt := runner(ctx, repo.Session).Update("webhook_deliveries").
+		Set("status", attempt.Status).
+		Set("leased_until", nil).
+		Set("attempts", dbr.Expr("attempts + 1")).
+		Set("last_status_code", attempt.StatusCode).
+		Set("last_error", attempt.Error).
+		Set("next_attempt_at", attempt.NextAttemptAt)
+	if attempt.Status == user.DeliveryDelivered {
+		stmt = stmt.Set("delivered_at", attempt.AttemptedAt)
+	}
+	_, err := stmt.Where("id = ?", id).ExecContext(ctx)
+	return err
+}
+
+// ListDeliveries database query logic, returns latest deliveries of webhook
+func (repo *webhookRepository) ListDeliveries(
+	ctx context.Context,
+	webhookID uuid.UUID,
+	status string,
+	limit int,
+) ([]user.WebhookDelivery, error) {
+	var deliveries []user.WebhookDelivery
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := primary(ctx, repo.Session).Select("*").
+		From("webhook_deliveries").
+		Where("tenant_id = ? AND webhook_id = ?", tenantID, webhookID)
+	if status != "" {
+		stmt = stmt.Where("status = ?", status)
+	}
+	_, err = stmt.OrderDesc("created_at").
+		Limit(uint64(limit)).
+		LoadContext(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver database query logic, schedules delivery for immediate attempt
+// whatever its status, dead delivery is given one more attempt
+func (repo *webhookRepository) Redeliver(
+	ctx context.Context,
+	id uuid.UUID,
+	now time.Time,
+) (*user.WebhookDelivery, error) {
+	var selectedDelivery *user.WebhookDelivery
+	tenantID, err := scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := runner(ctx, repo.Session).Update("webhook_deliveries").
+		Set("status", user.DeliveryPending).
+		Set("next_attempt_at", now).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		ExecContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(result, user.ErrDeliveryNotFound); err != nil {
+		return nil, err
+	}
+	_, err = primary(ctx, repo.Session).Select("*").
+		From("webhook_deliveries").
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		LoadContext(ctx, &selectedDelivery)
+	if err != nil {
+		return nil, err
+	}
+	return selectedDelivery, nil
+}
@@ -5,5 +5,37 @@ import "context"
 // Service interface
 type Service interface {
 	Register(ctx context.Context, email, passwords string) (string, error)
-	Login(ctx context.Context, email, passwords string) (string, error)
+	Login(ctx context.Context, email, passwords, deviceLabel string) (string, error)
+	GetMe(ctx context.Context) (*User, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	UpdateUser(ctx context.Context, id string, update Update) (*User, error)
+	ChangePassword(ctx context.Context, id, currentPassword, newPassword string) error
+	DeleteUser(ctx context.Context, id string) error
+	RestoreUser(ctx context.Context, id string) (*User, error)
+	SuspendUser(ctx context.Context, id, reason string) (*User, error)
+	ReactivateUser(ctx context.Context, id, reason string) (*User, error)
+	DeactivateUser(ctx context.Context, id, reason string) (*User, error)
+	ListUsers(ctx context.Context, filter ListFilter, orderBy string, pageSize int, pageToken string) ([]User, string, error)
+	ListSessions(ctx context.Context) ([]Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	ListAuditEvents(ctx context.Context, filter AuditFilter, pageSize int, pageToken string) ([]AuditEvent, string, error)
+
+	CreateOrganization(ctx context.Context, name string) (*Organization, error)
+	ListOrganizations(ctx context.Context) ([]Organization, error)
+	InviteMember(ctx context.Context, organizationID, email, role string) (*Invitation, string, error)
+	AcceptInvitation(ctx context.Context, token string) (*Membership, error)
+	DeclineInvitation(ctx context.Context, token string) error
+	ListMembers(ctx context.Context, organizationID string) ([]Membership, error)
+	ChangeMemberRole(ctx context.Context, organizationID, userID, role string) (*Membership, error)
+	RemoveMember(ctx context.Context, organizationID, userID string) error
+	LeaveOrganization(ctx context.Context, organizationID string) error
+
+	CreateWebhook(ctx context.Context, url string, events []string) (*Webhook, string, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhookDeliveries(ctx context.Context, webhookID, status string) ([]WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID string) (*WebhookDelivery, error)
 }
+
+// ServiceMiddleware decorates Service with cross cutting behavior
+type ServiceMiddleware func(Service) Service
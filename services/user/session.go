@@ -0,0 +1,83 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SessionTouchInterval minimum interval between last seen updates of session
+const SessionTouchInterval = time.Minute
+
+// Session model struct, one session is recorded per login
+type Session struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	TenantID    uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	IP          string     `json:"ip" db:"ip"`
+	DeviceLabel string     `json:"device_label" db:"device_label"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Current     bool       `json:"current" db:"-"`
+}
+
+// SessionRepository interface for session, every query is scoped to tenant
+// of context
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, id uuid.UUID) (*Session, error)
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	TouchSession(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+	RevokeSession(ctx context.Context, userID, id uuid.UUID, revokedAt time.Time) error
+	RevokeSessions(ctx context.Context, userID uuid.UUID, revokedAt time.Time) error
+}
+
+// Client request metadata of caller
+type Client struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type clientContextKey struct{}
+
+// ContextWithClient returns context carrying client metadata
+func ContextWithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext get client metadata, empty when transport did not
+// capture it
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientContextKey{}).(Client)
+	return client
+}
+
+// deviceNames known platforms matched against user agent, in match order
+var deviceNames = []struct {
+	token string
+	name  string
+}{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Macintosh", "Mac"},
+	{"Linux", "Linux"},
+	{"grpc-go", "gRPC client"},
+	{"curl", "curl"},
+}
+
+// DeviceLabel derive human readable device name from user agent
+func DeviceLabel(userAgent string) string {
+	for _, device := range deviceNames {
+		if strings.Contains(userAgent, device.token) {
+			return device.name
+		}
+	}
+	return "Unknown device"
+}
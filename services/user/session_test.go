@@ -0,0 +1,23 @@
+package user_test
+
+import (
+	"testing"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestDeviceLabel(t *testing.T) {
+	for userAgent, want := range map[string]string{
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X)": "iPhone",
+		"Mozilla/5.0 (Linux; Android 11; Pixel 5)":               "Android",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)":        "Mac",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64)":              "Windows",
+		"grpc-go/1.33.1": "gRPC client",
+		"curl/7.68.0":    "curl",
+		"":               "Unknown device",
+	} {
+		if label := user.DeviceLabel(userAgent); label != want {
+			t.Errorf("%q: label %q, want %q", userAgent, label, want)
+		}
+	}
+}
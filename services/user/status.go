@@ -0,0 +1,72 @@
+package user
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// User statuses
+const (
+	StatusPending     = "pending"
+	StatusActive      = "active"
+	StatusSuspended   = "suspended"
+	StatusLocked      = "locked"
+	StatusDeactivated = "deactivated"
+)
+
+// MaxFailedLogins consecutive failed logins locking active user until admin
+// reactivates them
+const MaxFailedLogins = 5
+
+// SystemActor actor of status changes made by service itself
+const SystemActor = "system"
+
+// transitions allowed next statuses of each status
+var transitions = map[string][]string{
+	StatusPending:     {StatusActive, StatusDeactivated},
+	StatusActive:      {StatusSuspended, StatusLocked, StatusDeactivated},
+	StatusSuspended:   {StatusActive, StatusDeactivated},
+	StatusLocked:      {StatusActive, StatusSuspended, StatusDeactivated},
+	StatusDeactivated: {StatusActive},
+}
+
+// StatusChange status history record
+type StatusChange struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	ActorID    string    `json:"actor_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CanTransition check whether status may change from one to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusError returns error preventing user with status from logging in,
+// nil means user may log in
+func StatusError(status string) error {
+	switch status {
+	case StatusActive:
+		return nil
+	case StatusPending:
+		return ErrAccountPending
+	case StatusSuspended:
+		return ErrAccountSuspended
+	case StatusLocked:
+		return ErrAccountLocked
+	case StatusDeactivated:
+		return ErrAccountDeactivated
+	default:
+		return ErrInvalidStatus
+	}
+}
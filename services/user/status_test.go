@@ -0,0 +1,45 @@
+package user_test
+
+import (
+	"testing"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{user.StatusPending, user.StatusActive, true},
+		{user.StatusPending, user.StatusSuspended, false},
+		{user.StatusActive, user.StatusLocked, true},
+		{user.StatusActive, user.StatusPending, false},
+		{user.StatusLocked, user.StatusActive, true},
+		{user.StatusSuspended, user.StatusLocked, false},
+		{user.StatusDeactivated, user.StatusActive, true},
+		{user.StatusDeactivated, user.StatusSuspended, false},
+		{user.StatusActive, user.StatusActive, false},
+		{"unknown", user.StatusActive, false},
+	}
+	for _, tt := range tests {
+		if allowed := user.CanTransition(tt.from, tt.to); allowed != tt.allowed {
+			t.Errorf("%s to %s: allowed %v, want %v", tt.from, tt.to, allowed, tt.allowed)
+		}
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	for status, want := range map[string]error{
+		user.StatusActive:      nil,
+		user.StatusPending:     user.ErrAccountPending,
+		user.StatusSuspended:   user.ErrAccountSuspended,
+		user.StatusLocked:      user.ErrAccountLocked,
+		user.StatusDeactivated: user.ErrAccountDeactivated,
+		"unknown":              user.ErrInvalidStatus,
+	} {
+		if err := user.StatusError(status); err != want {
+			t.Errorf("%s: err = %v, want %v", status, err, want)
+		}
+	}
+}
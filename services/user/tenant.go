@@ -0,0 +1,63 @@
+package user
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Tenant model struct, every user belongs to exactly one tenant
+type Tenant struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// tenantSlug lowercase dns label so slug can be used as subdomain
+var tenantSlug = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// NewTenant returns tenant of slug, name defaults to slug. Slug must be
+// lowercase dns label and not look like uuid since tenants are looked up by
+// either
+func NewTenant(slug, name string) (Tenant, error) {
+	if !tenantSlug.MatchString(slug) {
+		return Tenant{}, ErrInvalidTenantSlug
+	}
+	if _, err := uuid.FromString(slug); err == nil {
+		return Tenant{}, ErrInvalidTenantSlug
+	}
+	if name == "" {
+		name = slug
+	}
+	return Tenant{
+		ID:        uuid.NewV4(),
+		Slug:      slug,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// TenantRepository interface for tenant
+type TenantRepository interface {
+	GetTenant(ctx context.Context, key string) (*Tenant, error)
+	CreateTenant(ctx context.Context, tenant Tenant) error
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns context scoped to tenant
+func ContextWithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext get tenant id the context is scoped to
+func TenantFromContext(ctx context.Context) (uuid.UUID, error) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(uuid.UUID)
+	if !ok || tenantID == uuid.Nil {
+		return uuid.Nil, ErrTenantRequired
+	}
+	return tenantID, nil
+}
@@ -0,0 +1,11 @@
+package user
+
+import "context"
+
+// Transactor runs function as unit of work, repositories called with the
+// context given to function share its transaction. Unit of work started
+// within another one is nested in it, so rolling back the inner one only
+// undoes its own changes
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
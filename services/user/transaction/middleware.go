@@ -0,0 +1,325 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// transactionMiddleware runs service calls as unit of work, their
+// repository calls are committed together when call succeeds and rolled
+// back together when it fails. Register, Login and ChangePassword are passed
+// through so their password hashing does not hold pooled connection, service
+// runs their writes as unit of work of their own once hashing is done
+type transactionMiddleware struct {
+	next       user.Service
+	transactor user.Transactor
+}
+
+// NewMiddleware create service middleware running calls within transactor
+func NewMiddleware(transactor user.Transactor) user.ServiceMiddleware {
+	return func(next user.Service) user.Service {
+		return &transactionMiddleware{
+			next:       next,
+			transactor: transactor,
+		}
+	}
+}
+
+func (mw transactionMiddleware) Register(
+	ctx context.Context,
+	email, passwords string,
+) (string, error) {
+	return mw.next.Register(ctx, email, passwords)
+}
+
+func (mw transactionMiddleware) Login(
+	ctx context.Context,
+	email, passwords, deviceLabel string,
+) (string, error) {
+	return mw.next.Login(ctx, email, passwords, deviceLabel)
+}
+
+func (mw transactionMiddleware) GetMe(
+	ctx context.Context,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.GetMe(ctx)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) GetUser(
+	ctx context.Context,
+	id string,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.GetUser(ctx, id)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) UpdateUser(
+	ctx context.Context,
+	id string,
+	update user.Update,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.UpdateUser(ctx, id, update)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) ChangePassword(
+	ctx context.Context,
+	id, currentPassword, newPassword string,
+) error {
+	return mw.next.ChangePassword(ctx, id, currentPassword, newPassword)
+}
+
+func (mw transactionMiddleware) DeleteUser(
+	ctx context.Context,
+	id string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.DeleteUser(ctx, id)
+	})
+}
+
+func (mw transactionMiddleware) RestoreUser(
+	ctx context.Context,
+	id string,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.RestoreUser(ctx, id)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) SuspendUser(
+	ctx context.Context,
+	id, reason string,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.SuspendUser(ctx, id, reason)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) ReactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.ReactivateUser(ctx, id, reason)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) DeactivateUser(
+	ctx context.Context,
+	id, reason string,
+) (selectedUser *user.User, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		selectedUser, err = mw.next.DeactivateUser(ctx, id, reason)
+		return err
+	})
+	return selectedUser, err
+}
+
+func (mw transactionMiddleware) ListUsers(
+	ctx context.Context,
+	filter user.ListFilter,
+	orderBy string,
+	pageSize int,
+	pageToken string,
+) (users []user.User, nextPageToken string, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		users, nextPageToken, err = mw.next.ListUsers(ctx, filter, orderBy, pageSize, pageToken)
+		return err
+	})
+	return users, nextPageToken, err
+}
+
+func (mw transactionMiddleware) ListSessions(
+	ctx context.Context,
+) (sessions []user.Session, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		sessions, err = mw.next.ListSessions(ctx)
+		return err
+	})
+	return sessions, err
+}
+
+func (mw transactionMiddleware) RevokeSession(
+	ctx context.Context,
+	id string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.RevokeSession(ctx, id)
+	})
+}
+
+func (mw transactionMiddleware) ListAuditEvents(
+	ctx context.Context,
+	filter user.AuditFilter,
+	pageSize int,
+	pageToken string,
+) (events []user.AuditEvent, nextPageToken string, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, nextPageToken, err = mw.next.ListAuditEvents(ctx, filter, pageSize, pageToken)
+		return err
+	})
+	return events, nextPageToken, err
+}
+
+func (mw transactionMiddleware) CreateOrganization(
+	ctx context.Context,
+	name string,
+) (organization *user.Organization, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		organization, err = mw.next.CreateOrganization(ctx, name)
+		return err
+	})
+	return organization, err
+}
+
+func (mw transactionMiddleware) ListOrganizations(
+	ctx context.Context,
+) (organizations []user.Organization, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		organizations, err = mw.next.ListOrganizations(ctx)
+		return err
+	})
+	return organizations, err
+}
+
+func (mw transactionMiddleware) InviteMember(
+	ctx context.Context,
+	organizationID, email, role string,
+) (invitation *user.Invitation, token string, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		invitation, token, err = mw.next.InviteMember(ctx, organizationID, email, role)
+		return err
+	})
+	return invitation, token, err
+}
+
+func (mw transactionMiddleware) AcceptInvitation(
+	ctx context.Context,
+	token string,
+) (membership *user.Membership, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		membership, err = mw.next.AcceptInvitation(ctx, token)
+		return err
+	})
+	return membership, err
+}
+
+func (mw transactionMiddleware) DeclineInvitation(
+	ctx context.Context,
+	token string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.DeclineInvitation(ctx, token)
+	})
+}
+
+func (mw transactionMiddleware) ListMembers(
+	ctx context.Context,
+	organizationID string,
+) (members []user.Membership, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		members, err = mw.next.ListMembers(ctx, organizationID)
+		return err
+	})
+	return members, err
+}
+
+func (mw transactionMiddleware) ChangeMemberRole(
+	ctx context.Context,
+	organizationID, userID, role string,
+) (membership *user.Membership, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		membership, err = mw.next.ChangeMemberRole(ctx, organizationID, userID, role)
+		return err
+	})
+	return membership, err
+}
+
+func (mw transactionMiddleware) RemoveMember(
+	ctx context.Context,
+	organizationID, userID string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.RemoveMember(ctx, organizationID, userID)
+	})
+}
+
+func (mw transactionMiddleware) LeaveOrganization(
+	ctx context.Context,
+	organizationID string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.LeaveOrganization(ctx, organizationID)
+	})
+}
+
+func (mw transactionMiddleware) CreateWebhook(
+	ctx context.Context,
+	url string,
+	events []string,
+) (webhook *user.Webhook, secret string, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		webhook, secret, err = mw.next.CreateWebhook(ctx, url, events)
+		return err
+	})
+	return webhook, secret, err
+}
+
+func (mw transactionMiddleware) ListWebhooks(
+	ctx context.Context,
+) (webhooks []user.Webhook, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		webhooks, err = mw.next.ListWebhooks(ctx)
+		return err
+	})
+	return webhooks, err
+}
+
+func (mw transactionMiddleware) DeleteWebhook(
+	ctx context.Context,
+	id string,
+) error {
+	return mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return mw.next.DeleteWebhook(ctx, id)
+	})
+}
+
+func (mw transactionMiddleware) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID, status string,
+) (deliveries []user.WebhookDelivery, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		deliveries, err = mw.next.ListWebhookDeliveries(ctx, webhookID, status)
+		return err
+	})
+	return deliveries, err
+}
+
+func (mw transactionMiddleware) RedeliverWebhook(
+	ctx context.Context,
+	deliveryID string,
+) (delivery *user.WebhookDelivery, err error) {
+	err = mw.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		delivery, err = mw.next.RedeliverWebhook(ctx, deliveryID)
+		return err
+	})
+	return delivery, err
+}
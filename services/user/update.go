@@ -0,0 +1,41 @@
+package user
+
+// Field mask paths allowed to be updated
+const (
+	FieldEmail = "email"
+	FieldName  = "name"
+	FieldBio   = "bio"
+)
+
+// Update holds profile values and the field mask paths to apply,
+// fields outside of paths are left unchanged
+type Update struct {
+	Email string
+	Name  string
+	Bio   string
+	Paths []string
+}
+
+// Apply copy masked values into user and returns changed column values
+func (update Update) Apply(u *User) (map[string]interface{}, error) {
+	if len(update.Paths) == 0 {
+		return nil, ErrEmptyUpdateMask
+	}
+	columns := make(map[string]interface{}, len(update.Paths))
+	for _, path := range update.Paths {
+		switch path {
+		case FieldEmail:
+			u.Email = update.Email
+			columns["email"] = u.Email
+		case FieldName:
+			u.Name = update.Name
+			columns["name"] = u.Name
+		case FieldBio:
+			u.Bio = update.Bio
+			columns["bio"] = u.Bio
+		default:
+			return nil, ErrInvalidUpdateMask
+		}
+	}
+	return columns, nil
+}
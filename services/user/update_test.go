@@ -0,0 +1,49 @@
+package user_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+func TestUpdateApply(t *testing.T) {
+	update := user.Update{Email: "grace@example.com", Name: "Grace", Bio: "Admiral"}
+	tests := []struct {
+		name    string
+		paths   []string
+		columns map[string]interface{}
+		err     error
+	}{
+		{"single field", []string{user.FieldName}, map[string]interface{}{"name": "Grace"}, nil},
+		{"every field", []string{user.FieldEmail, user.FieldName, user.FieldBio}, map[string]interface{}{
+			"email": "grace@example.com",
+			"name":  "Grace",
+			"bio":   "Admiral",
+		}, nil},
+		{"empty mask", nil, nil, user.ErrEmptyUpdateMask},
+		{"role is not mutable", []string{user.FieldName, "role"}, nil, user.ErrInvalidUpdateMask},
+		{"password is not mutable", []string{"passwords"}, nil, user.ErrInvalidUpdateMask},
+	}
+	for _, tt := range tests {
+		u := user.User{Email: "ada@example.com", Name: "Ada", Bio: "Countess", Role: user.RoleUser}
+		update.Paths = tt.paths
+		columns, err := update.Apply(&u)
+		if err != tt.err {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(columns, tt.columns) {
+			t.Errorf("%s: columns %v, want %v", tt.name, columns, tt.columns)
+		}
+		// Fields outside of mask are left as they were
+		if _, ok := columns["email"]; !ok && u.Email != "ada@example.com" {
+			t.Errorf("%s: email changed to %q", tt.name, u.Email)
+		}
+		if _, ok := columns["bio"]; !ok && u.Bio != "Countess" {
+			t.Errorf("%s: bio changed to %q", tt.name, u.Bio)
+		}
+	}
+}
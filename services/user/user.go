@@ -7,17 +7,44 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-// User model struct
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// RetentionPeriod how long soft deleted user can be restored before purged
+const RetentionPeriod = 30 * 24 * time.Hour
+
+// User model struct, failed logins count those since last successful login
+// or status change
 type User struct {
-	ID        uuid.UUID `json:"id,omitempty" db:"id"`
-	Email     string    `json:"email"`
-	Passwords string    `json:"passwords"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id,omitempty" db:"id"`
+	TenantID     uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Email        string     `json:"email"`
+	Passwords    string     `json:"passwords"`
+	Name         string     `json:"name"`
+	Bio          string     `json:"bio"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	FailedLogins int        `json:"failed_logins" db:"failed_logins"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
 }
 
-// Repository interface for user
+// Repository interface for user, every query is scoped to tenant of context
+// except purging which runs across tenants
 type Repository interface {
 	Register(ctx context.Context, user User) error
 	Login(ctx context.Context, email, passwords string) (*User, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*User, error)
+	UpdateUser(ctx context.Context, id uuid.UUID, columns map[string]interface{}) error
+	ChangePassword(ctx context.Context, id uuid.UUID, hashedPassword, changedBy string, changedAt time.Time) error
+	DeleteUser(ctx context.Context, id uuid.UUID, deletedAt time.Time) error
+	RestoreUser(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error
+	PurgeUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	ChangeStatus(ctx context.Context, change StatusChange) error
+	FailLogin(ctx context.Context, id uuid.UUID) (int, error)
+	ListUsers(ctx context.Context, query ListQuery) ([]User, error)
 }
@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Webhook delivery statuses, delivery is dead after MaxDeliveryAttempts
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// MaxDeliveryAttempts failed attempts before delivery is dead lettered
+const MaxDeliveryAttempts = 8
+
+// Headers sent with every webhook delivery
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// webhookEvents event types webhook can subscribe to
+var webhookEvents = map[string]bool{
+	EventUserRegistered:  true,
+	EventUserLoggedIn:    true,
+	EventPasswordChanged: true,
+	EventUserDeleted:     true,
+}
+
+// Webhook model struct, events are stored comma separated and secret is
+// only revealed when webhook is created
+type Webhook struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	URL       string    `json:"url" db:"url"`
+	Events    string    `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedBy uuid.UUID `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery model struct, one delivery of event to webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	TenantID       uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	WebhookID      uuid.UUID  `json:"webhook_id" db:"webhook_id"`
+	EventID        uuid.UUID  `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code" db:"last_status_code"`
+	LastError      string     `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// DueDelivery delivery due for attempt with url and secret of its webhook
+type DueDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// DeliveryAttempt outcome of delivery attempt
+type DeliveryAttempt struct {
+	Status        string
+	StatusCode    int
+	Error         string
+	AttemptedAt   time.Time
+	NextAttemptAt time.Time
+}
+
+// WebhookRepository interface for webhook, due deliveries and subscribers
+// are looked up across tenants by workers, the rest is scoped to tenant of
+// context. Claimed deliveries are leased to caller so dispatchers of other
+// processes skip them until lease expires
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook Webhook) error
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	Subscribers(ctx context.Context, tenantID uuid.UUID, eventType string) ([]Webhook, error)
+	CreateDeliveries(ctx context.Context, deliveries []WebhookDelivery) error
+	ClaimDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]DueDelivery, error)
+	RecordAttempt(ctx context.Context, id uuid.UUID, attempt DeliveryAttempt) error
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]WebhookDelivery, error)
+	Redeliver(ctx context.Context, id uuid.UUID, now time.Time) (*WebhookDelivery, error)
+}
+
+// Subscribes tells whether webhook subscribed to event type
+func (webhook Webhook) Subscribes(eventType string) bool {
+	for _, subscribed := range webhook.EventTypes() {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// EventTypes returns event types webhook subscribed to
+func (webhook Webhook) EventTypes() []string {
+	return strings.Split(webhook.Events, ",")
+}
+
+// ParseWebhookEvents validate subscribed event types and join them
+func ParseWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", ErrInvalidWebhookEvent
+	}
+	for _, event := range events {
+		if !webhookEvents[event] {
+			return "", ErrInvalidWebhookEvent
+		}
+	}
+	return strings.Join(events, ","), nil
+}
+
+// privateNetworks networks not reachable from internet, webhooks must not
+// be pointed into them
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"fc00::/7",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// ValidWebhookURL tells whether url is absolute http or https url of public
+// host, addresses host name resolves to are checked again when dialing
+func ValidWebhookURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return PublicIP(ip)
+	}
+	return true
+}
+
+// PublicIP tells whether ip is reachable from internet, loopback, link local,
+// private and other special purpose addresses are not
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWebhookSecret generate random signing secret
+func NewWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// SignWebhook compute signature header value of body sent at timestamp,
+// receivers recompute it over "<timestamp>.<body>" to verify delivery
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
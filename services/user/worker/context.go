@@ -0,0 +1,20 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// detached context of parent values which is never cancelled, batch in
+// progress runs with it so stopping worker lets the batch finish
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detached) Done() <-chan struct{} { return nil }
+
+func (detached) Err() error { return nil }
+
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// Retry back off bounds of failed outbox event
+const (
+	MinRetryDelay = time.Second
+	MaxRetryDelay = time.Hour
+)
+
+// EventLease how long claimed events are held back from relays of other
+// processes, batch must be published within it
+const EventLease = time.Minute
+
+// Relay periodically publishes pending outbox events. Delivery is at least
+// once, events of the same aggregate are published in order so an event
+// waiting for retry holds back later events of its aggregate until it is
+// dead lettered
+type Relay struct {
+	outbox    user.OutboxRepository
+	publisher user.Publisher
+	logger    log.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+// NewRelay create instance of Relay struct
+func NewRelay(
+	outbox user.OutboxRepository,
+	publisher user.Publisher,
+	logger log.Logger,
+	interval time.Duration,
+	batchSize int,
+) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		logger:    log.With(logger, "worker", "outbox"),
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run relay pending events every interval until context is done, batch in
+// progress is finished before returning
+func (relay *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+	for {
+		relay.Relay(detached{parent: ctx})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publish one batch of claimed events, claimed events head their
+// aggregates so they are published independently of each other
+func (relay *Relay) Relay(ctx context.Context) {
+	events, err := relay.outbox.ClaimEvents(ctx, relay.batchSize, time.Now(), EventLease)
+	if err != nil {
+		_ = level.Error(relay.logger).Log("err", err)
+		return
+	}
+	for _, event := range events {
+		now := time.Now()
+		if err := relay.publisher.Publish(ctx, event); err != nil {
+			relay.retry(ctx, event, now, err)
+			continue
+		}
+		if err := relay.outbox.MarkPublished(ctx, event.ID, now); err != nil {
+			_ = level.Error(relay.logger).Log("event", event.ID, "err", err)
+			return
+		}
+	}
+}
+
+// retry schedules next attempt of failed event, event failing
+// MaxEventAttempts times is dead lettered instead
+func (relay *Relay) retry(
+	ctx context.Context,
+	event user.Event,
+	now time.Time,
+	cause error,
+) {
+	if event.Attempts+1 >= user.MaxEventAttempts {
+		_ = level.Error(relay.logger).Log(
+			"event", event.ID,
+			"type", event.Type,
+			"attempts", event.Attempts+1,
+			"status", "dead",
+			"err", cause,
+		)
+		err := relay.outbox.MarkDead(ctx, event.ID, now, cause.Error())
+		if err != nil {
+			_ = level.Error(relay.logger).Log("event", event.ID, "err", err)
+		}
+		return
+	}
+	nextAttemptAt := now.Add(RetryDelay(event.Attempts + 1))
+	_ = level.Warn(relay.logger).Log(
+		"event", event.ID,
+		"type", event.Type,
+		"attempts", event.Attempts+1,
+		"next_attempt_at", nextAttemptAt,
+		"err", cause,
+	)
+	err := relay.outbox.MarkFailed(ctx, event.ID, nextAttemptAt, cause.Error())
+	if err != nil {
+		_ = level.Error(relay.logger).Log("event", event.ID, "err", err)
+	}
+}
+
+// RetryDelay exponential back off after given failed attempts
+func RetryDelay(attempts int) time.Duration {
+	delay := MinRetryDelay
+	for i := 1; i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxRetryDelay {
+		return MaxRetryDelay
+	}
+	return delay
+}
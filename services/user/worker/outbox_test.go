@@ -0,0 +1,96 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/worker"
+)
+
+// outbox fake outbox handing out its events once and keeping what became
+// of each of them
+type outbox struct {
+	events []user.Event
+	marked map[uuid.UUID]string
+	next   map[uuid.UUID]time.Time
+}
+
+func (repo *outbox) ClaimEvents(context.Context, int, time.Time, time.Duration) ([]user.Event, error) {
+	claimed := repo.events
+	repo.events = nil
+	return claimed, nil
+}
+
+func (repo *outbox) MarkPublished(_ context.Context, id uuid.UUID, _ time.Time) error {
+	repo.marked[id] = "published"
+	return nil
+}
+
+func (repo *outbox) MarkFailed(_ context.Context, id uuid.UUID, nextAttemptAt time.Time, _ string) error {
+	repo.marked[id] = "failed"
+	repo.next[id] = nextAttemptAt
+	return nil
+}
+
+func (repo *outbox) MarkDead(_ context.Context, id uuid.UUID, _ time.Time, _ string) error {
+	repo.marked[id] = "dead"
+	return nil
+}
+
+// publisher fake publisher failing events of aggregates in failing
+type publisher struct {
+	failing map[uuid.UUID]bool
+}
+
+func (p publisher) Publish(_ context.Context, event user.Event) error {
+	if p.failing[event.AggregateID] {
+		return errors.New("broker is down")
+	}
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	healthy, failing := uuid.NewV4(), uuid.NewV4()
+	published := user.Event{ID: uuid.NewV4(), AggregateID: healthy}
+	retried := user.Event{ID: uuid.NewV4(), AggregateID: failing, Attempts: 2}
+	dead := user.Event{ID: uuid.NewV4(), AggregateID: failing, Attempts: user.MaxEventAttempts - 1}
+	repo := &outbox{
+		events: []user.Event{published, retried, dead},
+		marked: map[uuid.UUID]string{},
+		next:   map[uuid.UUID]time.Time{},
+	}
+	relay := worker.NewRelay(repo, publisher{failing: map[uuid.UUID]bool{failing: true}}, log.NewNopLogger(), time.Second, 10)
+
+	before := time.Now()
+	relay.Relay(context.Background())
+	for event, want := range map[uuid.UUID]string{published.ID: "published", retried.ID: "failed", dead.ID: "dead"} {
+		if repo.marked[event] != want {
+			t.Errorf("event %s marked %q, want %q", event, repo.marked[event], want)
+		}
+	}
+	// Third attempt is retried after back off of third failure
+	if delay := repo.next[retried.ID].Sub(before); delay < worker.RetryDelay(3) || delay > worker.RetryDelay(3)+time.Second {
+		t.Errorf("retry in %s, want %s", delay, worker.RetryDelay(3))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  worker.MinRetryDelay,
+		2:  2 * time.Second,
+		5:  16 * time.Second,
+		12: 2048 * time.Second,
+		13: worker.MaxRetryDelay,
+		40: worker.MaxRetryDelay,
+	} {
+		if delay := worker.RetryDelay(attempts); delay != want {
+			t.Errorf("after %d attempts: %s, want %s", attempts, delay, want)
+		}
+	}
+}
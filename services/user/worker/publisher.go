@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// logPublisher publishes events into log, used until message broker is
+// configured
+type logPublisher struct {
+	logger log.Logger
+}
+
+// NewLogPublisher create publisher writing events into logger
+func NewLogPublisher(logger log.Logger) user.Publisher {
+	return &logPublisher{
+		logger: log.With(logger, "publisher", "log"),
+	}
+}
+
+// Publish log json encoded event
+func (publisher *logPublisher) Publish(
+	_ context.Context,
+	event user.Event,
+) error {
+	content, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return level.Info(publisher.logger).Log("event", string(content))
+}
+
+// webhookPublisher publishes events by scheduling delivery to every webhook
+// subscribed to them
+type webhookPublisher struct {
+	webhooks user.WebhookRepository
+}
+
+// NewWebhookPublisher create publisher fanning events out to webhooks
+func NewWebhookPublisher(webhooks user.WebhookRepository) user.Publisher {
+	return &webhookPublisher{
+		webhooks: webhooks,
+	}
+}
+
+// Publish schedule delivery of json encoded event to subscribed webhooks
+func (publisher *webhookPublisher) Publish(
+	ctx context.Context,
+	event user.Event,
+) error {
+	subscribers, err := publisher.webhooks.Subscribers(ctx, event.TenantID, event.Type)
+	if err != nil || len(subscribers) == 0 {
+		return err
+	}
+	content, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deliveries := make([]user.WebhookDelivery, 0, len(subscribers))
+	for _, webhook := range subscribers {
+		deliveries = append(deliveries, user.WebhookDelivery{
+			ID:            uuid.NewV4(),
+			TenantID:      webhook.TenantID,
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(content),
+			Status:        user.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return publisher.webhooks.CreateDeliveries(ctx, deliveries)
+}
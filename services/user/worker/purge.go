@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// Purger periodically hard deletes users whose retention period expired
+type Purger struct {
+	repository user.Repository
+	logger     log.Logger
+	interval   time.Duration
+}
+
+// NewPurger create instance of Purger struct
+func NewPurger(
+	repo user.Repository,
+	logger log.Logger,
+	interval time.Duration,
+) *Purger {
+	return &Purger{
+		repository: repo,
+		logger:     log.With(logger, "worker", "purge"),
+		interval:   interval,
+	}
+}
+
+// Run purge expired users every interval until context is done, batch in
+// progress is finished before returning
+func (purger *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+	for {
+		purger.Purge(detached{parent: ctx})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard deletes users soft deleted before retention period
+func (purger *Purger) Purge(ctx context.Context) {
+	deletedBefore := time.Now().Add(-user.RetentionPeriod)
+	purged, err := purger.repository.PurgeUsers(ctx, deletedBefore)
+	if err != nil {
+		_ = level.Error(purger.logger).Log("err", err)
+		return
+	}
+	_ = level.Info(purger.logger).Log("purged", purged)
+}
@@ -0,0 +1,61 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/repository/memory"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/worker"
+)
+
+func TestPurgeExpiredUsers(t *testing.T) {
+	repo := memory.NewUserRepository()
+	now := time.Now()
+	// Users of every tenant are purged
+	deleted := map[string]time.Time{
+		"expired":       now.Add(-user.RetentionPeriod - time.Hour),
+		"other tenant":  now.Add(-user.RetentionPeriod - time.Hour),
+		"within period": now.Add(-user.RetentionPeriod + time.Hour),
+		"not deleted":   {},
+	}
+	contexts := map[string]context.Context{}
+	ids := map[string]uuid.UUID{}
+	tenant := user.ContextWithTenant(context.Background(), uuid.NewV4())
+	for name, deletedAt := range deleted {
+		ctx := tenant
+		if name == "other tenant" {
+			ctx = user.ContextWithTenant(context.Background(), uuid.NewV4())
+		}
+		u := user.User{ID: uuid.NewV4(), Email: name + "@example.com", Status: user.StatusActive, CreatedAt: now}
+		if err := repo.Register(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		if !deletedAt.IsZero() {
+			if err := repo.DeleteUser(ctx, u.ID, deletedAt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		contexts[name], ids[name] = ctx, u.ID
+	}
+
+	worker.NewPurger(repo, log.NewNopLogger(), time.Hour).Purge(context.Background())
+
+	for name, want := range map[string]error{
+		"expired":       user.ErrUserNotFound,
+		"other tenant":  user.ErrUserNotFound,
+		"within period": nil,
+	} {
+		err := repo.RestoreUser(contexts[name], ids[name], time.Time{})
+		if err != want {
+			t.Errorf("restore %s: err = %v, want %v", name, err, want)
+		}
+	}
+	if _, err := repo.GetUser(contexts["not deleted"], ids["not deleted"]); err != nil {
+		t.Errorf("get user not deleted: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+)
+
+// DeliveryLease how long claimed deliveries are held back from dispatchers
+// of other processes, deliveries not attempted within it are claimed again
+const DeliveryLease = time.Minute
+
+// ErrPrivateAddress returned when webhook host resolves to address which is
+// not reachable from internet
+var ErrPrivateAddress = errors.New("webhook host resolves to private address")
+
+// NewWebhookClient create http client of webhook deliveries, connections to
+// addresses which are not public are refused so host names resolving into
+// private networks cannot reach internal services
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !user.PublicIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Proxy would be dialed instead of webhook host
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// Dispatcher periodically delivers due webhook deliveries, failed delivery
+// is retried with exponential back off until it is dead lettered. Webhooks
+// are delivered to concurrently and deliveries of each in order, so slow or
+// dead endpoint holds back only its own deliveries
+type Dispatcher struct {
+	webhooks    user.WebhookRepository
+	client      *http.Client
+	logger      log.Logger
+	interval    time.Duration
+	batchSize   int
+	concurrency int
+}
+
+// NewDispatcher create instance of Dispatcher struct delivering to at most
+// concurrency webhooks at once
+func NewDispatcher(
+	webhooks user.WebhookRepository,
+	client *http.Client,
+	logger log.Logger,
+	interval time.Duration,
+	batchSize, concurrency int,
+) *Dispatcher {
+	return &Dispatcher{
+		webhooks:    webhooks,
+		client:      client,
+		logger:      log.With(logger, "worker", "webhook"),
+		interval:    interval,
+		batchSize:   batchSize,
+		concurrency: concurrency,
+	}
+}
+
+// Run dispatch due deliveries every interval until context is done, batch in
+// progress is finished before returning
+func (dispatcher *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(dispatcher.interval)
+	defer ticker.Stop()
+	for {
+		dispatcher.Dispatch(detached{parent: ctx})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch attempt one batch of claimed deliveries grouped by webhook
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context) {
+	now := time.Now()
+	deliveries, err := dispatcher.webhooks.ClaimDeliveries(
+		ctx,
+		now,
+		dispatcher.batchSize,
+		DeliveryLease,
+	)
+	if err != nil {
+		_ = level.Error(dispatcher.logger).Log("err", err)
+		return
+	}
+	var webhooks []uuid.UUID
+	pending := make(map[uuid.UUID][]user.DueDelivery)
+	for _, delivery := range deliveries {
+		if _, ok := pending[delivery.WebhookID]; !ok {
+			webhooks = append(webhooks, delivery.WebhookID)
+		}
+		pending[delivery.WebhookID] = append(pending[delivery.WebhookID], delivery)
+	}
+	// Attempt started later could outlive lease and be sent twice
+	deadline := now.Add(DeliveryLease - dispatcher.client.Timeout)
+	slots := make(chan struct{}, dispatcher.concurrency)
+	var wg sync.WaitGroup
+	for _, webhookID := range webhooks {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(deliveries []user.DueDelivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			dispatcher.deliver(ctx, deliveries, deadline)
+		}(pending[webhookID])
+	}
+	wg.Wait()
+}
+
+// deliver attempt deliveries of one webhook in order until one fails or
+// deadline passes, the rest are claimed again once their lease expires so
+// dead endpoint costs one timeout per batch
+func (dispatcher *Dispatcher) deliver(
+	ctx context.Context,
+	deliveries []user.DueDelivery,
+	deadline time.Time,
+) {
+	for _, delivery := range deliveries {
+		if time.Now().After(deadline) {
+			return
+		}
+		attempt := dispatcher.attempt(ctx, delivery)
+		if attempt.Status == user.DeliveryDead {
+			_ = level.Warn(dispatcher.logger).Log(
+				"delivery", delivery.ID,
+				"webhook", delivery.WebhookID,
+				"err", attempt.Error,
+				"msg", "delivery dead lettered",
+			)
+		}
+		err := dispatcher.webhooks.RecordAttempt(ctx, delivery.ID, attempt)
+		if err != nil {
+			_ = level.Error(dispatcher.logger).Log("delivery", delivery.ID, "err", err)
+		}
+		if attempt.Status != user.DeliveryDelivered {
+			return
+		}
+	}
+}
+
+// attempt post signed payload to webhook url, any 2xx response is success
+func (dispatcher *Dispatcher) attempt(
+	ctx context.Context,
+	delivery user.DueDelivery,
+) user.DeliveryAttempt {
+	now := time.Now()
+	attempt := user.DeliveryAttempt{
+		Status:        user.DeliveryDelivered,
+		AttemptedAt:   now,
+		NextAttemptAt: now,
+	}
+	statusCode, err := dispatcher.post(ctx, delivery, now)
+	attempt.StatusCode = statusCode
+	if err == nil {
+		return attempt
+	}
+	attempt.Error = err.Error()
+	attempt.Status = user.DeliveryPending
+	attempt.NextAttemptAt = now.Add(RetryDelay(delivery.Attempts + 1))
+	if delivery.Attempts+1 >= user.MaxDeliveryAttempts {
+		attempt.Status = user.DeliveryDead
+	}
+	return attempt
+}
+
+// post send delivery payload with signature headers
+func (dispatcher *Dispatcher) post(
+	ctx context.Context,
+	delivery user.DueDelivery,
+	now time.Time,
+) (int, error) {
+	body := []byte(delivery.Payload)
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		delivery.URL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(user.WebhookIDHeader, delivery.ID.String())
+	request.Header.Set(user.WebhookEventHeader, delivery.EventType)
+	request.Header.Set(user.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(
+		user.WebhookSignatureHeader,
+		user.SignWebhook(delivery.Secret, timestamp, body),
+	)
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
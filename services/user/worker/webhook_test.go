@@ -0,0 +1,195 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/worker"
+)
+
+// webhookRepository fake repository handing out deliveries once and keeping
+// attempts recorded for them
+type webhookRepository struct {
+	user.WebhookRepository
+	mutex      sync.Mutex
+	deliveries []user.DueDelivery
+	attempts   map[uuid.UUID]user.DeliveryAttempt
+}
+
+func (repo *webhookRepository) ClaimDeliveries(
+	_ context.Context,
+	_ time.Time,
+	limit int,
+	_ time.Duration,
+) ([]user.DueDelivery, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	claimed := repo.deliveries
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	repo.deliveries = repo.deliveries[len(claimed):]
+	return claimed, nil
+}
+
+func (repo *webhookRepository) RecordAttempt(
+	_ context.Context,
+	id uuid.UUID,
+	attempt user.DeliveryAttempt,
+) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	repo.attempts[id] = attempt
+	return nil
+}
+
+// newDelivery create delivery due to url which failed given attempts
+func newDelivery(webhookID uuid.UUID, url string, attempts int) user.DueDelivery {
+	return user.DueDelivery{
+		WebhookDelivery: user.WebhookDelivery{
+			ID:        uuid.NewV4(),
+			TenantID:  uuid.NewV4(),
+			WebhookID: webhookID,
+			EventID:   uuid.NewV4(),
+			EventType: user.EventUserRegistered,
+			Payload:   `{"type":"UserRegistered"}`,
+			Status:    user.DeliveryPending,
+			Attempts:  attempts,
+		},
+		URL:    url,
+		Secret: "secret",
+	}
+}
+
+// dispatch run one batch of deliveries against server
+func dispatch(t *testing.T, server *httptest.Server, deliveries ...user.DueDelivery) *webhookRepository {
+	t.Helper()
+	repo := &webhookRepository{
+		deliveries: deliveries,
+		attempts:   map[uuid.UUID]user.DeliveryAttempt{},
+	}
+	client := server.Client()
+	client.Timeout = time.Second
+	worker.NewDispatcher(repo, client, log.NewNopLogger(), time.Second, 10, 2).
+		Dispatch(context.Background())
+	return repo
+}
+
+func TestDispatcherSignsDelivery(t *testing.T) {
+	delivery := newDelivery(uuid.NewV4(), "", 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(user.WebhookTimestampHeader), 10, 64)
+		switch {
+		case err != nil:
+			t.Errorf("timestamp header: %v", err)
+		case r.Header.Get(user.WebhookSignatureHeader) != user.SignWebhook("secret", timestamp, body):
+			t.Errorf("signature does not match body")
+		case r.Header.Get(user.WebhookIDHeader) != delivery.ID.String():
+			t.Errorf("delivery id header = %q", r.Header.Get(user.WebhookIDHeader))
+		case r.Header.Get(user.WebhookEventHeader) != delivery.EventType:
+			t.Errorf("event header = %q", r.Header.Get(user.WebhookEventHeader))
+		case string(body) != delivery.Payload:
+			t.Errorf("body = %q", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	delivery.URL = server.URL
+
+	repo := dispatch(t, server, delivery)
+	attempt := repo.attempts[delivery.ID]
+	if attempt.Status != user.DeliveryDelivered || attempt.StatusCode != http.StatusNoContent {
+		t.Fatalf("attempt = %+v, want delivered with 204", attempt)
+	}
+}
+
+func TestDispatcherBacksOffFailedDelivery(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	webhookID := uuid.NewV4()
+	failed := newDelivery(webhookID, server.URL, 2)
+	next := newDelivery(webhookID, server.URL, 0)
+
+	repo := dispatch(t, server, failed, next)
+	attempt := repo.attempts[failed.ID]
+	if attempt.Status != user.DeliveryPending || attempt.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("attempt = %+v, want pending with 500", attempt)
+	}
+	if delay := attempt.NextAttemptAt.Sub(attempt.AttemptedAt); delay != worker.RetryDelay(3) {
+		t.Fatalf("retry delay = %v, want %v", delay, worker.RetryDelay(3))
+	}
+	// Later delivery of failing webhook waits for its lease to expire
+	if _, ok := repo.attempts[next.ID]; ok || requests != 1 {
+		t.Fatalf("requests = %d, want only failed delivery attempted", requests)
+	}
+}
+
+func TestDispatcherDeadLettersDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	delivery := newDelivery(uuid.NewV4(), server.URL, user.MaxDeliveryAttempts-1)
+
+	repo := dispatch(t, server, delivery)
+	if attempt := repo.attempts[delivery.ID]; attempt.Status != user.DeliveryDead {
+		t.Fatalf("attempt = %+v, want dead", attempt)
+	}
+}
+
+func TestDispatcherDeliversOtherWebhooks(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+	stalled := newDelivery(uuid.NewV4(), slow.URL, 0)
+	delivered := newDelivery(uuid.NewV4(), fast.URL, 0)
+
+	started := time.Now()
+	repo := dispatch(t, fast, stalled, delivered)
+	attempt := repo.attempts[delivered.ID]
+	if attempt.Status != user.DeliveryDelivered {
+		t.Fatalf("attempt = %+v, want delivered", attempt)
+	}
+	// Stalled webhook times out after a second, other one must not wait
+	if waited := attempt.AttemptedAt.Sub(started); waited > 500*time.Millisecond {
+		t.Fatalf("delivery waited %v for stalled webhook", waited)
+	}
+	if attempt := repo.attempts[stalled.ID]; attempt.Status != user.DeliveryPending {
+		t.Fatalf("attempt = %+v, want pending after timeout", attempt)
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("private address was reached")
+	}))
+	defer server.Close()
+
+	_, err := worker.NewWebhookClient(time.Second).Get(server.URL)
+	if !errors.Is(err, worker.ErrPrivateAddress) {
+		t.Fatalf("err = %v, want %v", err, worker.ErrPrivateAddress)
+	}
+}
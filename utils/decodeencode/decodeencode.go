@@ -4,8 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"reflect"
+
+	kitjwt "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/transport/http/jsonrpc"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user"
+	"github.com/muhammadisa/go-kit-boilerplate/services/user/auth"
+	"github.com/nats-io/nats.go"
+	"github.com/streadway/amqp"
 )
 
+// StatusClientClosedRequest non standard http status of requests client
+// canceled before response was written
+const StatusClientClosedRequest = 499
+
 // Custom error type for business logic error
 type errorer interface {
 	error() error
@@ -14,11 +26,123 @@ type errorer interface {
 // Identify error and returns http error code
 func codeFrom(err error) int {
 	switch err {
+	case user.ErrUserNotFound,
+		user.ErrTenantNotFound,
+		user.ErrOrganizationNotFound,
+		user.ErrNotMember,
+		user.ErrInvitationNotFound,
+		user.ErrSessionNotFound,
+		user.ErrWebhookNotFound,
+		user.ErrDeliveryNotFound:
+		return http.StatusNotFound
+	case user.ErrInvalidUserID,
+		user.ErrTenantRequired,
+		user.ErrInvalidOrgID,
+		user.ErrInvalidOrgRole,
+		user.ErrInvalidSessionID,
+		user.ErrInvalidWebhookID,
+		user.ErrInvalidWebhookURL,
+		user.ErrInvalidWebhookEvent,
+		user.ErrInvalidDeliveryID,
+		user.ErrEmptyUpdateMask,
+		user.ErrInvalidUpdateMask,
+		user.ErrInvalidOrderBy,
+		user.ErrInvalidPageToken:
+		return http.StatusBadRequest
+	case user.ErrInvalidCredentials,
+		auth.ErrUnauthenticated,
+		user.ErrSessionRevoked,
+		kitjwt.ErrTokenContextMissing,
+		kitjwt.ErrTokenInvalid,
+		kitjwt.ErrTokenExpired,
+		kitjwt.ErrTokenMalformed,
+		kitjwt.ErrTokenNotActive,
+		kitjwt.ErrUnexpectedSigningMethod:
+		return http.StatusUnauthorized
+	case user.ErrPermissionDenied,
+		user.ErrTenantMismatch,
+		user.ErrInvitationEmail,
+		user.ErrAccountPending,
+		user.ErrAccountSuspended,
+		user.ErrAccountLocked,
+		user.ErrAccountDeactivated:
+		return http.StatusForbidden
+	case user.ErrStatusTransition,
+		user.ErrAlreadyMember,
+		user.ErrEmailTaken,
+		user.ErrLastOwner:
+		return http.StatusConflict
+	case user.ErrInvitationExpired:
+		return http.StatusGone
+	case context.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case context.Canceled:
+		return StatusClientClosedRequest
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
+// Json rpc error codes of domain errors, implementation defined range of
+// -32000 to -32099 is used for errors json rpc has no code of
+const (
+	JSONRPCUnauthenticated  = -32001
+	JSONRPCPermissionDenied = -32003
+	JSONRPCNotFound         = -32004
+	JSONRPCConflict         = -32009
+	JSONRPCGone             = -32010
+	JSONRPCDeadlineExceeded = -32008
+	JSONRPCCanceled         = -32099
+)
+
+// JSONRPCErrorCode identify error and returns json rpc error code
+func JSONRPCErrorCode(err error) int {
+	switch codeFrom(err) {
+	case http.StatusBadRequest:
+		return jsonrpc.InvalidParamsError
+	case http.StatusUnauthorized:
+		return JSONRPCUnauthenticated
+	case http.StatusForbidden:
+		return JSONRPCPermissionDenied
+	case http.StatusNotFound:
+		return JSONRPCNotFound
+	case http.StatusConflict:
+		return JSONRPCConflict
+	case http.StatusGone:
+		return JSONRPCGone
+	case http.StatusGatewayTimeout:
+		return JSONRPCDeadlineExceeded
+	case StatusClientClosedRequest:
+		return JSONRPCCanceled
+	default:
+		return jsonrpc.InternalError
+	}
+}
+
+// GraphQLErrorCode identify error and returns graphql error extension code
+func GraphQLErrorCode(err error) string {
+	switch codeFrom(err) {
+	case http.StatusBadRequest:
+		return "BAD_USER_INPUT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusGone:
+		return "GONE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	case StatusClientClosedRequest:
+		return "CANCELED"
+	default:
+		return "INTERNAL_SERVER_ERROR"
+	}
+}
+
 // EncodeErrorResponse error response decoder for all services
 func EncodeErrorResponse(_ context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
@@ -44,3 +168,85 @@ func EncodeResponse(
 	}
 	return json.NewEncoder(w).Encode(response)
 }
+
+// EncodeNATSErrorResponse error response encoder for nats subscribers, reply
+// has no status so http status code is sent along with error
+func EncodeNATSErrorResponse(
+	_ context.Context,
+	err error,
+	reply string,
+	nc *nats.Conn,
+) {
+	content, _ := json.Marshal(map[string]interface{}{
+		"error": err.Error(),
+		"code":  codeFrom(err),
+	})
+	_ = nc.Publish(reply, content)
+}
+
+// EncodeNATSResponse encode response of nats subscribers
+func EncodeNATSResponse(
+	ctx context.Context,
+	reply string,
+	nc *nats.Conn,
+	response interface{},
+) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		EncodeNATSErrorResponse(ctx, e.error(), reply, nc)
+		return nil
+	}
+	content, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return nc.Publish(reply, content)
+}
+
+// EncodeAMQPErrorResponse error response encoder for amqp subscribers, body
+// is same as nats error reply
+func EncodeAMQPErrorResponse(
+	_ context.Context,
+	err error,
+	pub *amqp.Publishing,
+) {
+	pub.ContentType = "application/json"
+	pub.Body, _ = json.Marshal(map[string]interface{}{
+		"error": err.Error(),
+		"code":  codeFrom(err),
+	})
+}
+
+// EncodeAMQPResponse encode response of amqp subscribers into publishing
+func EncodeAMQPResponse(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	response interface{},
+) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		EncodeAMQPErrorResponse(ctx, e.error(), pub)
+		return nil
+	}
+	content, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	pub.ContentType = "application/json"
+	pub.Body = content
+	return nil
+}
+
+// DecodeRequest create decoder of json data into request of same type as
+// given request, shared by transports which carry requests as raw json.
+// Empty or null data decodes into zero request
+func DecodeRequest(request interface{}) func(data []byte) (interface{}, error) {
+	requestType := reflect.TypeOf(request)
+	return func(data []byte) (interface{}, error) {
+		req := reflect.New(requestType)
+		if len(data) > 0 && string(data) != "null" {
+			if err := json.Unmarshal(data, req.Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return req.Elem().Interface(), nil
+	}
+}
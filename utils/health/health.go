@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Paths probes are served on
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// MetricsPath path expvar metrics are served on by admin listener only
+const MetricsPath = "/debug/vars"
+
+// pollInterval how often in flight requests are checked while waiting
+const pollInterval = 50 * time.Millisecond
+
+// Probe readiness of process and its in flight http requests, it is ready
+// from creation until shutdown begins and stays alive until process exits
+type Probe struct {
+	notReady int32
+	inFlight int64
+	grpc     *health.Server
+}
+
+// NewProbe create instance of Probe struct
+func NewProbe() *Probe {
+	return &Probe{grpc: health.NewServer()}
+}
+
+// Ready returns whether process accepts new requests
+func (p *Probe) Ready() bool {
+	return atomic.LoadInt32(&p.notReady) == 0
+}
+
+// Shutdown flip probe to not ready, grpc health service reports not serving
+// from then on
+func (p *Probe) Shutdown() {
+	atomic.StoreInt32(&p.notReady, 1)
+	p.grpc.Shutdown()
+}
+
+// GRPCServer returns grpc health service reporting readiness of probe
+func (p *Probe) GRPCServer() grpc_health_v1.HealthServer {
+	return p.grpc
+}
+
+// Handler serve liveness and readiness paths and pass other
+// requests to next counting them as in flight until they are served
+func (p *Probe) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LivenessPath:
+			w.WriteHeader(http.StatusOK)
+		case ReadinessPath:
+			if !p.Ready() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			atomic.AddInt64(&p.inFlight, 1)
+			defer atomic.AddInt64(&p.inFlight, -1)
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Wait block until no request is in flight or context is done
+func (p *Probe) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&p.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}